@@ -0,0 +1,3 @@
+module github.com/brettsky/github-security-scanner
+
+go 1.21
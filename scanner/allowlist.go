@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+// repoAllowlist is the set of repositories findings may be reported for,
+// read from --repo-allowlist-file. Each line is an owner/name or a glob
+// such as "acme/*"; blank lines and lines starting with # are ignored.
+// Matching is case-insensitive, like GitHub repository names.
+type repoAllowlist struct {
+	entries []string
+}
+
+func loadRepoAllowlist(filePath string) (*repoAllowlist, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading repo allowlist: %v", err)
+	}
+
+	a := &repoAllowlist{}
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.ToLower(line)
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, fmt.Errorf("repo allowlist line %d: invalid pattern %q: %v", n+1, line, err)
+		}
+		a.entries = append(a.entries, line)
+	}
+	return a, nil
+}
+
+func (a *repoAllowlist) allows(repo string) bool {
+	repo = strings.ToLower(repo)
+	for _, entry := range a.entries {
+		if ok, _ := path.Match(entry, repo); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterAllowlisted keeps only findings from allowlisted repositories. It
+// logs every repository whose findings were dropped, with counts, so runs
+// leave an audit trail, and returns how many findings were dropped.
+func filterAllowlisted(findings []Finding, allowlist *repoAllowlist) ([]Finding, int) {
+	kept := findings[:0]
+	dropped := make(map[string]int)
+	for _, f := range findings {
+		if allowlist.allows(f.Repository) {
+			kept = append(kept, f)
+			continue
+		}
+		dropped[f.Repository]++
+	}
+	if len(dropped) == 0 {
+		return kept, 0
+	}
+
+	repos := make([]string, 0, len(dropped))
+	total := 0
+	for repo, n := range dropped {
+		repos = append(repos, repo)
+		total += n
+	}
+	sort.Strings(repos)
+	fmt.Printf("\nDropped %d finding(s) from %d repositories not on the allowlist:\n", total, len(repos))
+	for _, repo := range repos {
+		fmt.Printf("  %s (%d)\n", repo, dropped[repo])
+	}
+	return kept, total
+}
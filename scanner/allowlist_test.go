@@ -0,0 +1,47 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoAllowlistFiltersFindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist")
+	if err := os.WriteFile(path, []byte("# approved for scanning\nacme/*\n\n  Other/App  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	allowlist, err := loadRepoAllowlist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{
+		{Repository: "acme/api"},
+		{Repository: "other/app"},
+		{Repository: "other/lib"},
+		{Repository: "ACME/web"},
+		{Repository: "other/lib"},
+		{Repository: "acme/api/extra"},
+	}
+	kept, dropped := filterAllowlisted(findings, allowlist)
+
+	var repos []string
+	for _, f := range kept {
+		repos = append(repos, f.Repository)
+	}
+	if got := strings.Join(repos, ","); got != "acme/api,other/app,ACME/web" {
+		t.Errorf("kept %s, want only allowlisted repositories", got)
+	}
+	if dropped != 3 {
+		t.Errorf("dropped %d findings, want 3", dropped)
+	}
+}
+
+func TestRepoAllowlistInvalidPattern(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist")
+	os.WriteFile(path, []byte("acme/*\nacme/[\n"), 0o600)
+	if _, err := loadRepoAllowlist(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("got %v, want an error for line 2", err)
+	}
+}
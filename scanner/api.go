@@ -0,0 +1,76 @@
+package scanner
+
+import "context"
+
+// LoadConfig reads and validates a JSON config file.
+func LoadConfig(path string) (*Config, error) {
+	return loadConfig(path)
+}
+
+// ParseConfig decodes and validates a JSON config.
+func ParseConfig(data []byte) (*Config, error) {
+	return parseConfig(data)
+}
+
+// Scan runs the scan selected by cfg in the background and streams its
+// results: each finding as soon as the pattern, repository or gist that
+// produced it is done, with severity rules and fingerprints applied, and
+// each error. The min_pattern_matches and min_pattern_repos thresholds need
+// the whole scan and are not applied. Both channels are closed when the
+// scan ends and must be drained together (for example in a select loop),
+// since the scan waits for each value to be received. Cancelling ctx stops
+// the scan.
+//
+// cfg may come from LoadConfig or ParseConfig or be built directly; it is
+// not modified. Progress and warnings are written to cfg.Log, or stdout
+// when it is nil.
+func Scan(ctx context.Context, cfg *Config) (<-chan Finding, <-chan error) {
+	findings := make(chan Finding)
+	errs := make(chan error)
+
+	go func() {
+		defer close(findings)
+		defer close(errs)
+
+		config := *cfg
+		if config.files == nil {
+			if err := config.init(); err != nil {
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+				}
+				return
+			}
+		} else {
+			// An initialised cfg can be scanned more than once, so each
+			// scan gets its own first-hit and notifier state; cfg was
+			// validated when initialised, so newNotifier cannot fail here.
+			config.repoHits = newRepoHits()
+			config.notifier, _ = newNotifier(&config)
+		}
+
+		placeholders := newPlaceholderAllowlist(config.Placeholders)
+		config.onFindings = func(batch []Finding) {
+			for _, f := range batch {
+				classifyFinding(&config, &f, placeholders)
+				f.Fingerprint = fingerprint(f)
+				select {
+				case findings <- f:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		config.onError = func(err error) {
+			select {
+			case errs <- err:
+			case <-ctx.Done():
+			}
+		}
+
+		if _, err := runScan(ctx, &config, &RequestStats{}); err != nil {
+			config.onError(err)
+		}
+	}()
+	return findings, errs
+}
@@ -0,0 +1,136 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScanInitFailureDoesNotBlockAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Not initialised and invalid, so Scan's own init fails.
+	findings, errs := Scan(ctx, &Config{})
+
+	done := make(chan struct{})
+	go func() {
+		for range findings {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Scan blocked sending its init error after the context was cancelled")
+	}
+	for range errs {
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the scan's concurrent writers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// passwordSearchServer answers every code search with one password match
+// in o/r's app.env.
+func passwordSearchServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": 1, "items": []interface{}{map[string]interface{}{
+			"path":       "app.env",
+			"html_url":   "https://github.com/o/r/blob/main/app.env",
+			"repository": map[string]string{"full_name": "o/r"},
+			"text_matches": []interface{}{map[string]interface{}{
+				"property": "content",
+				"fragment": "password=hunter2",
+				"matches":  []interface{}{map[string]interface{}{"text": "password", "indices": []int{0, 8}}},
+			}},
+		}}})
+	}))
+}
+
+// collectScan drains a Scan of cfg, failing the test on any scan error.
+func collectScan(t *testing.T, cfg *Config) []Finding {
+	t.Helper()
+	findings, errs := Scan(context.Background(), cfg)
+	var got []Finding
+	for findings != nil || errs != nil {
+		select {
+		case f, ok := <-findings:
+			if !ok {
+				findings = nil
+				continue
+			}
+			got = append(got, f)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			t.Errorf("scan error: %v", err)
+		}
+	}
+	return got
+}
+
+func TestScanWritesToConfigLog(t *testing.T) {
+	srv := passwordSearchServer()
+	defer srv.Close()
+
+	config, err := ParseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	log := &syncBuffer{}
+	config.Log = log
+
+	if got := collectScan(t, config); len(got) != 1 {
+		t.Errorf("got %d findings, want 1", len(got))
+	}
+	for _, want := range []string{"Searching for: password", "app.env in o/r"} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("log is missing %q:\n%s", want, log.String())
+		}
+	}
+}
+
+func TestScanTwiceWithFirstHitPerRepo(t *testing.T) {
+	srv := passwordSearchServer()
+	defer srv.Close()
+
+	config, err := ParseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"], "first_hit_per_repo": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	config.Log = &syncBuffer{}
+
+	for i := 1; i <= 2; i++ {
+		if got := collectScan(t, config); len(got) != 1 {
+			t.Errorf("scan %d got %d findings, want 1", i, len(got))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes a file by streaming into a temporary file in the
+// same directory and renaming it into place once write succeeds, so readers
+// never observe a partially written file. On failure the temporary file is
+// removed and any existing file at path is left untouched.
+func writeFileAtomic(path string, write func(w io.Writer) error) (err error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(0644); err != nil {
+		return fmt.Errorf("error setting file permissions: %v", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("error flushing %s: %v", path, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("error closing %s: %v", path, err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error renaming into %s: %v", path, err)
+	}
+	return nil
+}
+
+// writeBytesAtomic is writeFileAtomic for data that is already in memory.
+func writeBytesAtomic(path string, data []byte) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
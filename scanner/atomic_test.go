@@ -0,0 +1,47 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicWriteFailureKeepsOldFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "findings.json")
+	if err := writeBytesAtomic(path, []byte("complete\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errors.New("killed mid-write")
+	})
+	if err == nil {
+		t.Fatal("writeFileAtomic returned no error for a failed write")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "complete\n" {
+		t.Errorf("findings.json is %q after a failed write, want the previous contents", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want no temporary file left behind", len(entries))
+	}
+}
+
+func TestSaveFindingsRenderErrorLeavesNoFile(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := parseOutputTemplate("{{.NoSuchField}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{{Repository: "o/r", FilePath: ".env"}}
+	if err := saveFindings(findings, outputOptions{format: "template", template: tmpl, dir: dir}); err == nil {
+		t.Fatal("saveFindings returned no error for a failing template")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("found %s after a failed save, want nothing written", entries[0].Name())
+	}
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/base64"
+	"regexp"
+)
+
+const (
+	// minBase64Segment is the shortest run treated as encoded data; shorter
+	// runs are mostly ordinary identifiers.
+	minBase64Segment = 24
+	// maxBase64Segment and maxBase64Segments bound the decoding work per
+	// file so a large or adversarial blob cannot stall the scan.
+	maxBase64Segment  = 64 * 1024
+	maxBase64Segments = 100
+
+	encodingBase64 = "base64"
+)
+
+var base64SegmentRe = regexp.MustCompile(`[A-Za-z0-9+/_-]{24,}={0,2}`)
+
+// decodeBase64Segment decodes s as standard or URL-safe base64, padded or
+// not. It reports false when s is not valid base64 or does not decode to
+// text.
+func decodeBase64Segment(s string) ([]byte, bool) {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		decoded, err := enc.DecodeString(s)
+		if err != nil {
+			continue
+		}
+		if len(decoded) == 0 || isBinaryContent(decoded) {
+			return nil, false
+		}
+		return decoded, true
+	}
+	return nil, false
+}
+
+// scanBase64 implements decode_base64: it decodes base64-looking segments
+// of content and scans each decoded segment with the patterns and
+// detectors. Findings are placed on the line holding the segment and
+// marked with Encoding "base64"; patterns in skip, already found in the
+// plain content, are not reported again.
+func (m *contentMatcher) scanBase64(repo, path, fileURL string, content []byte, skip map[string]bool) []Finding {
+	plain := *m
+	plain.decodeBase64 = false
+
+	var findings []Finding
+	locs := base64SegmentRe.FindAllIndex(content, maxBase64Segments)
+	for _, loc := range locs {
+		segment := content[loc[0]:loc[1]]
+		if len(segment) < minBase64Segment || len(segment) > maxBase64Segment {
+			continue
+		}
+		decoded, ok := decodeBase64Segment(string(segment))
+		if !ok {
+			continue
+		}
+		line := bytes.Count(content[:loc[0]], []byte("\n")) + 1
+		for _, f := range plain.scan(repo, path, fileURL, decoded) {
+			if skip[f.Pattern] {
+				continue
+			}
+			skip[f.Pattern] = true
+			f.Line = line
+			f.Encoding = encodingBase64
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// patternSpec is one search_patterns entry. Entries are either a plain
+// pattern string or an object that also sets a per-pattern budget:
+//
+//	"search_patterns": ["api_key", {"pattern": "password", "max_requests": 5}]
+type patternSpec struct {
+	Pattern     string `json:"pattern"`
+	MaxRequests int    `json:"max_requests"`
+}
+
+func (s *patternSpec) UnmarshalJSON(data []byte) error {
+	var pattern string
+	if err := json.Unmarshal(data, &pattern); err == nil {
+		*s = patternSpec{Pattern: pattern}
+		return nil
+	}
+	type plain patternSpec
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("search pattern must be a string or an object: %v", err)
+	}
+	if p.Pattern == "" {
+		return fmt.Errorf("search pattern object is missing \"pattern\"")
+	}
+	if p.MaxRequests < 0 {
+		return fmt.Errorf("search pattern %q: max_requests must not be negative", p.Pattern)
+	}
+	*s = patternSpec(p)
+	return nil
+}
+
+// splitPatternSpecs returns the patterns in order along with the
+// max_requests budget of each pattern that set one. When a pattern is
+// listed more than once the last budget wins.
+func splitPatternSpecs(specs []patternSpec) ([]string, map[string]int) {
+	patterns := make([]string, 0, len(specs))
+	var budgets map[string]int
+	for _, s := range specs {
+		patterns = append(patterns, s.Pattern)
+		if s.MaxRequests > 0 {
+			if budgets == nil {
+				budgets = make(map[string]int)
+			}
+			budgets[s.Pattern] = s.MaxRequests
+		}
+	}
+	return patterns, budgets
+}
+
+// TakePatternRequest counts a search request for pattern against its
+// max_requests budget. It reports false, without counting, once the
+// pattern has used limit requests; a limit of zero means no budget.
+// Budgets are shared by every search for the pattern, across qualifiers
+// and hosts that share stats.
+func (rs *RequestStats) TakePatternRequest(pattern string, limit int) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if limit > 0 && rs.PatternRequests[pattern] >= limit {
+		return false
+	}
+	if rs.PatternRequests == nil {
+		rs.PatternRequests = make(map[string]int)
+	}
+	rs.PatternRequests[pattern]++
+	return true
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPatternBudgetStopsOnlyThatPattern(t *testing.T) {
+	const pages = 4
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pattern := strings.Fields(r.URL.Query().Get("q"))[0]
+		mu.Lock()
+		requests[pattern]++
+		mu.Unlock()
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		var items []map[string]interface{}
+		for i := 0; i < searchPerPage; i++ {
+			items = append(items, map[string]interface{}{
+				"path":       fmt.Sprintf("%s/p%s/file%d.env", pattern, r.URL.Query().Get("page"), i),
+				"html_url":   "https://github.com/o/r/blob/main/x",
+				"repository": map[string]string{"full_name": "o/r"},
+				"text_matches": []interface{}{map[string]interface{}{
+					"property": "content",
+					"fragment": pattern + "=hunter2",
+					"matches":  []interface{}{map[string]interface{}{"text": pattern, "indices": []int{0, len(pattern)}}},
+				}},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": pages * searchPerPage, "items": items})
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "file_patterns": ["\\.env$"],
+		"search_patterns": [{"pattern": "password", "max_requests": 2}, "token"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	stats := &RequestStats{}
+
+	findings, err := runScan(context.Background(), config, stats)
+	if err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	if requests["password"] != 2 || requests["token"] != pages {
+		t.Errorf("requests per pattern = %v, want password capped at 2 and token at all %d pages", requests, pages)
+	}
+	perPattern := make(map[string]int)
+	for _, f := range findings {
+		perPattern[f.Pattern]++
+	}
+	if perPattern["password"] != 2*searchPerPage || perPattern["token"] != pages*searchPerPage {
+		t.Errorf("findings per pattern = %v", perPattern)
+	}
+	if stats.PatternRequests["password"] != 2 {
+		t.Errorf("stats count %d password requests, want 2", stats.PatternRequests["password"])
+	}
+}
@@ -0,0 +1,46 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCaseInsensitiveMatching(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "PROD.ENV"), []byte("PASSWORD=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	const base = `"search_patterns": ["Password"], "file_patterns": ["\\.env$"]`
+
+	config, err := parseConfig([]byte(`{` + base + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := scanLocalPath(context.Background(), config, dir, &RequestStats{})
+	if err != nil {
+		t.Fatalf("scanLocalPath: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("got %d findings with case-sensitive matching, want none", len(findings))
+	}
+
+	config, err = parseConfig([]byte(`{` + base + `, "file_globs": ["*.yml"], "case_insensitive": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err = scanLocalPath(context.Background(), config, dir, &RequestStats{})
+	if err != nil {
+		t.Fatalf("scanLocalPath: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings with case_insensitive, want 1", len(findings))
+	}
+	if findings[0].Severity != "HIGH" {
+		t.Errorf("severity = %s, want HIGH from the password keyword", findings[0].Severity)
+	}
+	if !config.matchesFile("config/APP.YML") {
+		t.Error("file glob did not ignore case")
+	}
+}
@@ -0,0 +1,188 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// severityFor returns the severity of pattern: the severity_overrides entry
+// when there is one, then the detector's severity, otherwise the built-in
+// rules, with default_severity for patterns they do not cover.
+func (c *Config) severityFor(pattern string) string {
+	severity, _ := c.explainSeverity(pattern)
+	return severity
+}
+
+// explainSeverity is severityFor along with a description of the rule that
+// decided the severity.
+func (c *Config) explainSeverity(pattern string) (string, string) {
+	severity, reason, _ := c.severityRuleFor(pattern)
+	return severity, reason
+}
+
+// severityRuleFor is explainSeverity that also reports whether a rule
+// matched; false means the pattern fell through to the default severity.
+func (c *Config) severityRuleFor(pattern string) (string, string, bool) {
+	if severity, ok := c.SeverityOverrides[pattern]; ok {
+		return severity, fmt.Sprintf("severity %s from severity_overrides", severity), true
+	}
+	if d, ok := findDetector(pattern); ok {
+		return d.Severity, fmt.Sprintf("severity %s from detector %s", d.Severity, d.Name), true
+	}
+	rulePattern := pattern
+	if c.CaseInsensitive {
+		// The built-in keywords are lower case.
+		rulePattern = strings.ToLower(pattern)
+	}
+	severity, keyword := severityRule(rulePattern)
+	if keyword == "" {
+		if c.DefaultSeverity != "" {
+			severity = c.DefaultSeverity
+		}
+		return severity, fmt.Sprintf("severity %s by default, no high-severity keyword in pattern", severity), false
+	}
+	return severity, fmt.Sprintf("severity %s because the pattern contains %q", severity, keyword), true
+}
+
+// validateSeverityRules normalizes default_severity and the
+// severity_overrides values, rejecting unknown severities.
+func validateSeverityRules(c *Config) error {
+	if c.DefaultSeverity != "" {
+		severity, err := parseSeverity(c.DefaultSeverity)
+		if err != nil {
+			return fmt.Errorf("default_severity: %v", err)
+		}
+		c.DefaultSeverity = severity
+	}
+	patterns := make([]string, 0, len(c.SeverityOverrides))
+	for p := range c.SeverityOverrides {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+	for _, p := range patterns {
+		severity, err := parseSeverity(c.SeverityOverrides[p])
+		if err != nil {
+			return fmt.Errorf("severity_overrides: %s: %v", p, err)
+		}
+		c.SeverityOverrides[p] = severity
+	}
+	return nil
+}
+
+// checkStrictSeverity, with strict_severity, rejects search patterns that no
+// severity rule covers so every pattern is classified on purpose: by a
+// severity_overrides entry, a detector or a built-in keyword.
+func checkStrictSeverity(c *Config) error {
+	if !c.StrictSeverity {
+		return nil
+	}
+	var unclassified []string
+	for _, p := range c.SearchPatterns {
+		if _, _, ok := c.severityRuleFor(p); !ok {
+			unclassified = append(unclassified, p)
+		}
+	}
+	if len(unclassified) > 0 {
+		return fmt.Errorf("strict_severity: no severity rule matches %s; add them to severity_overrides", strings.Join(unclassified, ", "))
+	}
+	return nil
+}
+
+// classifyFindings assigns severities to findings from the current rules.
+// It runs after every scan and on its own for reclassify, so detection and
+// classification can change independently.
+func classifyFindings(config *Config, findings []Finding) {
+	classifySeverities(config, findings)
+	demoteUnconfirmed(config, findings)
+}
+
+// classifySeverities is classifyFindings without the confirmation
+// threshold, for findings classified a batch at a time.
+func classifySeverities(config *Config, findings []Finding) {
+	placeholders := newPlaceholderAllowlist(config.Placeholders)
+	for i := range findings {
+		classifyFinding(config, &findings[i], placeholders)
+	}
+}
+
+// classifyFinding applies the per-finding severity rules. Unlike
+// classifyFindings it does not apply the confirmation threshold, which
+// needs every finding of the scan.
+func classifyFinding(config *Config, f *Finding, placeholders *placeholderAllowlist) {
+	f.Severity = config.severityFor(f.Pattern)
+	f.Tags = config.tagsFor(f.Pattern)
+	if contextEscalates(config, *f, placeholders) {
+		f.Severity = escalateSeverity(f.Severity)
+	}
+	if config.EscalatePublic && f.Public {
+		f.Severity = escalateSeverity(f.Severity)
+	}
+}
+
+// explainFindings sets a human-readable Explanation on each classified
+// finding: what matched, which severity rule fired and whether the finding
+// was demoted by the confirmation threshold. Later steps that change a
+// finding, such as downgradeBelow, append to an existing explanation.
+func explainFindings(config *Config, findings []Finding) {
+	placeholders := newPlaceholderAllowlist(config.Placeholders)
+	for i := range findings {
+		f := &findings[i]
+		var parts []string
+
+		if d, ok := findDetector(f.Pattern); ok {
+			parts = append(parts, fmt.Sprintf("matched detector %s (%s)", d.Name, d.Description))
+		} else {
+			parts = append(parts, fmt.Sprintf("matched search pattern %q", f.Pattern))
+		}
+
+		switch f.Confidence {
+		case confidenceHigh:
+			parts = append(parts, fmt.Sprintf("found in file contents at line %d", f.Line))
+		case confidenceMedium:
+			parts = append(parts, "matched text returned by code search")
+		case confidenceLow:
+			parts = append(parts, "reported by code search without matched text")
+		}
+
+		severity, reason := config.explainSeverity(f.Pattern)
+		parts = append(parts, reason)
+		if contextEscalates(config, *f, placeholders) {
+			severity = escalateSeverity(severity)
+			parts = append(parts, fmt.Sprintf("escalated to %s: the matched line assigns a real value", severity))
+		}
+		if config.EscalatePublic && f.Public {
+			severity = escalateSeverity(severity)
+			parts = append(parts, fmt.Sprintf("escalated to %s: the repository is public", severity))
+		}
+		if f.Severity != severity {
+			parts = append(parts, fmt.Sprintf("demoted to %s: pattern below the confirmation threshold", f.Severity))
+		}
+		f.Explanation = strings.Join(parts, "; ")
+	}
+}
+
+// downgradeBelow moves findings ranked below threshold to floor instead of
+// dropping them, so noisy findings are kept for the record but sort and
+// alert as the lowest severity.
+func downgradeBelow(findings []Finding, threshold, floor string) {
+	for i := range findings {
+		f := &findings[i]
+		if severityRank(f.Severity) >= severityRank(threshold) || f.Severity == floor {
+			continue
+		}
+		if f.Explanation != "" {
+			f.Explanation += fmt.Sprintf("; downgraded from %s to %s, below --downgrade-below %s", f.Severity, floor, threshold)
+		}
+		f.Severity = floor
+	}
+}
+
+// parseSeverity validates a severity given on the command line.
+func parseSeverity(value string) (string, error) {
+	severity := strings.ToUpper(strings.TrimSpace(value))
+	if severityRank(severity) < 0 {
+		return "", fmt.Errorf("unknown severity %q (use CRITICAL, HIGH, MEDIUM, LOW or INFO)", value)
+	}
+	return severity, nil
+}
@@ -0,0 +1,149 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplanationReflectsMatchingRule(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["api_key", "password", "hostname", "github-token"],
+		"severity_overrides": {"api_key": "critical"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{
+		{Pattern: "api_key", Confidence: confidenceMedium},
+		{Pattern: "password", Confidence: confidenceHigh, Line: 4},
+		{Pattern: "hostname", Confidence: confidenceLow},
+		{Pattern: "github-token", Confidence: confidenceHigh, Line: 1},
+	}
+	classifyFindings(config, findings)
+	explainFindings(config, findings)
+
+	want := [][]string{
+		{`matched search pattern "api_key"`, "severity CRITICAL from severity_overrides", "matched text returned by code search"},
+		{`severity HIGH because the pattern contains "password"`, "at line 4"},
+		{"severity MEDIUM by default", "without matched text"},
+		{"matched detector github-token", "severity CRITICAL from detector github-token"},
+	}
+	for i, f := range findings {
+		for _, part := range want[i] {
+			if !strings.Contains(f.Explanation, part) {
+				t.Errorf("%s explanation %q does not mention %q", f.Pattern, f.Explanation, part)
+			}
+		}
+	}
+}
+
+func TestExplanationNotesDemotion(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "min_pattern_matches": 2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{{Pattern: "password", Repository: "o/r", Confidence: confidenceMedium}}
+	classifyFindings(config, findings)
+	explainFindings(config, findings)
+	if !strings.Contains(findings[0].Explanation, "demoted to LOW") {
+		t.Errorf("explanation %q does not mention the demotion", findings[0].Explanation)
+	}
+}
+
+func TestDowngradeBelowKeepsFindings(t *testing.T) {
+	findings := []Finding{
+		{Repository: "o/a", Severity: "CRITICAL"},
+		{Repository: "o/b", Severity: "HIGH"},
+		{Repository: "o/c", Severity: "MEDIUM", Explanation: "matched search pattern \"host\""},
+		{Repository: "o/d", Severity: "LOW"},
+	}
+	downgradeBelow(findings, "HIGH", downgradeFloor)
+
+	if len(findings) != 4 {
+		t.Fatalf("%d findings after downgrading, want all 4 kept", len(findings))
+	}
+	want := []string{"CRITICAL", "HIGH", downgradeFloor, downgradeFloor}
+	for i, f := range findings {
+		if f.Severity != want[i] {
+			t.Errorf("%s is %s, want %s", f.Repository, f.Severity, want[i])
+		}
+	}
+	if !strings.Contains(findings[2].Explanation, "downgraded from MEDIUM to INFO") {
+		t.Errorf("explanation %q does not note the downgrade", findings[2].Explanation)
+	}
+	if findings[3].Explanation != "" {
+		t.Errorf("unexplained finding got explanation %q", findings[3].Explanation)
+	}
+}
+
+func TestContextEscalationNeedsRealValue(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["hostname"], "context_escalation": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		path, snippet, want string
+	}{
+		{"config/app.env", `hostname = "db-prod-7"`, "HIGH"},
+		{"config/app.yml", `hostname: db-prod-7`, "HIGH"},
+		{"config/app.env", `hostname = ""`, "MEDIUM"},
+		{"config/app.env", `# hostname = "db-prod-7"`, "MEDIUM"},
+		{"config/app.env", `hostname = $DB_HOST`, "MEDIUM"},
+		{"config/app.env", `hostname = os.Getenv("DB_HOST")`, "MEDIUM"},
+		{"config/app.env", `hostname = changeme`, "MEDIUM"},
+		{"config/app.env", `see the hostname docs`, "MEDIUM"},
+		{"test/fixtures/app.env", `hostname = "db-prod-7"`, "MEDIUM"},
+	}
+	for _, c := range cases {
+		findings := []Finding{{Pattern: "hostname", FilePath: c.path, Snippet: c.snippet}}
+		classifyFindings(config, findings)
+		if findings[0].Severity != c.want {
+			t.Errorf("%s %q classified %s, want %s", c.path, c.snippet, findings[0].Severity, c.want)
+		}
+	}
+
+	config.ContextEscalation = false
+	findings := []Finding{{Pattern: "hostname", FilePath: "config/app.env", Snippet: `hostname = "db-prod-7"`}}
+	classifyFindings(config, findings)
+	if findings[0].Severity != "MEDIUM" {
+		t.Errorf("escalated to %s with context_escalation off", findings[0].Severity)
+	}
+}
+
+func TestDefaultSeverityForUnmatchedPatterns(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.env"), []byte("hostname=db.internal\npassword=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := parseConfig([]byte(`{"search_patterns": ["hostname", "password"], "file_patterns": ["\\.env$"], "default_severity": "low"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := scanLocalPath(context.Background(), config, dir, &RequestStats{})
+	if err != nil {
+		t.Fatalf("scanLocalPath: %v", err)
+	}
+	got := make(map[string]string)
+	for _, f := range findings {
+		got[f.Pattern] = f.Severity
+	}
+	if got["hostname"] != "LOW" || got["password"] != "HIGH" {
+		t.Errorf("severities = %v, want hostname LOW by default and password HIGH", got)
+	}
+
+	if _, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "default_severity": "urgent"}`)); err == nil {
+		t.Error("parseConfig accepted an unknown default_severity")
+	}
+}
+
+func TestStrictSeverityRejectsUnclassifiedPatterns(t *testing.T) {
+	_, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password", "hostname", "zone"], "strict_severity": true}`))
+	if err == nil || !strings.Contains(err.Error(), "hostname, zone") {
+		t.Fatalf("got %v, want strict_severity to name hostname and zone", err)
+	}
+	if _, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password", "hostname"],
+		"strict_severity": true, "severity_overrides": {"hostname": "low"}}`)); err != nil {
+		t.Errorf("an overridden pattern still failed strict_severity: %v", err)
+	}
+}
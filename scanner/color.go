@@ -0,0 +1,45 @@
+package scanner
+
+import "os"
+
+const colorReset = "\x1b[0m"
+
+// severityColors are the ANSI colors used for severity labels on a
+// terminal.
+var severityColors = map[string]string{
+	"CRITICAL": "\x1b[1;35m",
+	"HIGH":     "\x1b[1;31m",
+	"MEDIUM":   "\x1b[33m",
+	"LOW":      "\x1b[36m",
+	"INFO":     "\x1b[2m",
+}
+
+// stdoutColor reports whether progress output should be colored: stdout
+// must be a terminal, NO_COLOR (https://no-color.org) unset and TERM not
+// "dumb". Output files are never colored.
+var stdoutColor = colorEnabled(os.Stdout)
+
+func colorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps text in the color for severity when enabled is set.
+func colorize(text, severity string, enabled bool) string {
+	color, ok := severityColors[severity]
+	if !enabled || !ok {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// severityLabel is the "[HIGH]" style label printed with each finding.
+func severityLabel(severity string) string {
+	return colorize("["+severity+"]", severity, stdoutColor)
+}
@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestColorizeOnlyWhenEnabled(t *testing.T) {
+	if got := colorize("[HIGH]", "HIGH", false); got != "[HIGH]" {
+		t.Errorf("disabled colorize gave %q", got)
+	}
+	if got := colorize("[HIGH]", "HIGH", true); got != severityColors["HIGH"]+"[HIGH]"+colorReset {
+		t.Errorf("enabled colorize gave %q", got)
+	}
+	if colorize("[CRITICAL]", "CRITICAL", true) == colorize("[LOW]", "LOW", true) {
+		t.Error("severities share a color")
+	}
+	if got := colorize("[UNKNOWN]", "UNKNOWN", true); got != "[UNKNOWN]" {
+		t.Errorf("unknown severity colored as %q", got)
+	}
+}
+
+func TestColorDisabledForFilesAndNoColor(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if colorEnabled(f) {
+		t.Error("color enabled for a regular file")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(os.Stdout) {
+		t.Error("color enabled with NO_COLOR set")
+	}
+}
+
+func TestMachineOutputUncolored(t *testing.T) {
+	var buf bytes.Buffer
+	findings := []Finding{{Repository: "o/r", FilePath: ".env", Severity: "CRITICAL"}}
+	for _, format := range []string{"json", "csv"} {
+		buf.Reset()
+		if err := writeFindings(&buf, findings, outputOptions{format: format}); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(buf.String(), "\x1b[") {
+			t.Errorf("%s output contains color codes: %q", format, buf.String())
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Confidence levels describe how a finding was confirmed: a code search hit
+// with no matched text is only known to mention the pattern somewhere, a
+// text_matches fragment shows the match, and scanning the file contents
+// pins it to a line.
+const (
+	confidenceLow    = "low"
+	confidenceMedium = "medium"
+	confidenceHigh   = "high"
+)
+
+var confidenceRank = map[string]int{
+	confidenceLow:    0,
+	confidenceMedium: 1,
+	confidenceHigh:   2,
+}
+
+// parseConfidence validates a --min-confidence value.
+func parseConfidence(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if _, ok := confidenceRank[value]; !ok {
+		return "", fmt.Errorf("unknown confidence %q (use low, medium or high)", value)
+	}
+	return value, nil
+}
+
+// filterByConfidence drops findings less confident than min.
+func filterByConfidence(findings []Finding, min string) []Finding {
+	kept := findings[:0]
+	for _, f := range findings {
+		if confidenceRank[f.Confidence] >= confidenceRank[min] {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
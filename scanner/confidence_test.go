@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfidencePerDetectionPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/contents/fetched.env":
+			w.Write([]byte("password=hunter2\n"))
+			return
+		case "/repos/o/r/contents/gone.env":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		w.Write([]byte(`{"total_count": 3, "items": [
+			{"path": "fragment.env", "html_url": "https://github.com/o/r/blob/main/fragment.env", "repository": {"full_name": "o/r"},
+			 "text_matches": [{"property": "content", "fragment": "password=hunter2", "matches": [{"text": "password", "indices": [0, 8]}]}]},
+			{"path": "fetched.env", "html_url": "https://github.com/o/r/blob/main/fetched.env", "repository": {"full_name": "o/r"}},
+			{"path": "gone.env", "html_url": "https://github.com/o/r/blob/main/gone.env", "repository": {"full_name": "o/r"}}
+		]}`))
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"], "max_retries": -1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := searchGitHub(context.Background(), config, "password", &RequestStats{}, newTopicCache())
+	if err != nil {
+		t.Fatalf("searchGitHub: %v", err)
+	}
+	got := make(map[string]string)
+	for _, f := range findings {
+		got[f.FilePath] = f.Confidence
+	}
+	want := map[string]string{"fragment.env": confidenceMedium, "fetched.env": confidenceHigh, "gone.env": confidenceLow}
+	for path, confidence := range want {
+		if got[path] != confidence {
+			t.Errorf("%s has confidence %q, want %q", path, got[path], confidence)
+		}
+	}
+
+	if kept := filterByConfidence(findings, confidenceMedium); len(kept) != 2 {
+		t.Errorf("--min-confidence medium kept %d findings, want 2", len(kept))
+	}
+}
@@ -0,0 +1,58 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGzippedConfigMatchesPlain(t *testing.T) {
+	dir := t.TempDir()
+	plain := []byte(`{"github_token": "t", "search_patterns": ["password", {"pattern": "api_key", "max_requests": 3}],
+		"file_patterns": ["\\.env$"], "default_severity": "low", "csv_columns": ["Repository", "Severity"]}`)
+	var zipped bytes.Buffer
+	zw := gzip.NewWriter(&zipped)
+	zw.Write(plain)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"config.json":    plain,
+		"config.json.gz": zipped.Bytes(),
+		// Detected by its magic bytes alone.
+		"config.bin": zipped.Bytes(),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := loadConfig(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON, _ := json.Marshal(want)
+	for _, name := range []string{"config.json.gz", "config.bin"} {
+		got, err := loadConfig(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if gotJSON, _ := json.Marshal(got); !bytes.Equal(gotJSON, wantJSON) {
+			t.Errorf("%s loaded as %s, want %s", name, gotJSON, wantJSON)
+		}
+		if got.patternBudgets["api_key"] != 3 || len(got.csvColumns) != 2 {
+			t.Errorf("%s: derived settings not parsed", name)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json.gz"), plain, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(filepath.Join(dir, "broken.json.gz")); err == nil {
+		t.Error("loadConfig accepted a .gz file that is not gzipped")
+	}
+}
@@ -0,0 +1,56 @@
+package scanner
+
+// demotedSeverity is assigned to findings of patterns that did not reach
+// the confirmation threshold.
+const demotedSeverity = "LOW"
+
+// demoteUnconfirmed lowers the severity and confidence of every finding
+// whose pattern matched fewer than min_pattern_matches times or in fewer
+// than min_pattern_repos distinct repositories. A single hit is often a
+// fluke; the same pattern across many repositories is worth an alert.
+func demoteUnconfirmed(config *Config, findings []Finding) {
+	unconfirmed, _ := unconfirmedPatterns(config, findingSlice(findings))
+	demotePatterns(findings, unconfirmed)
+}
+
+// unconfirmedPatterns returns the patterns below the confirmation
+// threshold among every finding of src, logging each one.
+func unconfirmedPatterns(config *Config, src findingSource) (map[string]bool, error) {
+	if config.MinPatternMatches <= 1 && config.MinPatternRepos <= 1 {
+		return nil, nil
+	}
+
+	matches := make(map[string]int)
+	repos := make(map[string]map[string]bool)
+	err := src.each(func(f Finding) error {
+		matches[f.Pattern]++
+		if repos[f.Pattern] == nil {
+			repos[f.Pattern] = make(map[string]bool)
+		}
+		repos[f.Pattern][f.Repository] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unconfirmed := make(map[string]bool)
+	for pattern, n := range matches {
+		if n < config.MinPatternMatches || len(repos[pattern]) < config.MinPatternRepos {
+			config.logf("Pattern %s matched %d time(s) in %d repo(s), below the confirmation threshold; reporting as %s\n",
+				pattern, n, len(repos[pattern]), demotedSeverity)
+			unconfirmed[pattern] = true
+		}
+	}
+	return unconfirmed, nil
+}
+
+// demotePatterns demotes the findings of the unconfirmed patterns.
+func demotePatterns(findings []Finding, unconfirmed map[string]bool) {
+	for i := range findings {
+		if unconfirmed[findings[i].Pattern] {
+			findings[i].Severity = demotedSeverity
+			findings[i].Confidence = confidenceLow
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package scanner
+
+import "testing"
+
+func TestUnconfirmedPatternsDemoted(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password", "token", "key"], "min_pattern_matches": 2, "min_pattern_repos": 2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{
+		// Two matches in two repositories: confirmed.
+		{Pattern: "password", Repository: "o/a", Severity: "HIGH", Confidence: confidenceHigh},
+		{Pattern: "password", Repository: "o/b", Severity: "HIGH", Confidence: confidenceMedium},
+		// Two matches, one repository: below min_pattern_repos.
+		{Pattern: "token", Repository: "o/a", Severity: "CRITICAL", Confidence: confidenceHigh},
+		{Pattern: "token", Repository: "o/a", Severity: "CRITICAL", Confidence: confidenceMedium},
+		// A single match.
+		{Pattern: "key", Repository: "o/c", Severity: "MEDIUM", Confidence: confidenceHigh},
+	}
+	demoteUnconfirmed(config, findings)
+
+	want := []string{"HIGH", "HIGH", demotedSeverity, demotedSeverity, demotedSeverity}
+	wantConfidence := []string{confidenceHigh, confidenceMedium, confidenceLow, confidenceLow, confidenceLow}
+	for i, f := range findings {
+		if f.Severity != want[i] {
+			t.Errorf("finding %d (%s in %s) is %s, want %s", i, f.Pattern, f.Repository, f.Severity, want[i])
+		}
+		if f.Confidence != wantConfidence[i] {
+			t.Errorf("finding %d (%s in %s) has %s confidence, want %s", i, f.Pattern, f.Repository, f.Confidence, wantConfidence[i])
+		}
+	}
+}
+
+func TestNoThresholdKeepsSeverity(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["key"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{{Pattern: "key", Repository: "o/c", Severity: "MEDIUM"}}
+	demoteUnconfirmed(config, findings)
+	if findings[0].Severity != "MEDIUM" {
+		t.Errorf("severity %s without a threshold, want MEDIUM", findings[0].Severity)
+	}
+}
@@ -0,0 +1,243 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// binarySniffLen is how much of a file is inspected to decide whether it is
+// binary, matching what git and most editors look at.
+const binarySniffLen = 8000
+
+// contentPattern is a search pattern compiled for matching against file
+// contents fetched directly from a repository.
+type contentPattern struct {
+	pattern  string
+	severity string
+	re       *regexp.Regexp
+	// multiline patterns are matched against the whole content.
+	multiline bool
+}
+
+// multilineFlagRe recognizes patterns that can only match across lines:
+// those setting the s flag, such as (?s) or (?is:...), or naming a newline.
+var multilineFlagRe = regexp.MustCompile(`\(\?[a-zA-Z]*s[a-zA-Z]*[:)]|\\n`)
+
+// multilineMatches returns each match of re in content, reported at the
+// line it starts on with its inner lines redacted by redactBlock.
+func multilineMatches(re *regexp.Regexp, content []byte) []Match {
+	var matches []Match
+	for _, loc := range re.FindAllIndex(content, -1) {
+		matches = append(matches, Match{
+			Line:    bytes.Count(content[:loc[0]], []byte("\n")) + 1,
+			Snippet: redactBlock(string(content[loc[0]:loc[1]])),
+		})
+	}
+	return matches
+}
+
+// redactBlock keeps the first and last line of a multi-line match, such
+// as a PEM block's BEGIN and END lines, and replaces the rest with a line
+// count so the secret itself never reaches the output.
+func redactBlock(block string) string {
+	lines := strings.Split(strings.TrimSpace(block), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	switch len(lines) {
+	case 1:
+		return lines[0]
+	case 2:
+		return lines[0] + "\n" + lines[1]
+	}
+	return fmt.Sprintf("%s\n[REDACTED %d lines]\n%s", lines[0], len(lines)-2, lines[len(lines)-1])
+}
+
+// compileContentPatterns compiles each search pattern as a regular
+// expression, falling back to a literal match when the pattern is not
+// valid regex syntax (search patterns are GitHub query terms first).
+func compileContentPatterns(patterns []string, flags string) []contentPattern {
+	compiled := make([]contentPattern, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(flags + p)
+		if err != nil {
+			re = regexp.MustCompile(flags + regexp.QuoteMeta(p))
+		}
+		compiled = append(compiled, contentPattern{pattern: p, severity: determineSeverity(p), re: re, multiline: multilineFlagRe.MatchString(p)})
+	}
+	return compiled
+}
+
+// contentMatcher scans file contents against the search patterns and the
+// enabled detectors, skipping matches whose value is a known placeholder.
+type contentMatcher struct {
+	patterns     []contentPattern
+	detectors    []Detector
+	placeholders *placeholderAllowlist
+	// decodeBase64 also scans decoded base64 segments (decode_base64).
+	decodeBase64 bool
+}
+
+func newContentMatcher(config *Config) *contentMatcher {
+	patterns := compileContentPatterns(config.SearchPatterns, caseFlag(config))
+	// Use the config's severity rules, including default_severity.
+	for i := range patterns {
+		patterns[i].severity = config.severityFor(patterns[i].pattern)
+	}
+	// Detector names were validated when the config was loaded.
+	detectors, _ := enabledDetectors(config.Detectors)
+	return &contentMatcher{
+		patterns:     patterns,
+		detectors:    detectors,
+		placeholders: newPlaceholderAllowlist(config.Placeholders),
+		decodeBase64: config.DecodeBase64,
+	}
+}
+
+// scan matches file contents line by line and returns one finding per
+// pattern or detector, located at its first matching line that is not a
+// placeholder.
+func (m *contentMatcher) scan(repo, path, fileURL string, content []byte) []Finding {
+	var findings []Finding
+	for _, p := range m.patterns {
+		if p.multiline {
+			if matches := multilineMatches(p.re, content); len(matches) > 0 {
+				findings = append(findings, Finding{
+					Repository: repo,
+					FilePath:   path,
+					URL:        fileURL,
+					Pattern:    p.pattern,
+					Severity:   p.severity,
+					Line:       matches[0].Line,
+					Snippet:    matches[0].Snippet,
+					Confidence: confidenceHigh,
+				})
+			}
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		lineNum := 0
+		for scanner.Scan() {
+			lineNum++
+			line := scanner.Text()
+			loc := p.re.FindStringIndex(line)
+			if loc == nil {
+				continue
+			}
+			if m.placeholders.contains(matchedValue(line, loc)) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Repository: repo,
+				FilePath:   path,
+				URL:        fileURL,
+				Pattern:    p.pattern,
+				Severity:   p.severity,
+				Line:       lineNum,
+				Snippet:    strings.TrimSpace(line),
+				Confidence: confidenceHigh,
+			})
+			break
+		}
+	}
+	for _, d := range m.detectors {
+		for _, match := range d.Detect(content) {
+			if match.Value != "" && m.placeholders.contains(match.Value) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Repository: repo,
+				FilePath:   path,
+				URL:        fileURL,
+				Pattern:    d.Name(),
+				Severity:   describeDetector(d).Severity,
+				Line:       match.Line,
+				Snippet:    match.Snippet,
+				Confidence: confidenceHigh,
+			})
+			break
+		}
+	}
+	if m.decodeBase64 {
+		found := make(map[string]bool, len(findings))
+		for _, f := range findings {
+			found[f.Pattern] = true
+		}
+		findings = append(findings, m.scanBase64(repo, path, fileURL, content, found)...)
+	}
+	return findings
+}
+
+// onlyPlaceholders reports whether pattern, a search pattern or detector
+// name, matches content at least once and only ever with a placeholder
+// value.
+func (m *contentMatcher) onlyPlaceholders(pattern string, content []byte) bool {
+	matched := false
+	for _, p := range m.patterns {
+		if p.pattern != pattern || p.multiline {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			loc := p.re.FindStringIndex(line)
+			if loc == nil {
+				continue
+			}
+			if !m.placeholders.contains(matchedValue(line, loc)) {
+				return false
+			}
+			matched = true
+		}
+	}
+	for _, d := range m.detectors {
+		if d.Name() != pattern {
+			continue
+		}
+		for _, match := range d.Detect(content) {
+			if match.Value == "" || !m.placeholders.contains(match.Value) {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+// isBinaryContent reports whether content looks like a binary file: it
+// contains a NUL byte, or is not valid UTF-8 and is mostly non-text bytes.
+// Binary files are not pattern-matched since they only yield garbage
+// snippets.
+func isBinaryContent(content []byte) bool {
+	sample := content
+	if len(sample) > binarySniffLen {
+		sample = sample[:binarySniffLen]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	if utf8.Valid(sample) {
+		return false
+	}
+	// Allow a multi-byte rune cut off by the sample boundary.
+	for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+	if utf8.Valid(sample) {
+		return false
+	}
+
+	nonText := 0
+	for _, b := range sample {
+		if (b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f') || b == 0x7f {
+			nonText++
+		}
+	}
+	return nonText*10 > len(sample)
+}
@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBinaryFilesSkipped(t *testing.T) {
+	files := map[string]string{
+		"text.env":   "password=hunter2\n",
+		"binary.env": "\x89PNG\r\n\x1a\n\x00\x00password=hunter2\x00",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/commits/HEAD":
+			fmt.Fprint(w, `{"sha": "head"}`)
+		case "/repos/o/r/git/trees/head":
+			fmt.Fprint(w, `{"tree": [{"path": "text.env", "type": "blob"}, {"path": "binary.env", "type": "blob"}]}`)
+		case "/repos/o/r/contents/text.env", "/repos/o/r/contents/binary.env":
+			fmt.Fprint(w, files[r.URL.Path[len("/repos/o/r/contents/"):]])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"], "repositories": ["o/r"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	stats := &RequestStats{}
+	findings, err := collectRepositories(context.Background(), config, stats)
+	if err != nil {
+		t.Fatalf("scanRepositories: %v", err)
+	}
+	if len(findings) != 1 || findings[0].FilePath != "text.env" {
+		t.Errorf("got %+v, want only the text file's finding", findings)
+	}
+	if stats.SkippedBinary != 1 {
+		t.Errorf("SkippedBinary = %d, want 1", stats.SkippedBinary)
+	}
+}
+
+func TestIsBinaryContent(t *testing.T) {
+	cases := map[string]bool{
+		"password=hunter2\n": false,
+		"clé=café\n":         false,
+		"\x00\x01\x02":       true,
+		"\xff\xfe\x01\x02\x03\x04\x05\x06\x07\x08": true,
+		"": false,
+	}
+	for content, want := range cases {
+		if got := isBinaryContent([]byte(content)); got != want {
+			t.Errorf("isBinaryContent(%q) = %v, want %v", content, got, want)
+		}
+	}
+}
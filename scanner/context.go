@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"path"
+	"strings"
+)
+
+// commentPrefixes mark a snippet as commented out, in which case it never
+// escalates severity.
+var commentPrefixes = []string{"#", "//", "--", ";", "/*", "*", "<!--", "rem "}
+
+// testPathMarkers identify files that usually hold sample credentials.
+var testPathMarkers = []string{"test", "spec", "fixture", "mock", "example", "sample"}
+
+// assignedValue returns the value assigned on a snippet line such as
+// `password = "hunter2"` or `password: hunter2`, or "" when the line is a
+// comment, has no assignment or assigns nothing.
+func assignedValue(snippet string) string {
+	line := strings.TrimSpace(snippet)
+	lower := strings.ToLower(line)
+	for _, prefix := range commentPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return ""
+		}
+	}
+	i := strings.IndexAny(line, "=:")
+	if i < 0 {
+		return ""
+	}
+	value := strings.TrimRight(strings.TrimSpace(line[i+1:]), ",;")
+	value = strings.Trim(value, "\"'` ")
+	return value
+}
+
+// isReferenceValue reports whether value points elsewhere, such as an
+// environment variable or a function call, rather than being a secret.
+func isReferenceValue(value string) bool {
+	return strings.HasPrefix(value, "$") || strings.HasPrefix(value, "<") ||
+		strings.HasPrefix(value, "{") || strings.HasPrefix(value, "%") ||
+		strings.Contains(value, "(")
+}
+
+// isTestPath reports whether filePath looks like a test or sample file.
+func isTestPath(filePath string) bool {
+	lower := strings.ToLower(filePath)
+	for _, dir := range strings.Split(path.Dir(lower), "/") {
+		for _, marker := range testPathMarkers {
+			if strings.Contains(dir, marker) {
+				return true
+			}
+		}
+	}
+	base := path.Base(lower)
+	for _, marker := range testPathMarkers {
+		if strings.Contains(base, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// escalateSeverity returns the next severity up, capped at CRITICAL.
+func escalateSeverity(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "INFO":
+		return "LOW"
+	case "LOW":
+		return "MEDIUM"
+	case "MEDIUM":
+		return "HIGH"
+	default:
+		return "CRITICAL"
+	}
+}
+
+// contextEscalates reports whether context_escalation raises the severity
+// of f: its matched line must assign a real value (not empty, a reference
+// or a placeholder) in a file that is not a test or sample.
+func contextEscalates(config *Config, f Finding, placeholders *placeholderAllowlist) bool {
+	if !config.ContextEscalation || f.Snippet == "" || isTestPath(f.FilePath) {
+		return false
+	}
+	value := assignedValue(f.Snippet)
+	return value != "" && !isReferenceValue(value) && !placeholders.contains(value)
+}
@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCountOnlyWritesNoOutput(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "app.env"), []byte("password=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(config, []byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runMain(t, dir, "-config", config, "-local-path", src, "-count-only")
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != exitCodeFindings {
+		t.Fatalf("got %v, want exit status %d\n%s%s", err, exitCodeFindings, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "Findings: 1") {
+		t.Errorf("counts not printed:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "hunter2") {
+		t.Errorf("count-only output leaks the match:\n%s", stdout)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "src" && e.Name() != "config.json" {
+			t.Errorf("count-only wrote %s", e.Name())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// csvColumn is one column of CSV output: its header and how to read the
+// value from a finding.
+type csvColumn struct {
+	name  string
+	value func(Finding) string
+}
+
+// csvColumnValues maps every column csv_columns may name to its value.
+var csvColumnValues = map[string]func(Finding) string{
+	"Repository":   func(f Finding) string { return f.Repository },
+	"FilePath":     func(f Finding) string { return f.FilePath },
+	"URL":          func(f Finding) string { return f.URL },
+	"OriginalURL":  func(f Finding) string { return f.OriginalURL },
+	"Pattern":      func(f Finding) string { return f.Pattern },
+	"Severity":     func(f Finding) string { return f.Severity },
+	"Line":         func(f Finding) string { return strconv.Itoa(f.Line) },
+	"Snippet":      func(f Finding) string { return f.Snippet },
+	"Ref":          func(f Finding) string { return f.Ref },
+	"Host":         func(f Finding) string { return f.Host },
+	"Confidence":   func(f Finding) string { return f.Confidence },
+	"Explanation":  func(f Finding) string { return f.Explanation },
+	"ScanID":       func(f Finding) string { return f.ScanID },
+	"Fingerprint":  func(f Finding) string { return f.Fingerprint },
+	"AlreadyKnown": func(f Finding) string { return strconv.FormatBool(f.AlreadyKnown) },
+	"Public":       func(f Finding) string { return strconv.FormatBool(f.Public) },
+	"Query":        func(f Finding) string { return f.Query },
+	"Group":        func(f Finding) string { return f.Group },
+	"Encoding":     func(f Finding) string { return f.Encoding },
+	"Tags":         func(f Finding) string { return strings.Join(f.Tags, ",") },
+}
+
+// defaultCSVColumns is the CSV layout when csv_columns is not set.
+var defaultCSVColumns = []string{"Repository", "FilePath", "URL", "Pattern", "Severity", "Confidence", "ScanID"}
+
+// parseCSVColumns resolves csv_columns names, in order, to columns. An
+// empty list selects defaultCSVColumns.
+func parseCSVColumns(names []string) ([]csvColumn, error) {
+	if len(names) == 0 {
+		names = defaultCSVColumns
+	}
+	columns := make([]csvColumn, 0, len(names))
+	for _, name := range names {
+		value, ok := csvColumnValues[name]
+		if !ok {
+			return nil, fmt.Errorf("csv_columns: unknown column %q (valid: %s)", name, strings.Join(csvColumnNames(), ", "))
+		}
+		columns = append(columns, csvColumn{name: name, value: value})
+	}
+	return columns, nil
+}
+
+// csvColumnNames returns the valid column names, sorted.
+func csvColumnNames() []string {
+	names := make([]string, 0, len(csvColumnValues))
+	for name := range csvColumnValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeCSV writes a header row and one row per finding.
+func writeCSV(w io.Writer, findings []Finding, columns []csvColumn) error {
+	return writeCSVFrom(w, findingSlice(findings), columns)
+}
+
+func writeCSVFrom(w io.Writer, src findingSource, columns []csvColumn) error {
+	if columns == nil {
+		columns, _ = parseCSVColumns(nil)
+	}
+	cw := csv.NewWriter(w)
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		row[i] = c.name
+	}
+	if err := cw.Write(row); err != nil {
+		return err
+	}
+	err := src.each(func(f Finding) error {
+		for i, c := range columns {
+			row[i] = c.value(f)
+		}
+		return cw.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCustomCSVColumns(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"],
+		"csv_columns": ["Severity", "Line", "Repository", "Snippet"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	findings := []Finding{
+		{Repository: "acme/api", Severity: "HIGH", Line: 12, Snippet: `password = "a,b"`},
+		{Repository: "acme/web", Severity: "LOW", Line: 3, Snippet: "token"},
+	}
+	if err := saveFindings(findings, outputOptions{format: "csv", dir: dir, csvColumns: config.csvColumns}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "findings.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	want := [][]string{
+		{"Severity", "Line", "Repository", "Snippet"},
+		{"HIGH", "12", "acme/api", `password = "a,b"`},
+		{"LOW", "3", "acme/web", "token"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %v, want %v", records, want)
+	}
+}
+
+func TestUnknownCSVColumnRejected(t *testing.T) {
+	_, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "csv_columns": ["Repository", "Owner"]}`))
+	if err == nil || !strings.Contains(err.Error(), `"Owner"`) {
+		t.Errorf("got %v, want an unknown column error naming Owner", err)
+	}
+}
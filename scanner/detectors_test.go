@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// listDetectorsOutput runs list-detectors with args and returns what it
+// printed.
+func listDetectorsOutput(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	runListDetectors(args)
+	os.Stdout = stdout
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestListDetectorsJSON(t *testing.T) {
+	var listed []detectorInfo
+	output := listDetectorsOutput(t, "--format", "json")
+	if err := json.Unmarshal([]byte(output), &listed); err != nil {
+		t.Fatalf("output %q is not JSON: %v", output, err)
+	}
+	registered := registeredDetectors()
+	if len(listed) != len(registered) {
+		t.Fatalf("listed %d detectors, want all %d registered", len(listed), len(registered))
+	}
+	for i, d := range registered {
+		if listed[i].Name != d.Name || listed[i].Severity != d.Severity || listed[i].Description != d.Description {
+			t.Errorf("detector %d listed as %+v, want %+v", i, listed[i], d)
+		}
+	}
+}
+
+func TestListDetectorsTable(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(listDetectorsOutput(t)), "\n")
+	if !strings.HasPrefix(lines[0], "NAME") {
+		t.Fatalf("table starts with %q, want the header", lines[0])
+	}
+	for _, d := range builtinDetectors {
+		found := false
+		for _, line := range lines[1:] {
+			found = found || (strings.HasPrefix(line, d.Name+" ") && strings.Contains(line, d.Example))
+		}
+		if !found {
+			t.Errorf("table has no row for %s with its example", d.Name)
+		}
+	}
+}
+
+func TestBuiltinDetectorExamplesMatch(t *testing.T) {
+	for _, info := range builtinDetectors {
+		d, ok := lookupDetector(info.Name)
+		if !ok {
+			t.Fatalf("%s is not registered", info.Name)
+		}
+		if matches := d.Detect([]byte("value = " + info.Example + "\n")); len(matches) == 0 {
+			t.Errorf("%s does not match its own example %q", info.Name, info.Example)
+		}
+	}
+}
+
+// employeeIDDetector is a custom detector outside the built-in table, as
+// a program embedding the scanner would register.
+type employeeIDDetector struct{}
+
+var employeeIDRe = regexp.MustCompile(`EMP-[0-9]{6}`)
+
+func (employeeIDDetector) Name() string     { return "test-employee-id" }
+func (employeeIDDetector) Severity() string { return "low" }
+
+func (employeeIDDetector) Detect(content []byte) []Match {
+	var matches []Match
+	for i, line := range strings.Split(string(content), "\n") {
+		if id := employeeIDRe.FindString(line); id != "" {
+			matches = append(matches, Match{Line: i + 1, Snippet: line, Value: id})
+		}
+	}
+	return matches
+}
+
+func init() {
+	RegisterDetector(employeeIDDetector{})
+}
+
+func TestRegisteredDetectorContributesFindings(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "staff.env"), []byte("# staff\nowner=EMP-123456\npassword=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := parseConfig([]byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"],
+		"detectors": ["test-employee-id"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := scanLocalPath(context.Background(), config, dir, &RequestStats{})
+	if err != nil {
+		t.Fatalf("scanLocalPath: %v", err)
+	}
+	var custom *Finding
+	for i := range findings {
+		if findings[i].Pattern == "test-employee-id" {
+			custom = &findings[i]
+		}
+	}
+	if len(findings) != 2 || custom == nil {
+		t.Fatalf("got %+v, want the password finding and one from the custom detector", findings)
+	}
+	if custom.Line != 2 || custom.Severity != "LOW" {
+		t.Errorf("custom finding at line %d with severity %s, want line 2, LOW", custom.Line, custom.Severity)
+	}
+}
@@ -0,0 +1,14 @@
+package scanner
+
+import "sort"
+
+// applyDeterministic puts the config into deterministic mode: inputs are
+// scanned in sorted order, any concurrency is disabled and jitter uses a
+// fixed seed, so two runs over the same data produce byte-identical output.
+func applyDeterministic(config *Config) {
+	config.Deterministic = true
+	config.jitter = newJitter(true)
+	sort.Strings(config.SearchPatterns)
+	sort.Strings(config.Repositories)
+	sort.Strings(config.ScanGists)
+}
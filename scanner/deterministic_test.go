@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// shuffledSearchServer returns the same results for every query, in a
+// different order each time.
+func shuffledSearchServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		term := strings.Fields(r.URL.Query().Get("q"))[0]
+		var items []map[string]interface{}
+		for i := 0; i < 6; i++ {
+			items = append(items, map[string]interface{}{
+				"path":       fmt.Sprintf("dir%d/app.env", i),
+				"html_url":   fmt.Sprintf("https://github.com/o/r%d/blob/main/dir%d/app.env", i%3, i),
+				"repository": map[string]string{"full_name": fmt.Sprintf("o/r%d", i%3)},
+				"text_matches": []interface{}{map[string]interface{}{
+					"property": "content",
+					"fragment": term + "=hunter2",
+					"matches":  []interface{}{map[string]interface{}{"text": term, "indices": []int{0, len(term)}}},
+				}},
+			})
+		}
+		rand.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": len(items), "items": items})
+	}))
+}
+
+// deterministicRun scans srv in deterministic mode and returns the findings
+// and metadata files written.
+func deterministicRun(t *testing.T, srv *httptest.Server, patterns string) ([]byte, []byte) {
+	t.Helper()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ` + patterns + `, "file_patterns": ["\\.env$"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	applyDeterministic(config)
+
+	stats := &RequestStats{}
+	findings, err := runScan(context.Background(), config, stats)
+	if err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	keys, _ := parseSortKeys(defaultSortBy)
+	sortFindings(findings, keys)
+	opts := outputOptions{format: "json", dir: t.TempDir()}
+	if err := saveFindings(findings, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveScanMetadata(opts.metadataFile(), len(findings), stats, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	output, err := os.ReadFile(filepath.Join(opts.dir, "findings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := os.ReadFile(opts.metadataFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output, metadata
+}
+
+func TestDeterministicRunsAreIdentical(t *testing.T) {
+	srv := shuffledSearchServer()
+	defer srv.Close()
+
+	output1, metadata1 := deterministicRun(t, srv, `["token", "password", "secret"]`)
+	output2, metadata2 := deterministicRun(t, srv, `["secret", "token", "password"]`)
+	if !bytes.Equal(output1, output2) {
+		t.Errorf("findings differ between runs:\n%s\n%s", output1, output2)
+	}
+	if !bytes.Equal(metadata1, metadata2) {
+		t.Errorf("metadata differs between runs:\n%s\n%s", metadata1, metadata2)
+	}
+	if bytes.Count(output1, []byte(`"repository"`)) != 18 {
+		t.Errorf("output has the wrong number of findings:\n%s", output1)
+	}
+}
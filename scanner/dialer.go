@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const dnsPort = "53"
+
+// defaultConnectTimeout matches the dialer of http.DefaultTransport.
+const defaultConnectTimeout = 30 * time.Second
+
+// defaultClientTimeout bounds a whole request, body included, when
+// client_timeout is not set. It is a safety net beneath the per-request
+// contexts, so a stalled connection cannot hang the scan even with
+// --timeout disabled.
+const defaultClientTimeout = 5 * time.Minute
+
+// clientTimeout returns the http.Client timeout: client_timeout in seconds,
+// or defaultClientTimeout.
+func (c *Config) clientTimeout() time.Duration {
+	if c.ClientTimeout > 0 {
+		return secondsToDuration(c.ClientTimeout)
+	}
+	return defaultClientTimeout
+}
+
+// newBaseTransport returns the transport used beneath authTransport. It is
+// the default transport unless dns_server, prefer_ipv6, the TLS options or
+// one of the timeouts need a customised one. connect_timeout bounds establishing the
+// TCP connection, tls_handshake_timeout the TLS handshake and
+// response_header_timeout the wait for response headers once the request
+// is sent (all in seconds); reading the body is bounded by the request's
+// context and the client timeout.
+func newBaseTransport(config *Config) http.RoundTripper {
+	tlsConfig := config.tlsConfig()
+	if config.DNSServer == "" && !config.PreferIPv6 && config.ConnectTimeout <= 0 &&
+		config.TLSHandshakeTimeout <= 0 && config.ResponseHeaderTimeout <= 0 && tlsConfig == nil {
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = newDialer(config).DialContext
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	if config.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = secondsToDuration(config.TLSHandshakeTimeout)
+	}
+	if config.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = secondsToDuration(config.ResponseHeaderTimeout)
+	}
+	return transport
+}
+
+// dialer resolves hosts with an optional custom DNS server and, when
+// preferIPv6 is set, tries IPv6 addresses before IPv4 ones.
+type dialer struct {
+	resolver   *net.Resolver
+	dialer     *net.Dialer
+	preferIPv6 bool
+}
+
+func newDialer(config *Config) *dialer {
+	connectTimeout := defaultConnectTimeout
+	if config.ConnectTimeout > 0 {
+		connectTimeout = secondsToDuration(config.ConnectTimeout)
+	}
+	d := &dialer{
+		resolver:   net.DefaultResolver,
+		dialer:     &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second},
+		preferIPv6: config.PreferIPv6,
+	}
+	if config.DNSServer != "" {
+		server := config.DNSServer
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, dnsPort)
+		}
+		d.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return d.dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return d
+}
+
+func (d *dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if d.preferIPv6 {
+		sort.SliceStable(ips, func(i, j int) bool {
+			return ips[i].IP.To4() == nil && ips[j].IP.To4() != nil
+		})
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,208 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDNSServer answers A queries for name with 127.0.0.1 over UDP and
+// records every name queried.
+type fakeDNSServer struct {
+	conn net.PacketConn
+	name string
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func newFakeDNSServer(t *testing.T, name string) *fakeDNSServer {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeDNSServer{conn: conn, name: name}
+	go s.serve()
+	return s
+}
+
+func (s *fakeDNSServer) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.answer(buf[:n]); resp != nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *fakeDNSServer) answer(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+	// Walk the question's name labels.
+	var labels []string
+	i := 12
+	for i < len(query) && query[i] != 0 {
+		l := int(query[i])
+		if i+1+l > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[i+1:i+1+l]))
+		i += 1 + l
+	}
+	if i+5 > len(query) {
+		return nil
+	}
+	question := query[12 : i+5]
+	qtype := binary.BigEndian.Uint16(query[i+1:])
+	name := strings.Join(labels, ".")
+	s.mu.Lock()
+	s.queries = append(s.queries, name)
+	s.mu.Unlock()
+
+	resp := make([]byte, 12, 64)
+	copy(resp, query[:2])
+	flags, answers := uint16(0x8180), uint16(0)
+	switch {
+	case !strings.EqualFold(name, s.name):
+		flags |= 3 // NXDOMAIN
+	case qtype == 1:
+		answers = 1
+	}
+	binary.BigEndian.PutUint16(resp[2:], flags)
+	binary.BigEndian.PutUint16(resp[4:], 1)
+	binary.BigEndian.PutUint16(resp[6:], answers)
+	resp = append(resp, question...)
+	if answers == 1 {
+		resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 1)
+	}
+	return resp
+}
+
+func TestCustomDNSServerResolvesHosts(t *testing.T) {
+	dns := newFakeDNSServer(t, "scanner.test")
+	defer dns.conn.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "dns_server": "` + dns.conn.LocalAddr().String() + `"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(srv.URL)
+	client := &http.Client{Transport: newBaseTransport(config)}
+	resp, err := client.Get("http://scanner.test:" + u.Port() + "/")
+	if err != nil {
+		t.Fatalf("request through the custom resolver failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("body %q, want ok", body)
+	}
+
+	dns.mu.Lock()
+	defer dns.mu.Unlock()
+	found := false
+	for _, q := range dns.queries {
+		found = found || q == "scanner.test"
+	}
+	if !found {
+		t.Errorf("DNS server saw queries %v, want scanner.test", dns.queries)
+	}
+}
+
+func TestTransportTimeoutsFromConfig(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"],
+		"connect_timeout": 3, "tls_handshake_timeout": 4.5, "response_header_timeout": 0.2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, ok := newBaseTransport(config).(*http.Transport)
+	if !ok {
+		t.Fatal("timeouts did not give a customised transport")
+	}
+	if transport.TLSHandshakeTimeout != 4500*time.Millisecond {
+		t.Errorf("TLSHandshakeTimeout = %v, want 4.5s", transport.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != 200*time.Millisecond {
+		t.Errorf("ResponseHeaderTimeout = %v, want 200ms", transport.ResponseHeaderTimeout)
+	}
+	if d := newDialer(config); d.dialer.Timeout != 3*time.Second {
+		t.Errorf("connect timeout = %v, want 3s", d.dialer.Timeout)
+	}
+	if newBaseTransport(&Config{}) != http.DefaultTransport {
+		t.Error("a config without timeouts did not use the default transport")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	start := time.Now()
+	if _, err := (&http.Client{Transport: transport}).Get(srv.URL); err == nil {
+		t.Fatal("request succeeded past response_header_timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("slow headers were waited on for %v", elapsed)
+	}
+}
+
+func TestHTTPClientAlwaysHasTimeout(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := newHTTPClient(config).Timeout; got != defaultClientTimeout {
+		t.Errorf("default client timeout = %v, want %v", got, defaultClientTimeout)
+	}
+
+	// Headers arrive at once but the body stalls, which only the client
+	// timeout catches.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	config, err = parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "client_timeout": 0.3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newHTTPClient(config)
+	if client.Timeout != 300*time.Millisecond {
+		t.Fatalf("client timeout = %v, want 300ms", client.Timeout)
+	}
+	start := time.Now()
+	resp, err := client.Get(srv.URL)
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil {
+		t.Fatal("stalled body read succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stalled body was waited on for %v", elapsed)
+	}
+}
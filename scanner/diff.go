@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// diffReport splits two findings files into the findings only in the newer
+// one (Added), only in the older one (Removed) and in both (Common, as
+// recorded in the newer file).
+type diffReport struct {
+	Added   []Finding `json:"added"`
+	Removed []Finding `json:"removed"`
+	Common  []Finding `json:"common"`
+}
+
+// diffFindings compares findings by fingerprint. Findings saved without a
+// fingerprint, by runs older than fingerprinting, have one computed.
+func diffFindings(before, after []Finding) diffReport {
+	key := func(f Finding) string {
+		if f.Fingerprint != "" {
+			return f.Fingerprint
+		}
+		return fingerprint(f)
+	}
+	inBefore := make(map[string]bool, len(before))
+	for _, f := range before {
+		inBefore[key(f)] = true
+	}
+	inAfter := make(map[string]bool, len(after))
+	report := diffReport{Added: []Finding{}, Removed: []Finding{}, Common: []Finding{}}
+	for _, f := range after {
+		k := key(f)
+		if inAfter[k] {
+			continue
+		}
+		inAfter[k] = true
+		if inBefore[k] {
+			report.Common = append(report.Common, f)
+		} else {
+			report.Added = append(report.Added, f)
+		}
+	}
+	seen := make(map[string]bool, len(before))
+	for _, f := range before {
+		k := key(f)
+		if !inAfter[k] && !seen[k] {
+			seen[k] = true
+			report.Removed = append(report.Removed, f)
+		}
+	}
+	return report
+}
+
+// runDiff implements the diff subcommand: diff [flags] a.json b.json
+// reports the findings added in b, removed since a and common to both,
+// without scanning.
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "Report format (text, json or csv)")
+	errorFormat := fs.String("error-format", "text", "Format for fatal errors (text or json, written to stderr)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: diff [flags] OLD.json NEW.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		exitWithError(*errorFormat, errCodeUsage, "Invalid arguments", fmt.Errorf("diff needs exactly two findings files"))
+	}
+
+	before, err := loadFindings(fs.Arg(0))
+	if err != nil {
+		exitWithError(*errorFormat, errCodeUsage, "Error loading findings", err)
+	}
+	after, err := loadFindings(fs.Arg(1))
+	if err != nil {
+		exitWithError(*errorFormat, errCodeUsage, "Error loading findings", err)
+	}
+	if err := writeDiffReport(os.Stdout, diffFindings(before, after), *format); err != nil {
+		exitWithError(*errorFormat, errCodeOutput, "Error writing report", err)
+	}
+}
+
+// writeDiffReport writes report in format. The csv format prefixes the
+// default CSV columns with a Change column.
+func writeDiffReport(w io.Writer, report diffReport, format string) error {
+	sections := []struct {
+		name     string
+		findings []Finding
+	}{
+		{"added", report.Added},
+		{"removed", report.Removed},
+		{"common", report.Common},
+	}
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "csv":
+		columns, _ := parseCSVColumns(nil)
+		cw := csv.NewWriter(w)
+		row := []string{"Change"}
+		for _, c := range columns {
+			row = append(row, c.name)
+		}
+		cw.Write(row)
+		for _, s := range sections {
+			for _, f := range s.findings {
+				row = append(row[:0], s.name)
+				for _, c := range columns {
+					row = append(row, c.value(f))
+				}
+				cw.Write(row)
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, s := range sections {
+			fmt.Fprintf(tw, "%s (%d):\n", s.name, len(s.findings))
+			for _, f := range s.findings {
+				fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", f.Severity, f.Repository, f.FilePath, f.Pattern)
+			}
+		}
+		fmt.Fprintf(tw, "\n%d added, %d removed, %d common\n", len(report.Added), len(report.Removed), len(report.Common))
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported report format: %s", format)
+	}
+}
@@ -0,0 +1,69 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func diffTestFinding(repo, path, pattern string) Finding {
+	f := Finding{Repository: repo, FilePath: path, Pattern: pattern, Severity: "HIGH"}
+	f.Fingerprint = fingerprint(f)
+	return f
+}
+
+func pathsOf(findings []Finding) []string {
+	var paths []string
+	for _, f := range findings {
+		paths = append(paths, f.Repository+"/"+f.FilePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestDiffFindingsCategorizes(t *testing.T) {
+	kept := diffTestFinding("acme/api", ".env", "password")
+	fixed := diffTestFinding("acme/api", "old.yml", "token")
+	introduced := diffTestFinding("acme/web", "app.env", "password")
+
+	// The same finding on a different line is still common.
+	moved := kept
+	moved.Line = 40
+	// Older files may not carry fingerprints.
+	unfingerprinted := fixed
+	unfingerprinted.Fingerprint = ""
+
+	report := diffFindings([]Finding{kept, unfingerprinted}, []Finding{moved, introduced})
+	if got := pathsOf(report.Added); strings.Join(got, ",") != "acme/web/app.env" {
+		t.Errorf("added = %v", got)
+	}
+	if got := pathsOf(report.Removed); strings.Join(got, ",") != "acme/api/old.yml" {
+		t.Errorf("removed = %v", got)
+	}
+	if len(report.Common) != 1 || report.Common[0].Line != 40 {
+		t.Errorf("common = %+v, want the newer copy of the kept finding", report.Common)
+	}
+}
+
+func TestWriteDiffReportCSV(t *testing.T) {
+	report := diffFindings(
+		[]Finding{diffTestFinding("o/a", "x", "p")},
+		[]Finding{diffTestFinding("o/b", "y", "p")},
+	)
+	var buf bytes.Buffer
+	if err := writeDiffReport(&buf, report, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "Change" || rows[1][0] != "added" || rows[2][0] != "removed" {
+		t.Errorf("rows = %v", rows)
+	}
+	if err := writeDiffReport(&buf, report, "yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
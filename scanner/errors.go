@@ -0,0 +1,60 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Codes reported in machine-readable fatal errors.
+const (
+	errCodeUsage    = "usage_error"
+	errCodeConfig   = "config_error"
+	errCodeAuth     = "auth_error"
+	errCodeOutput   = "output_error"
+	errCodeSelfTest = "selftest_error"
+	errCodeHook     = "hook_error"
+	errCodeServe    = "serve_error"
+)
+
+// Exit statuses other than 0 and 1, which stays reserved for fatal errors.
+const (
+	// exitCodeFindings is the exit status of --count-only when anything
+	// was found.
+	exitCodeFindings = 2
+	// exitCodeTruncated means --max-runtime or max_total_findings stopped
+	// the scan: the output was written but only covers what was scanned.
+	exitCodeTruncated = 3
+)
+
+var errUnauthorized = errors.New("unauthorized: check that the GitHub token is valid")
+
+// fatalError is the structured form of a fatal failure, written to stderr
+// with --error-format json so orchestrators can tell failures apart.
+type fatalError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// exitWithError reports a fatal failure in the requested format and exits
+// non-zero. The default text format matches the scanner's regular output.
+func exitWithError(errorFormat, code, message string, err error) {
+	if errorFormat == "json" {
+		fe := fatalError{Code: code, Message: message}
+		if err != nil {
+			fe.Detail = err.Error()
+		}
+		data, _ := json.Marshal(fe)
+		fmt.Fprintln(os.Stderr, string(data))
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Printf("%s: %v\n", message, err)
+	} else {
+		fmt.Println(message)
+	}
+	os.Exit(1)
+}
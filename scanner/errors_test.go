@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMainSubprocess runs Main with the arguments in SCANNER_TEST_ARGS, one
+// per line. runMain starts it in a subprocess, since Main exits the process
+// on fatal errors.
+func TestMainSubprocess(t *testing.T) {
+	args := os.Getenv("SCANNER_TEST_ARGS")
+	if args == "" {
+		t.Skip("only run as a subprocess")
+	}
+	os.Args = append([]string{"scanner"}, strings.Split(args, "\n")...)
+	Main()
+}
+
+// runMain runs Main with args in dir and returns its stdout, its stderr and
+// the exit error, if any.
+func runMain(t *testing.T, dir string, args ...string) (string, string, error) {
+	t.Helper()
+	exe, err := filepath.Abs(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(exe, "-test.run=^TestMainSubprocess$")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "SCANNER_TEST_ARGS="+strings.Join(args, "\n"))
+	var stdout, stderr strings.Builder
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err = cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestJSONErrorForBadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"github_token": `), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err := runMain(t, dir, "-config", path, "-error-format", "json")
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("got %v, want exit status 1", err)
+	}
+
+	var fe fatalError
+	if err := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &fe); err != nil {
+		t.Fatalf("stderr %q is not one JSON error: %v", stderr, err)
+	}
+	if fe.Code != errCodeConfig || fe.Message != "Error loading config" || fe.Detail == "" {
+		t.Errorf("got %+v, want a config_error with a detail", fe)
+	}
+}
@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxExpandWords bounds a keyword's length; longer phrases are better
+	// written out as patterns.
+	maxExpandWords = 4
+	// maxExpandedPatterns bounds the patterns expand_patterns may add in
+	// total, since every one costs at least one search request.
+	maxExpandedPatterns = 200
+)
+
+var expandWordRe = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// splitKeyword splits a keyword into its lower-cased words at _, -, . and
+// spaces and at camelCase boundaries: "apiKey", "api_key" and "API-KEY"
+// all give [api key].
+func splitKeyword(keyword string) []string {
+	var words []string
+	for _, part := range strings.FieldsFunc(keyword, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || unicode.IsSpace(r)
+	}) {
+		start := 0
+		runes := []rune(part)
+		for i := 1; i < len(runes); i++ {
+			if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]) {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+	return words
+}
+
+// expandKeyword returns the common spellings of a keyword: its words
+// joined by _, - or nothing, in lower and upper case, plus camelCase and
+// PascalCase. "api_key" expands to api_key, api-key, apikey, API_KEY,
+// API-KEY, APIKEY, apiKey and ApiKey. A one-word keyword gives its lower
+// and upper case forms. Variants are deduplicated and kept in that order.
+func expandKeyword(keyword string) ([]string, error) {
+	words := splitKeyword(keyword)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("expand_patterns: empty keyword")
+	}
+	if len(words) > maxExpandWords {
+		return nil, fmt.Errorf("expand_patterns: %q has more than %d words", keyword, maxExpandWords)
+	}
+	for _, w := range words {
+		if !expandWordRe.MatchString(w) {
+			return nil, fmt.Errorf("expand_patterns: %q must be letters and digits separated by _, -, . or spaces", keyword)
+		}
+	}
+
+	title := make([]string, len(words))
+	for i, w := range words {
+		title[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	var variants []string
+	for _, sep := range []string{"_", "-", ""} {
+		variants = append(variants, strings.Join(words, sep))
+	}
+	for _, sep := range []string{"_", "-", ""} {
+		variants = append(variants, strings.ToUpper(strings.Join(words, sep)))
+	}
+	variants = append(variants, words[0]+strings.Join(title[1:], ""), strings.Join(title, ""))
+
+	seen := make(map[string]bool)
+	unique := variants[:0]
+	for _, v := range variants {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique, nil
+}
+
+// expandPatterns adds the variants of every expand_patterns keyword to
+// search_patterns. Variants already listed are collapsed later with the
+// other duplicates.
+func (c *Config) expandPatterns() error {
+	added := 0
+	for _, keyword := range c.ExpandPatterns {
+		variants, err := expandKeyword(keyword)
+		if err != nil {
+			return err
+		}
+		if added += len(variants); added > maxExpandedPatterns {
+			return fmt.Errorf("expand_patterns: expands to more than %d patterns", maxExpandedPatterns)
+		}
+		c.SearchPatterns = append(c.SearchPatterns, variants...)
+	}
+	return nil
+}
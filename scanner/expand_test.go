@@ -0,0 +1,58 @@
+package scanner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandKeyword(t *testing.T) {
+	want := []string{"api_key", "api-key", "apikey", "API_KEY", "API-KEY", "APIKEY", "apiKey", "ApiKey"}
+	for _, keyword := range []string{"api_key", "apiKey", "API-KEY", "api key"} {
+		got, err := expandKeyword(keyword)
+		if err != nil {
+			t.Fatalf("%s: %v", keyword, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expandKeyword(%q) = %v, want %v", keyword, got, want)
+		}
+	}
+}
+
+func TestExpandSingleWord(t *testing.T) {
+	got, err := expandKeyword("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"password", "PASSWORD", "Password"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestExpandPatternsBounded(t *testing.T) {
+	if _, err := expandKeyword("one_two_three_four_five"); err == nil {
+		t.Error("expected an error for a keyword over the word limit")
+	}
+	if _, err := expandKeyword("api_key(.*)"); err == nil {
+		t.Error("expected an error for a keyword with regex syntax")
+	}
+
+	var keywords []string
+	for i := 0; i < maxExpandedPatterns; i++ {
+		keywords = append(keywords, `"key`+strings.Repeat("x", i)+`_id"`)
+	}
+	if _, err := parseConfig([]byte(`{"expand_patterns": [` + strings.Join(keywords, ",") + `]}`)); err == nil {
+		t.Error("expected an error past the total expansion limit")
+	}
+}
+
+func TestExpandPatternsMergesWithSearchPatterns(t *testing.T) {
+	config, err := parseConfig([]byte(`{"search_patterns": ["api_key", "token"], "expand_patterns": ["api_key"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"api_key", "token", "api-key", "apikey", "API_KEY", "API-KEY", "APIKEY", "apiKey", "ApiKey"}
+	if !reflect.DeepEqual(config.SearchPatterns, want) {
+		t.Errorf("search patterns = %v, want %v", config.SearchPatterns, want)
+	}
+}
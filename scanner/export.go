@@ -0,0 +1,184 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// exportMapping describes how findings are reshaped for an external schema.
+// Each entry in Fields maps a target field, dot-separated for nesting, to
+// either the name of a Finding field (Go or JSON name, copied with its
+// type), a text/template evaluated per finding, or a literal string.
+// When Root is set the findings array is wrapped in an object under it.
+type exportMapping struct {
+	Name   string            `json:"name"`
+	Root   string            `json:"root"`
+	Fields map[string]string `json:"fields"`
+
+	templates map[string]*template.Template
+}
+
+// builtinExportMappings ship with the scanner and can be selected by name.
+var builtinExportMappings = map[string]string{
+	// DefectDojo's "Generic Findings Import" JSON format.
+	"defectdojo": `{
+  "name": "defectdojo",
+  "root": "findings",
+  "fields": {
+    "title": "{{.Pattern}} found in {{.FilePath}}",
+    "severity": "{{title .Severity}}",
+    "description": "Pattern {{printf \"%q\" .Pattern}} matched {{.FilePath}} in {{.Repository}}.",
+    "file_path": "FilePath",
+    "line": "Line",
+    "component_name": "Repository",
+    "references": "URL",
+    "static_finding": "true",
+    "active": "true"
+  }
+}`,
+}
+
+var exportTemplateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"title": func(s string) string {
+		if s == "" {
+			return s
+		}
+		lower := strings.ToLower(s)
+		return strings.ToUpper(lower[:1]) + lower[1:]
+	},
+}
+
+// findingFields indexes Finding's fields by both Go and JSON name.
+var findingFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(Finding{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fields[f.Name] = i
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			fields[tag] = i
+		}
+	}
+	return fields
+}()
+
+// loadExportMapping loads a mapping by built-in name or from a JSON file.
+func loadExportMapping(nameOrPath string) (*exportMapping, error) {
+	if nameOrPath == "" {
+		return nil, fmt.Errorf("output format export requires --export-mapping")
+	}
+
+	data := []byte(builtinExportMappings[nameOrPath])
+	if len(data) == 0 {
+		var err error
+		data, err = ioutil.ReadFile(nameOrPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading export mapping: %v", err)
+		}
+	}
+
+	var m exportMapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error parsing export mapping: %v", err)
+	}
+	if len(m.Fields) == 0 {
+		return nil, fmt.Errorf("export mapping %s defines no fields", nameOrPath)
+	}
+
+	m.templates = make(map[string]*template.Template)
+	for target, source := range m.Fields {
+		if !strings.Contains(source, "{{") {
+			continue
+		}
+		tmpl, err := template.New(target).Funcs(exportTemplateFuncs).Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing export mapping field %s: %v", target, err)
+		}
+		m.templates[target] = tmpl
+	}
+	return &m, nil
+}
+
+// mapFinding converts a single finding into the target shape.
+func (m *exportMapping) mapFinding(f Finding) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	v := reflect.ValueOf(f)
+
+	targets := make([]string, 0, len(m.Fields))
+	for target := range m.Fields {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		source := m.Fields[target]
+		var value interface{}
+		if tmpl, ok := m.templates[target]; ok {
+			var sb strings.Builder
+			if err := tmpl.Execute(&sb, f); err != nil {
+				return nil, fmt.Errorf("error rendering export field %s: %v", target, err)
+			}
+			value = sb.String()
+		} else if idx, ok := findingFields[source]; ok {
+			value = v.Field(idx).Interface()
+		} else {
+			value = literalValue(source)
+		}
+		setNested(out, strings.Split(target, "."), value)
+	}
+	return out, nil
+}
+
+// literalValue keeps JSON literals such as true or 42 typed in the output.
+func literalValue(s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		switch v.(type) {
+		case bool, float64, nil:
+			return v
+		}
+	}
+	return s
+}
+
+func setNested(m map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := m[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			m[key] = child
+		}
+		m = child
+	}
+	m[path[len(path)-1]] = value
+}
+
+func writeExport(w io.Writer, m *exportMapping, findings []Finding) error {
+	mapped := make([]map[string]interface{}, 0, len(findings))
+	for _, f := range findings {
+		out, err := m.mapFinding(f)
+		if err != nil {
+			return err
+		}
+		mapped = append(mapped, out)
+	}
+
+	var doc interface{} = mapped
+	if m.Root != "" {
+		doc = map[string]interface{}{m.Root: mapped}
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling export: %v", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCustomExportMappingShape(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	mapping := `{
+  "root": "results",
+  "fields": {
+    "id": "{{.Repository}}#{{.Line}}",
+    "location.file": "file_path",
+    "location.line": "Line",
+    "level": "{{lower .Severity}}",
+    "tool": "github-security-scanner",
+    "verified": "false"
+  }
+}`
+	if err := os.WriteFile(path, []byte(mapping), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := loadExportMapping(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	findings := []Finding{{Repository: "o/r", FilePath: ".env", Line: 3, Severity: "HIGH"}}
+	if err := writeFindings(&buf, findings, outputOptions{format: "export", mapping: m}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got, want interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("export %q is not JSON: %v", buf.String(), err)
+	}
+	json.Unmarshal([]byte(`{"results": [{
+		"id": "o/r#3",
+		"location": {"file": ".env", "line": 3},
+		"level": "high",
+		"tool": "github-security-scanner",
+		"verified": false
+	}]}`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("export %s, want %v", buf.String(), want)
+	}
+}
+
+func TestBuiltinDefectDojoMapping(t *testing.T) {
+	m, err := loadExportMapping("defectdojo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := m.mapFinding(Finding{Repository: "o/r", FilePath: ".env", Pattern: "password", Severity: "CRITICAL", Line: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["severity"] != "Critical" || out["title"] != "password found in .env" || out["line"] != 7 || out["active"] != true {
+		t.Errorf("mapped %v", out)
+	}
+}
+
+func TestInvalidExportMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	os.WriteFile(path, []byte(`{"fields": {"title": "{{.Pattern"}}`), 0o600)
+	if _, err := loadExportMapping(path); err == nil {
+		t.Error("loadExportMapping accepted a broken field template")
+	}
+}
@@ -0,0 +1,275 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// fetchQueue persists, for fetch_queue_file, the content fetches still
+// owed to finished searches. Once a pattern's search completes its results
+// are written to the queue with the ones that need their file fetched
+// marked pending, and each fetch is recorded as it finishes. A run that
+// crashes can then be resumed: patterns already in the queue are not
+// searched again, only their pending fetches are made. The file is removed
+// after a scan that runs to completion.
+//
+// The file is rewritten only when a search is queued. Completed fetches
+// are appended to a log next to it, path plus ".log", which is replayed on
+// load and cleared by the next rewrite, so a fetch costs one small append
+// rather than rewriting every queued finding.
+type fetchQueue struct {
+	path string
+	mu   sync.Mutex
+	log  *os.File
+	// Entries is keyed by fetchQueueKey.
+	Entries map[string]*fetchQueueEntry `json:"entries"`
+}
+
+// fetchCompletion is a completion log record: the fetched finding at Index
+// of Key's entry, and whether it was dropped.
+type fetchCompletion struct {
+	Key     string  `json:"key"`
+	Index   int     `json:"index"`
+	Finding Finding `json:"finding"`
+	Dropped bool    `json:"dropped,omitempty"`
+}
+
+// fetchQueueEntry holds one pattern's search results; Pending lists the
+// indices into Findings whose content has not been fetched yet, and
+// Dropped the ones whose fetched content only matched placeholders.
+type fetchQueueEntry struct {
+	Findings []Finding `json:"findings"`
+	Pending  []int     `json:"pending"`
+	Dropped  []int     `json:"dropped,omitempty"`
+}
+
+// loadFetchQueue reads the queue file, returning an empty queue if it does
+// not exist yet.
+func loadFetchQueue(path string) (*fetchQueue, error) {
+	q := &fetchQueue{path: path, Entries: make(map[string]*fetchQueueEntry)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading fetch queue: %v", err)
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("error parsing fetch queue: %v", err)
+	}
+	if q.Entries == nil {
+		q.Entries = make(map[string]*fetchQueueEntry)
+	}
+	if err := q.replayLog(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *fetchQueue) logPath() string {
+	return q.path + ".log"
+}
+
+// replayLog applies the completion log to the loaded entries. Replaying a
+// completion the file already reflects changes nothing, and a final line
+// cut short by a crash is ignored.
+func (q *fetchQueue) replayLog() error {
+	data, err := ioutil.ReadFile(q.logPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading fetch queue log: %v", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var c fetchCompletion
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			break
+		}
+		q.applyLocked(c)
+	}
+	return nil
+}
+
+// fetchQueueKey identifies a pattern's entry. It includes the API URL
+// because multi-host scans share one queue.
+func fetchQueueKey(config *Config, pattern string) string {
+	return config.apiURL() + " " + pattern
+}
+
+// pending returns how many fetches are queued for key and whether the
+// queue has an entry for it at all.
+func (q *fetchQueue) pending(key string) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.Entries[key]
+	if !ok {
+		return 0, false
+	}
+	return len(e.Pending), true
+}
+
+// entry returns copies of key's findings and pending indices, and the set
+// of dropped indices.
+func (q *fetchQueue) entry(key string) ([]Finding, []int, map[int]bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.Entries[key]
+	if !ok {
+		return nil, nil, nil
+	}
+	dropped := make(map[int]bool, len(e.Dropped))
+	for _, idx := range e.Dropped {
+		dropped[idx] = true
+	}
+	return append([]Finding(nil), e.Findings...), append([]int(nil), e.Pending...), dropped
+}
+
+// enqueue records a finished search for key, queueing the findings that
+// still need their content fetched.
+func (q *fetchQueue) enqueue(key string, findings []Finding) error {
+	e := &fetchQueueEntry{Findings: findings, Pending: []int{}}
+	for i, f := range findings {
+		if f.pendingFetch {
+			e.Pending = append(e.Pending, i)
+		}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.Entries[key] = e
+	return q.saveLocked()
+}
+
+// complete stores the fetched finding at index idx of key and drops it
+// from the pending list, appending the completion to the log. keep false
+// records the finding as dropped.
+func (q *fetchQueue) complete(key string, idx int, f Finding, keep bool) error {
+	c := fetchCompletion{Key: key, Index: idx, Finding: f, Dropped: !keep}
+	line, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("error marshaling fetch completion: %v", err)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.applyLocked(c) {
+		return nil
+	}
+	if q.log == nil {
+		if q.log, err = os.OpenFile(q.logPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600); err != nil {
+			return fmt.Errorf("error opening fetch queue log: %v", err)
+		}
+	}
+	if _, err := q.log.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing fetch queue log: %v", err)
+	}
+	return nil
+}
+
+// applyLocked applies a completion to its entry and reports whether the
+// entry exists.
+func (q *fetchQueue) applyLocked(c fetchCompletion) bool {
+	e, ok := q.Entries[c.Key]
+	if !ok || c.Index < 0 || c.Index >= len(e.Findings) {
+		return false
+	}
+	e.Findings[c.Index] = c.Finding
+	if c.Dropped {
+		e.Dropped = append(e.Dropped, c.Index)
+	}
+	for i, p := range e.Pending {
+		if p == c.Index {
+			e.Pending = append(e.Pending[:i], e.Pending[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// saveLocked rewrites the queue file and then clears the completion log,
+// which the file now reflects.
+func (q *fetchQueue) saveLocked() error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return fmt.Errorf("error marshaling fetch queue: %v", err)
+	}
+	if err := writeBytesAtomic(q.path, data); err != nil {
+		return fmt.Errorf("error writing fetch queue: %v", err)
+	}
+	return q.removeLogLocked()
+}
+
+func (q *fetchQueue) removeLogLocked() error {
+	if q.log != nil {
+		q.log.Close()
+		q.log = nil
+	}
+	if err := os.Remove(q.logPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing fetch queue log: %v", err)
+	}
+	return nil
+}
+
+// close closes the completion log, keeping the files for a later resume.
+func (q *fetchQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.log != nil {
+		q.log.Close()
+		q.log = nil
+	}
+}
+
+// remove deletes the queue file and its log once nothing is left to
+// resume.
+func (q *fetchQueue) remove() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.removeLogLocked(); err != nil {
+		return err
+	}
+	if err := os.Remove(q.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing fetch queue: %v", err)
+	}
+	return nil
+}
+
+// fetchPending makes the content fetches queued for key, recording each
+// in the queue as it completes, and returns the entry's findings without
+// the dropped ones. A fetch cut short by ctx stays queued for the next run.
+func fetchPending(ctx context.Context, config *Config, key string, stats *RequestStats) ([]Finding, error) {
+	q := config.fetchQueue
+	findings, pending, dropped := q.entry(key)
+	client := newHTTPClient(config)
+	matcher := newContentMatcher(config)
+	var err error
+	for _, idx := range pending {
+		f := findings[idx]
+		keep := describeFromContent(ctx, client, config, matcher, &f, stats)
+		if ctx.Err() != nil {
+			break
+		}
+		f.pendingFetch = false
+		findings[idx] = f
+		if !keep {
+			dropped[idx] = true
+		}
+		if err = q.complete(key, idx, f, keep); err != nil {
+			break
+		}
+	}
+	kept := findings[:0]
+	for i, f := range findings {
+		if !dropped[i] {
+			kept = append(kept, f)
+		}
+	}
+	return kept, err
+}
@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFetchQueueResumesAfterCrash(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	ctx, crash := context.WithCancel(context.Background())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, strings.TrimPrefix(r.URL.Path, "/repos/o/r/contents/"))
+		n := len(fetched)
+		mu.Unlock()
+		// The first run dies during its second fetch.
+		if n == 2 {
+			crash()
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, "# config\npassword=hunter2\n")
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := loadFetchQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.fetchQueue = q
+	key := fetchQueueKey(config, "password")
+	var queued []Finding
+	for _, name := range []string{"a.env", "b.env", "c.env"} {
+		queued = append(queued, Finding{Repository: "o/r", FilePath: name, Pattern: "password", Confidence: confidenceLow, pendingFetch: true})
+	}
+	if err := q.enqueue(key, queued); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetchPending(ctx, config, key, &RequestStats{})
+	q.close()
+
+	// The completed fetch went to the log; the queue file is untouched.
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, snapshot) {
+		t.Error("queue file rewritten for a completed fetch")
+	}
+	if log, err := os.ReadFile(path + ".log"); err != nil || bytes.Count(log, []byte("\n")) != 1 {
+		t.Errorf("completion log %q (%v), want one record", log, err)
+	}
+
+	resumed, err := loadFetchQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := resumed.pending(key); n != 2 {
+		t.Fatalf("%d fetches pending after the crash, want 2", n)
+	}
+	config.fetchQueue = resumed
+	findings, err := fetchPending(context.Background(), config, key, &RequestStats{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fetched, ","); got != "a.env,b.env,b.env,c.env" {
+		t.Errorf("fetched %s, want a.env and b.env, then only b.env and c.env on resume", got)
+	}
+	for _, f := range findings {
+		if f.Line != 2 || f.Confidence != confidenceHigh {
+			t.Errorf("%s: line %d, confidence %s; want the fetched match", f.FilePath, f.Line, f.Confidence)
+		}
+	}
+	if err := resumed.remove(); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{path, path + ".log"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s left behind after remove", p)
+		}
+	}
+}
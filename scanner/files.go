@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// fileMatcher decides which file paths are worth reporting or fetching.
+// Extensions from file_extensions are checked first with map lookups on the
+// file name's suffixes; only paths that miss fall through to the globs in
+// file_globs and the regular expressions in file_patterns.
+type fileMatcher struct {
+	extensions map[string]bool
+	globs      []string
+	patterns   []*regexp.Regexp
+	// foldCase makes globs match regardless of case; file patterns get
+	// the (?i) flag instead.
+	foldCase bool
+}
+
+func newFileMatcher(config *Config) (*fileMatcher, error) {
+	m := &fileMatcher{extensions: make(map[string]bool), foldCase: config.CaseInsensitive}
+	for _, ext := range config.FileExtensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		m.extensions[ext] = true
+	}
+	for _, g := range config.FileGlobs {
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, fmt.Errorf("invalid file glob %q: %v", g, err)
+		}
+		if m.foldCase {
+			g = strings.ToLower(g)
+		}
+		m.globs = append(m.globs, g)
+	}
+	for _, p := range config.FilePatterns {
+		re, err := regexp.Compile(caseFlag(config) + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file pattern %q: %v", p, err)
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+// matchesExtension checks every dot-suffix of the file name, so ".env"
+// matches both ".env" and "prod.env", and ".tar.gz" works as an extension.
+func (m *fileMatcher) matchesExtension(filePath string) bool {
+	if len(m.extensions) == 0 {
+		return false
+	}
+	name := strings.ToLower(path.Base(filePath))
+	for i := 0; i < len(name); i++ {
+		if name[i] == '.' && m.extensions[name[i:]] {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *fileMatcher) matches(filePath string) bool {
+	if m.matchesExtension(filePath) {
+		return true
+	}
+	globPath := filePath
+	if m.foldCase {
+		globPath = strings.ToLower(filePath)
+	}
+	for _, g := range m.globs {
+		if matchGlob(g, globPath) {
+			return true
+		}
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// caseFlag is the regexp flag prefix for case_insensitive. GitHub code
+// search ignores case, so with the option set file patterns, content
+// patterns and severity rules do too. Built-in detectors keep their exact
+// formats.
+func caseFlag(config *Config) string {
+	if config.CaseInsensitive {
+		return "(?i)"
+	}
+	return ""
+}
+
+// matchGlob matches filePath against a shell-style glob. Unlike
+// file_patterns, which are unanchored regular expressions over the whole
+// path, globs are anchored: a glob without a slash such as "*.env" is
+// matched against the file name alone, so it matches ".env" and
+// "deploy/prod.env" but not "env.txt". A glob with a slash is matched
+// against the whole path, where "**" stands for any number of directories,
+// so "config/**/*.yml" matches "config/app.yml" and "config/a/b/app.yml".
+func matchGlob(glob, filePath string) bool {
+	if !strings.Contains(glob, "/") {
+		ok, _ := path.Match(glob, path.Base(filePath))
+		return ok
+	}
+	return matchSegments(strings.Split(glob, "/"), strings.Split(filePath, "/"))
+}
+
+func matchSegments(glob, segments []string) bool {
+	if len(glob) == 0 {
+		return len(segments) == 0
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(glob[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(glob[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(glob[1:], segments[1:])
+}
+
+// matchesFile reports whether path passes the configured file filters.
+func (c *Config) matchesFile(filePath string) bool {
+	files := c.files
+	if files == nil {
+		var err error
+		if files, err = newFileMatcher(c); err != nil {
+			return false
+		}
+	}
+	return files.matches(filePath)
+}
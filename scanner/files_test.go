@@ -0,0 +1,62 @@
+package scanner
+
+import "testing"
+
+func TestFileExtensionsWithPatterns(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"],
+		"file_extensions": ["env", ".PEM", ".tar.gz"], "file_patterns": ["^config/.*\\.json$"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]bool{
+		".env":             true,
+		"deploy/prod.env":  true,
+		"keys/server.pem":  true,
+		"keys/SERVER.PEM":  true,
+		"backup.tar.gz":    true,
+		"config/app.json":  true,
+		"env.txt":          false,
+		"other/app.json":   false,
+		"docs/pem.md":      false,
+		"backup.tar.gz.sh": false,
+	}
+	for path, want := range cases {
+		if got := config.matchesFile(path); got != want {
+			t.Errorf("matchesFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestFileGlobs(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"],
+		"file_globs": ["*.env", "id_rsa*", "config/**/*.yml", "secrets/*.json"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]bool{
+		".env":                  true,
+		"deploy/prod.env":       true,
+		"home/.ssh/id_rsa":      true,
+		"home/.ssh/id_rsa.pub":  true,
+		"config/app.yml":        true,
+		"config/a/b/app.yml":    true,
+		"secrets/prod.json":     true,
+		"env.txt":               false,
+		"prod.env.example":      false,
+		"other/config/app.yml":  false,
+		"config/app.yaml":       false,
+		"secrets/a/prod.json":   false,
+		"deploy/secrets/x.json": false,
+	}
+	for path, want := range cases {
+		if got := config.matchesFile(path); got != want {
+			t.Errorf("matchesFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestInvalidFileGlob(t *testing.T) {
+	if _, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_globs": ["[a-"]}`)); err == nil {
+		t.Error("parseConfig accepted a malformed glob")
+	}
+}
@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+)
+
+// findingCap enforces max_total_findings: the first finding beyond the cap
+// is dropped and cancels the scan's context, so nothing more is fetched.
+// Multi-host scans share one cap through their host configs.
+type findingCap struct {
+	max int
+
+	mu      sync.Mutex
+	count   int
+	reached bool
+	cancel  context.CancelFunc
+}
+
+// limitFindings starts a finding cap for one scan, returning the context
+// the scan runs under. Without max_total_findings it returns ctx as is.
+func (c *Config) limitFindings(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.MaxTotalFindings <= 0 {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.findingCap = &findingCap{max: c.MaxTotalFindings, cancel: cancel}
+	return ctx, cancel
+}
+
+// claimFinding counts a finding against max_total_findings, reporting
+// whether it is kept. The first claim over the cap stops the scan.
+func (c *Config) claimFinding() bool {
+	fc := c.findingCap
+	if fc == nil {
+		return true
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.count >= fc.max {
+		if !fc.reached {
+			fc.reached = true
+			fc.cancel()
+		}
+		return false
+	}
+	fc.count++
+	return true
+}
+
+// findingCapReached reports whether the last scan was stopped by
+// max_total_findings.
+func (c *Config) findingCapReached() bool {
+	fc := c.findingCap
+	if fc == nil {
+		return false
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.reached
+}
@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestScanStopsAtFindingCap(t *testing.T) {
+	var searches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&searches, 1)
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		var items []map[string]interface{}
+		for i := 0; i < 10; i++ {
+			items = append(items, map[string]interface{}{
+				"path":       fmt.Sprintf("file%d.env", i),
+				"html_url":   fmt.Sprintf("https://github.com/o/r/blob/main/file%d.env", i),
+				"repository": map[string]string{"full_name": "o/r"},
+				"text_matches": []interface{}{map[string]interface{}{
+					"property": "content",
+					"fragment": "password=hunter2",
+					"matches":  []interface{}{map[string]interface{}{"text": "password", "indices": []int{0, 8}}},
+				}},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": len(items), "items": items})
+	}))
+	defer srv.Close()
+
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password", "secret"], "file_patterns": ["\\.env$"], "max_total_findings": 4}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := runScan(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	if len(findings) != 4 {
+		t.Errorf("got %d findings, want the cap of 4", len(findings))
+	}
+	if !config.findingCapReached() {
+		t.Error("cap not reported as reached")
+	}
+	if n := atomic.LoadInt32(&searches); n != 1 {
+		t.Errorf("%d searches, want the second pattern skipped", n)
+	}
+}
+
+func TestFindingCapUnderConcurrency(t *testing.T) {
+	config := &Config{MaxTotalFindings: 50}
+	ctx, stop := config.limitFindings(context.Background())
+	defer stop()
+
+	var kept int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if config.claimFinding() {
+					atomic.AddInt32(&kept, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if kept != 50 {
+		t.Errorf("kept %d findings, want 50", kept)
+	}
+	if ctx.Err() == nil {
+		t.Error("scan context not cancelled at the cap")
+	}
+}
+
+func TestFindingCapNotReachedAtExactCount(t *testing.T) {
+	config := &Config{MaxTotalFindings: 2}
+	ctx, stop := config.limitFindings(context.Background())
+	defer stop()
+	config.claimFinding()
+	config.claimFinding()
+	if config.findingCapReached() || ctx.Err() != nil {
+		t.Error("cap reported as exceeded with exactly max findings")
+	}
+}
@@ -0,0 +1,26 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// fingerprint identifies a finding across runs. It covers where the match
+// was found and which pattern matched, but not the line or URL, which change
+// as unrelated edits land or commits move.
+func fingerprint(f Finding) string {
+	parts := []string{f.Repository, f.Ref, f.FilePath, f.Pattern}
+	if f.Host != "" {
+		parts = append(parts, f.Host)
+	}
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h[:16])
+}
+
+// assignFingerprints sets the Fingerprint of every finding in place.
+func assignFingerprints(findings []Finding) {
+	for i := range findings {
+		findings[i].Fingerprint = fingerprint(findings[i])
+	}
+}
@@ -0,0 +1,47 @@
+package scanner
+
+import "sync"
+
+// repoHits records, for first_hit_per_repo, the repositories that already
+// have a finding so the rest of their results can be skipped without
+// fetching anything. Repositories are keyed with the API URL because
+// multi-host scans share one set.
+type repoHits struct {
+	mu   sync.Mutex
+	hits map[string]bool
+}
+
+func newRepoHits() *repoHits {
+	return &repoHits{hits: make(map[string]bool)}
+}
+
+func repoHitKey(config *Config, repo string) string {
+	return config.apiURL() + " " + repo
+}
+
+// claimRepoHit marks repo as hit, reporting whether this call was the first to do
+// so. Without first_hit_per_repo every claim succeeds.
+func (c *Config) claimRepoHit(repo string) bool {
+	if !c.FirstHitPerRepo {
+		return true
+	}
+	key := repoHitKey(c, repo)
+	c.repoHits.mu.Lock()
+	defer c.repoHits.mu.Unlock()
+	if c.repoHits.hits[key] {
+		return false
+	}
+	c.repoHits.hits[key] = true
+	return true
+}
+
+// repoHit reports whether first_hit_per_repo is on and repo already has its
+// finding.
+func (c *Config) repoHit(repo string) bool {
+	if !c.FirstHitPerRepo {
+		return false
+	}
+	c.repoHits.mu.Lock()
+	defer c.repoHits.mu.Unlock()
+	return c.repoHits.hits[repoHitKey(c, repo)]
+}
@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFirstHitPerRepoSearch(t *testing.T) {
+	var contentFetches int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/code", func(w http.ResponseWriter, r *http.Request) {
+		var items []string
+		for _, repo := range []string{"acme/api", "acme/web"} {
+			for i := 0; i < 3; i++ {
+				items = append(items, fmt.Sprintf(`{"path": "conf%d.env", "html_url": "https://github.com/%s/blob/main/conf%d.env", "repository": {"full_name": %q}}`, i, repo, i, repo))
+			}
+		}
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		fmt.Fprintf(w, `{"total_count": %d, "items": [%s]}`, len(items), strings.Join(items, ","))
+	})
+	mux.HandleFunc("/repos/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&contentFetches, 1)
+		w.Write([]byte("password=hunter2\n"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"], "first_hit_per_repo": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := searchGitHubQuery(context.Background(), config, "password", "", &RequestStats{}, newTopicCache())
+	if err != nil {
+		t.Fatalf("searchGitHubQuery: %v", err)
+	}
+	perRepo := make(map[string]int)
+	for _, f := range findings {
+		perRepo[f.Repository]++
+	}
+	if len(findings) != 2 || perRepo["acme/api"] != 1 || perRepo["acme/web"] != 1 {
+		t.Errorf("findings per repo = %v, want one each for acme/api and acme/web", perRepo)
+	}
+	if n := atomic.LoadInt32(&contentFetches); n > 2 {
+		t.Errorf("%d content fetches, want at most one per repository", n)
+	}
+}
+
+func TestFirstHitPerRepoLocal(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.env", "b.env", "c.env"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("password=hunter2\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config, err := parseConfig([]byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"], "first_hit_per_repo": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := scanLocalPath(context.Background(), config, dir, &RequestStats{})
+	if err != nil {
+		t.Fatalf("scanLocalPath: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Errorf("got %d findings, want 1", len(findings))
+	}
+}
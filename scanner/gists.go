@@ -0,0 +1,104 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type gistFile struct {
+	Filename  string `json:"filename"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated"`
+}
+
+type gist struct {
+	ID      string              `json:"id"`
+	HTMLURL string              `json:"html_url"`
+	Public  bool                `json:"public"`
+	Files   map[string]gistFile `json:"files"`
+}
+
+// listGists returns the IDs of every public gist owned by user.
+func listGists(ctx context.Context, client *http.Client, config *Config, user string, stats *RequestStats) ([]string, error) {
+	const perPage = 100
+	var ids []string
+	for page := 1; ; page++ {
+		var result []gist
+		url := fmt.Sprintf("%s/users/%s/gists?per_page=%d&page=%d", config.apiURL(), user, perPage, page)
+		if err := getJSON(ctx, client, config, url, stats, &result); err != nil {
+			return ids, fmt.Errorf("error listing gists for %s: %v", user, err)
+		}
+		for _, g := range result {
+			ids = append(ids, g.ID)
+		}
+		if len(result) < perPage {
+			return ids, nil
+		}
+	}
+}
+
+// scanGist fetches a single gist, which includes file contents, and scans
+// each file that matches the configured file patterns.
+func scanGist(ctx context.Context, client *http.Client, config *Config, id string, matcher *contentMatcher, stats *RequestStats) ([]Finding, error) {
+	var g gist
+	url := fmt.Sprintf("%s/gists/%s", config.apiURL(), id)
+	if err := getJSON(ctx, client, config, url, stats, &g); err != nil {
+		return nil, fmt.Errorf("error fetching gist %s: %v", id, err)
+	}
+
+	var findings []Finding
+	for _, file := range g.Files {
+		if !config.matchesFile(file.Filename) {
+			continue
+		}
+		if isBinaryContent([]byte(file.Content)) {
+			config.logf("Skipping binary file: %s in gist %s\n", file.Filename, id)
+			stats.IncrementSkippedBinary()
+			continue
+		}
+		if file.Truncated {
+			config.logf("Warning: gist %s file %s is truncated, only the first part is scanned\n", id, file.Filename)
+		}
+		for _, f := range matcher.scan("gist:"+id, file.Filename, g.HTMLURL, []byte(file.Content)) {
+			if !config.claimFinding() {
+				break
+			}
+			config.logf("Found %s: %s in gist %s (line %d)\n", severityLabel(f.Severity), f.FilePath, id, f.Line)
+			f.Public = g.Public
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// scanGists scans every gist of the users listed in scan_gists.
+func scanGists(ctx context.Context, config *Config, stats *RequestStats) ([]Finding, error) {
+	client := newHTTPClient(config)
+	matcher := newContentMatcher(config)
+
+	var allFindings []Finding
+	for _, user := range config.ScanGists {
+		if ctx.Err() != nil {
+			break
+		}
+
+		config.logf("\nScanning gists for: %s\n", user)
+		ids, err := listGists(ctx, client, config, user, stats)
+		if err != nil {
+			config.reportError(err)
+		}
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				break
+			}
+			findings, err := scanGist(ctx, client, config, id, matcher, stats)
+			if err != nil {
+				config.reportError(err)
+				continue
+			}
+			allFindings = append(allFindings, findings...)
+		}
+	}
+	return allFindings, nil
+}
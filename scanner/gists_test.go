@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// gistsServer lists 101 gists for user u over two pages; only the last,
+// on the second page, holds a secret.
+func gistsServer() *httptest.Server {
+	const total = 101
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users/u/gists":
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			var list []map[string]string
+			for i := (page - 1) * 100; i < page*100 && i < total; i++ {
+				list = append(list, map[string]string{"id": fmt.Sprintf("g%d", i)})
+			}
+			json.NewEncoder(w).Encode(list)
+		case strings.HasPrefix(r.URL.Path, "/gists/"):
+			id := strings.TrimPrefix(r.URL.Path, "/gists/")
+			content := "nothing here\n"
+			if id == fmt.Sprintf("g%d", total-1) {
+				content = "password=hunter2\n"
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":       id,
+				"html_url": "https://gist.github.com/u/" + id,
+				"public":   true,
+				"files": map[string]interface{}{
+					"app.env":   map[string]string{"filename": "app.env", "content": content},
+					"notes.txt": map[string]string{"filename": "notes.txt", "content": "password=hunter2\n"},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestScanGistsAcrossPages(t *testing.T) {
+	srv := gistsServer()
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"], "scan_gists": ["u"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := scanGists(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("scanGists: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want only the secret in g100/app.env", len(findings))
+	}
+	f := findings[0]
+	if f.Repository != "gist:g100" || f.FilePath != "app.env" || f.URL != "https://gist.github.com/u/g100" || !f.Public {
+		t.Errorf("got %+v, want a public finding in gist g100", f)
+	}
+}
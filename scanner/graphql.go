@@ -0,0 +1,193 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// graphqlPerPage is the most repositories a GraphQL connection returns per
+// call; one page costs a single point however many repositories it holds.
+const graphqlPerPage = 100
+
+// graphqlOrgReposQuery lists an organization's repositories along with the
+// GraphQL rate limit, which is counted in points separately from REST.
+const graphqlOrgReposQuery = `query($org: String!, $first: Int!, $cursor: String, $privacy: RepositoryPrivacy) {
+  organization(login: $org) {
+    repositories(first: $first, after: $cursor, privacy: $privacy, orderBy: {field: NAME, direction: ASC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes { nameWithOwner isArchived isFork visibility }
+    }
+  }
+  rateLimit { cost remaining resetAt }
+}`
+
+// graphqlRateLimit is the point-based GraphQL rate limit reported with
+// each response.
+type graphqlRateLimit struct {
+	Cost      int       `json:"cost"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+type graphqlOrgReposResponse struct {
+	Data struct {
+		Organization *struct {
+			Repositories struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					NameWithOwner string `json:"nameWithOwner"`
+					IsArchived    bool   `json:"isArchived"`
+					IsFork        bool   `json:"isFork"`
+					Visibility    string `json:"visibility"`
+				} `json:"nodes"`
+			} `json:"repositories"`
+		} `json:"organization"`
+		RateLimit *graphqlRateLimit `json:"rateLimit"`
+	} `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphqlURL is the GraphQL endpoint of the configured API: /graphql on
+// github.com, /api/graphql on GitHub Enterprise Server.
+func (c *Config) graphqlURL() string {
+	base := c.apiURL()
+	if strings.HasSuffix(base, "/api/v3") {
+		return strings.TrimSuffix(base, "/v3") + "/graphql"
+	}
+	return base + "/graphql"
+}
+
+// validateGraphQLOrg rejects scan_org types the GraphQL enumeration
+// cannot express.
+func validateGraphQLOrg(org *orgScanConfig) error {
+	switch org.Type {
+	case "", "all", "public", "private", "forks", "sources":
+		return nil
+	default:
+		return fmt.Errorf("scan_org: type %q is not supported with use_graphql", org.Type)
+	}
+}
+
+// listOrgReposGraphQL is listOrgRepos over the GraphQL API, which returns
+// up to graphqlPerPage repositories per request and draws on the separate
+// point budget. It waits out that budget when the next page would exceed
+// it, and retries a RATE_LIMITED answer after the reset.
+func listOrgReposGraphQL(ctx context.Context, client *http.Client, config *Config, org *orgScanConfig, stats *RequestStats) ([]string, error) {
+	variables := map[string]interface{}{"org": org.Name, "first": graphqlPerPage}
+	switch org.Type {
+	case "public", "private":
+		variables["privacy"] = strings.ToUpper(org.Type)
+	}
+
+	var repos []string
+	var limit *graphqlRateLimit
+	for {
+		if limit != nil && limit.Remaining < limit.Cost {
+			if err := waitGraphQLReset(ctx, config, limit.ResetAt, stats); err != nil {
+				return repos, err
+			}
+		}
+		var result graphqlOrgReposResponse
+		if err := postGraphQL(ctx, client, config, graphqlOrgReposQuery, variables, stats, &result); err != nil {
+			return repos, fmt.Errorf("error listing repositories for %s: %v", org.Name, err)
+		}
+		if result.Data.Organization == nil {
+			return repos, fmt.Errorf("error listing repositories for %s: organization not found", org.Name)
+		}
+		limit = result.Data.RateLimit
+
+		conn := result.Data.Organization.Repositories
+		for _, r := range conn.Nodes {
+			if r.IsArchived && !org.IncludeArchived {
+				continue
+			}
+			if (org.Type == "forks" && !r.IsFork) || (org.Type == "sources" && r.IsFork) {
+				continue
+			}
+			if org.Visibility != "" && strings.ToLower(r.Visibility) != org.Visibility {
+				continue
+			}
+			repos = append(repos, r.NameWithOwner)
+		}
+		if !conn.PageInfo.HasNextPage {
+			return repos, nil
+		}
+		variables["cursor"] = conn.PageInfo.EndCursor
+	}
+}
+
+// postGraphQL sends query to the GraphQL endpoint and decodes the response
+// into result. GraphQL reports errors, including its rate limit, in a 200
+// response; a RATE_LIMITED answer is retried after the reset, up to
+// max_retries times, and other errors are returned.
+func postGraphQL(ctx context.Context, client *http.Client, config *Config, query string, variables map[string]interface{}, stats *RequestStats, result *graphqlOrgReposResponse) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("error encoding query: %v", err)
+	}
+	policy := newRetryPolicy(config)
+	for attempt := 0; ; attempt++ {
+		resp, err := doWithRetry(ctx, client, func() (*http.Request, error) {
+			req, err := newGitHubRequestWithBody(ctx, config, "POST", config.graphqlURL(), bytes.NewReader(body))
+			if err == nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			return req, err
+		}, policy, stats)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			stats.IncrementFailed()
+			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		*result = graphqlOrgReposResponse{}
+		err = json.NewDecoder(resp.Body).Decode(result)
+		resp.Body.Close()
+		if err != nil {
+			stats.IncrementFailed()
+			return fmt.Errorf("error decoding response: %v", err)
+		}
+
+		if len(result.Errors) == 0 {
+			stats.IncrementSuccess()
+			return nil
+		}
+		stats.IncrementFailed()
+		if result.Errors[0].Type != "RATE_LIMITED" || attempt >= policy.maxRetries {
+			return fmt.Errorf("graphql error: %s", result.Errors[0].Message)
+		}
+		stats.IncrementRateLimit()
+		reset := time.Now()
+		if n, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			reset = time.Unix(n, 0)
+		}
+		if err := waitGraphQLReset(ctx, config, reset, stats); err != nil {
+			return err
+		}
+	}
+}
+
+// waitGraphQLReset sleeps until the GraphQL point budget resets.
+func waitGraphQLReset(ctx context.Context, config *Config, reset time.Time, stats *RequestStats) error {
+	wait := time.Until(reset)
+	if wait < 0 {
+		wait = 0
+	}
+	config.logf("GraphQL rate limit reached, waiting %v for reset\n", wait.Round(time.Second))
+	stats.AddWaitTime(wait)
+	return sleepContext(ctx, wait)
+}
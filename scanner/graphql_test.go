@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// graphqlOrgServer serves two pages of organization repositories. The
+// first request is answered RATE_LIMITED, and the first page reports the
+// point budget as spent, so both waits are exercised.
+func graphqlOrgServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" || r.Method != "POST" {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		n := atomic.AddInt32(requests, 1)
+		if n == 1 {
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Unix()))
+			fmt.Fprint(w, `{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`)
+			return
+		}
+		var payload struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Variables["org"] != "acme" || payload.Variables["privacy"] != "PUBLIC" {
+			t.Errorf("variables %v", payload.Variables)
+		}
+		type node struct {
+			NameWithOwner string `json:"nameWithOwner"`
+			IsArchived    bool   `json:"isArchived"`
+			IsFork        bool   `json:"isFork"`
+			Visibility    string `json:"visibility"`
+		}
+		nodes := []node{{"acme/api", false, false, "PUBLIC"}, {"acme/old", true, false, "PUBLIC"}}
+		hasNext, remaining := true, 0
+		if payload.Variables["cursor"] == "c1" {
+			nodes = []node{{"acme/web", false, false, "PUBLIC"}}
+			hasNext, remaining = false, 4998
+		}
+		resp := map[string]interface{}{"data": map[string]interface{}{
+			"organization": map[string]interface{}{"repositories": map[string]interface{}{
+				"pageInfo": map[string]interface{}{"hasNextPage": hasNext, "endCursor": "c1"},
+				"nodes":    nodes,
+			}},
+			"rateLimit": map[string]interface{}{"cost": 1, "remaining": remaining, "resetAt": time.Now().Format(time.RFC3339)},
+		}}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestListOrgReposGraphQL(t *testing.T) {
+	var requests int32
+	srv := graphqlOrgServer(t, &requests)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "scan_org": {"name": "acme", "type": "public", "use_graphql": true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	stats := &RequestStats{}
+	repos, err := repositoriesToScan(context.Background(), http.DefaultClient, config, stats)
+	if err != nil {
+		t.Fatalf("repositoriesToScan: %v", err)
+	}
+	if want := []string{"acme/api", "acme/web"}; !reflect.DeepEqual(repos, want) {
+		t.Errorf("got %v, want %v", repos, want)
+	}
+	if requests != 3 {
+		t.Errorf("%d GraphQL requests, want 3 (one rate limited, two pages)", requests)
+	}
+	if stats.RateLimitHits != 1 {
+		t.Errorf("%d rate limit hits, want 1", stats.RateLimitHits)
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	for base, want := range map[string]string{
+		"https://api.github.com":         "https://api.github.com/graphql",
+		"https://ghe.example.com/api/v3": "https://ghe.example.com/api/graphql",
+	} {
+		c := &Config{apiBase: base}
+		if got := c.graphqlURL(); got != want {
+			t.Errorf("graphqlURL(%s) = %s, want %s", base, got, want)
+		}
+	}
+}
+
+func TestGraphQLRejectsMemberType(t *testing.T) {
+	_, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "scan_org": {"name": "acme", "type": "member", "use_graphql": true}}`))
+	if err == nil {
+		t.Fatal("expected an error for type member")
+	}
+}
@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ungroupedSuffix names the per-group output file of findings that belong
+// to no pattern group.
+const ungroupedSuffix = "-ungrouped"
+
+// patternGroup is one pattern_groups entry: a family of patterns, such as
+// cloud or database credentials, searched with the extra code search
+// qualifiers in Scope (e.g. "org:acme language:go").
+type patternGroup struct {
+	Patterns []string `json:"patterns"`
+	Scope    string   `json:"scope"`
+}
+
+// patternGroups is pattern_groups, keyed by group name.
+type patternGroups map[string]patternGroup
+
+// initPatternGroups adds the pattern_groups patterns to search_patterns
+// and records which group each belongs to. A pattern may belong to only one
+// group.
+func (c *Config) initPatternGroups() error {
+	if len(c.PatternGroups) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(c.PatternGroups))
+	for name := range c.PatternGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c.groupOf = make(map[string]string)
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("pattern_groups: invalid group name %q", name)
+		}
+		for _, p := range c.PatternGroups[name].Patterns {
+			if other, ok := c.groupOf[p]; ok && other != name {
+				return fmt.Errorf("pattern_groups: pattern %q is in both %s and %s", p, other, name)
+			}
+			if _, ok := c.groupOf[p]; !ok {
+				c.SearchPatterns = append(c.SearchPatterns, p)
+			}
+			c.groupOf[p] = name
+		}
+	}
+	return nil
+}
+
+// groupScope returns the code search qualifiers of the pattern's group,
+// joined for a query, or "" when it has none.
+func (c *Config) groupScope(pattern string) string {
+	group, ok := c.groupOf[pattern]
+	if !ok {
+		return ""
+	}
+	return strings.Join(strings.Fields(c.PatternGroups[group].Scope), "+")
+}
+
+// tagPatternGroups sets each finding's Group from its pattern.
+func tagPatternGroups(config *Config, findings []Finding) {
+	for i := range findings {
+		findings[i].Group = config.groupOf[findings[i].Pattern]
+	}
+}
+
+// saveFindingsByGroup writes one output file per pattern group present,
+// such as findings-cloud.json, with findings outside every group in
+// findings-ungrouped.json. It returns the files written.
+func saveFindingsByGroup(findings []Finding, opts outputOptions) ([]string, error) {
+	groups := make(map[string][]Finding)
+	for _, f := range findings {
+		groups[f.Group] = append(groups[f.Group], f)
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	// Ungrouped findings go last.
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "" || names[j] == "" {
+			return names[j] == "" && names[i] != ""
+		}
+		return names[i] < names[j]
+	})
+
+	var files []string
+	for _, name := range names {
+		o := opts
+		o.suffix = "-" + name
+		if name == "" {
+			o.suffix = ungroupedSuffix
+		}
+		if err := saveFindings(groups[name], o); err != nil {
+			return files, err
+		}
+		files = append(files, o.fileName())
+	}
+	return files, nil
+}
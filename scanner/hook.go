@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const defaultHookTimeout = 60 * time.Second
+
+// hookEnv returns the environment passed to the post-scan hook: the
+// scanner's own environment plus finding counts, the scan ID and the output
+// file (comma-separated when output is split by severity).
+func hookEnv(findings []Finding, outputFile, scanID string) []string {
+	counts := countBySeverity(findings)
+
+	env := append(os.Environ(),
+		fmt.Sprintf("SCANNER_FINDINGS_TOTAL=%d", len(findings)),
+		"SCANNER_OUTPUT_FILE="+outputFile,
+		"SCANNER_SCAN_ID="+scanID,
+	)
+	for _, severity := range severityLevels {
+		env = append(env, fmt.Sprintf("SCANNER_FINDINGS_%s=%d", severity, counts[severity]))
+	}
+	return env
+}
+
+// runPostHook runs command through the shell with the findings as JSON on
+// stdin, bounded by timeout and by ctx. A non-zero exit or a timeout is
+// returned as an error that includes the hook's exit code.
+func runPostHook(parent context.Context, command string, timeout time.Duration, findings []Finding, outputFile, scanID string) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	data, err := json.Marshal(findings)
+	if err != nil {
+		return fmt.Errorf("error marshaling findings for hook: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = hookEnv(findings, outputFile, scanID)
+
+	fmt.Printf("\nRunning post-scan hook: %s\n", command)
+	err = cmd.Run()
+	if parent.Err() != nil {
+		return fmt.Errorf("post-scan hook stopped at the run's deadline")
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("post-scan hook timed out after %v", timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("post-scan hook exited with code %d", exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("error running post-scan hook: %v", err)
+	}
+	fmt.Println("Post-scan hook completed successfully")
+	return nil
+}
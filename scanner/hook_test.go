@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostHookStopsAtRunDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := runPostHook(ctx, "sleep 5", time.Minute, nil, "", "")
+	if err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Fatalf("runPostHook returned %v, want the run's deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("hook ran for %v after the run's deadline", elapsed)
+	}
+}
+
+func TestPostHookTimeout(t *testing.T) {
+	err := runPostHook(context.Background(), "sleep 5", 100*time.Millisecond, nil, "", "")
+	if err == nil || !strings.Contains(err.Error(), "timed out after") {
+		t.Fatalf("runPostHook returned %v, want a timeout", err)
+	}
+}
+
+func TestPostHookReceivesFindings(t *testing.T) {
+	findings := []Finding{{Repository: "o/r", FilePath: ".env", Severity: "HIGH"}}
+	err := runPostHook(context.Background(), `test "$SCANNER_FINDINGS_HIGH" = 1 && grep -q '"repository":"o/r"'`, time.Minute, findings, "out.json", "id")
+	if err != nil {
+		t.Fatalf("runPostHook: %v", err)
+	}
+	if err := runPostHook(context.Background(), "exit 4", time.Minute, nil, "", ""); err == nil || !strings.Contains(err.Error(), "code 4") {
+		t.Errorf("failing hook returned %v, want exit code 4", err)
+	}
+}
+
+func TestPostHookStdinAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	stdin, env := filepath.Join(dir, "stdin.json"), filepath.Join(dir, "env")
+	findings := []Finding{
+		{Repository: "o/a", FilePath: ".env", Severity: "HIGH"},
+		{Repository: "o/b", FilePath: "id_rsa", Severity: "CRITICAL"},
+	}
+	command := `cat > "$STDIN_FILE" && echo "$SCANNER_FINDINGS_TOTAL $SCANNER_FINDINGS_CRITICAL $SCANNER_SCAN_ID $SCANNER_OUTPUT_FILE" > "$ENV_FILE"`
+	t.Setenv("STDIN_FILE", stdin)
+	t.Setenv("ENV_FILE", env)
+	if err := runPostHook(context.Background(), command, time.Minute, findings, "findings.json", "scan-1"); err != nil {
+		t.Fatalf("runPostHook: %v", err)
+	}
+
+	data, err := os.ReadFile(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received []Finding
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatalf("stdin %q is not findings JSON: %v", data, err)
+	}
+	if !reflect.DeepEqual(received, findings) {
+		t.Errorf("hook received %+v, want %+v", received, findings)
+	}
+	if data, _ := os.ReadFile(env); strings.TrimSpace(string(data)) != "2 1 scan-1 findings.json" {
+		t.Errorf("hook environment %q", data)
+	}
+}
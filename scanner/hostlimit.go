@@ -0,0 +1,65 @@
+package scanner
+
+import (
+	"net/http"
+	"sync"
+)
+
+// hostLimiter caps the requests in flight to one host, so a slow GitHub
+// Enterprise Server cannot tie up the workers scanning other hosts. A
+// request holds its slot until its response body is closed.
+type hostLimiter struct {
+	slots chan struct{}
+}
+
+func newHostLimiter(n int) *hostLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return &hostLimiter{slots: make(chan struct{}, n)}
+}
+
+// limitTransport is the transport of a host with a max_concurrency.
+type limitTransport struct {
+	base    http.RoundTripper
+	limiter *hostLimiter
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.limiter.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	var once sync.Once
+	release := func() { once.Do(func() { <-t.limiter.slots }) }
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// hostConcurrency returns h's request limit: its own max_concurrency, else
+// max_concurrency_per_host. Zero means no limit.
+func (c *Config) hostConcurrency(h hostEntry) int {
+	if h.MaxConcurrency > 0 {
+		return h.MaxConcurrency
+	}
+	return c.MaxConcurrencyPerHost
+}
+
+// initHostLimiters creates one limiter per host. They live on the config,
+// not on the per-scan copies forHost makes, so concurrent scans of the same
+// host share its limit.
+func (c *Config) initHostLimiters() {
+	c.hostLimiters = make(map[string]*hostLimiter, len(c.Hosts))
+	for _, h := range c.Hosts {
+		if limiter := newHostLimiter(c.hostConcurrency(h)); limiter != nil {
+			c.hostLimiters[h.Name] = limiter
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// hostEntry is one GitHub instance to scan, such as github.com or a GitHub
+// Enterprise Server. api_url is the REST base, e.g.
+// https://github.example.com/api/v3; web_url defaults to api_url without
+// its /api/v3 suffix. A host without a token uses the top-level tokens.
+// max_concurrency caps the host's requests in flight, overriding
+// max_concurrency_per_host.
+type hostEntry struct {
+	Name           string   `json:"name"`
+	APIURL         string   `json:"api_url"`
+	WebURL         string   `json:"web_url"`
+	Token          string   `json:"token"`
+	Tokens         []string `json:"tokens"`
+	MaxConcurrency int      `json:"max_concurrency"`
+}
+
+func (h hostEntry) webURL() string {
+	if h.WebURL != "" {
+		return strings.TrimSuffix(h.WebURL, "/")
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(h.APIURL, "/"), "/api/v3")
+}
+
+func validateHosts(hosts []hostEntry) error {
+	seen := make(map[string]bool)
+	for i, h := range hosts {
+		if h.Name == "" || h.APIURL == "" {
+			return fmt.Errorf("hosts[%d]: name and api_url are required", i)
+		}
+		if seen[h.Name] {
+			return fmt.Errorf("hosts[%d]: duplicate host name %s", i, h.Name)
+		}
+		seen[h.Name] = true
+	}
+	return nil
+}
+
+// apiURL is the REST API base for requests made with this config.
+func (c *Config) apiURL() string {
+	if c.apiBase != "" {
+		return c.apiBase
+	}
+	return githubAPIURL
+}
+
+// webURL is the base for links to files in the web UI.
+func (c *Config) webURL() string {
+	if c.webBase != "" {
+		return c.webBase
+	}
+	return githubWebURL
+}
+
+// forHost returns a copy of the config that targets h. Each host gets its
+// own token pool, so concurrency limits and rate limits are tracked per
+// host, and its own incremental state file, since repository names can
+// repeat across hosts.
+func (c *Config) forHost(h hostEntry) *Config {
+	hc := *c
+	hc.Hosts = nil
+	hc.apiBase = strings.TrimSuffix(h.APIURL, "/")
+	hc.webBase = h.webURL()
+
+	var tokens []string
+	if h.Token != "" {
+		tokens = append(tokens, h.Token)
+	}
+	for _, t := range h.Tokens {
+		if t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	tokens, _ = dedupeTokens(tokens)
+	if len(tokens) == 0 {
+		tokens = c.configTokens()
+	}
+	hc.tokenPool = newTokenPool(tokens, c.PerTokenConcurrency)
+	if limiter := c.hostLimiters[h.Name]; limiter != nil {
+		base := c.transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		hc.transport = &limitTransport{base: base, limiter: limiter}
+	}
+	hc.host = h.Name
+
+	if c.Incremental {
+		statePath := c.stateFilePath()
+		ext := filepath.Ext(statePath)
+		hc.StateFile = strings.TrimSuffix(statePath, ext) + "." + h.Name + ext
+	}
+	return &hc
+}
+
+// runHostScans runs the scan against every configured host into spool,
+// tagging each finding with its host. Hosts are scanned concurrently
+// unless deterministic output was requested.
+func runHostScans(ctx context.Context, config *Config, stats *RequestStats, spool *findingSpool) error {
+	errs := make([]error, len(config.Hosts))
+
+	var wg sync.WaitGroup
+	for i, h := range config.Hosts {
+		scanHost := func(i int, h hostEntry) {
+			hostCtx, hostSpan := startSpan(ctx, "host")
+			hostSpan.setAttribute("host", h.Name)
+			defer hostSpan.finish()
+
+			config.logf("\nScanning host %s (%s)\n", h.Name, h.APIURL)
+			err := scanSources(hostCtx, config.forHost(h), stats, spool)
+			hostSpan.recordError(err)
+			errs[i] = err
+		}
+		if config.Deterministic {
+			scanHost(i, h)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, h hostEntry) {
+			defer wg.Done()
+			scanHost(i, h)
+		}(i, h)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("host %s: %w", config.Hosts[i].Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// hostSearchServer answers code search under prefix with one finding in
+// repo, failing any request not made with token.
+func hostSearchServer(t *testing.T, prefix, token, repo string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(prefix+"/", http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token "+token {
+			t.Errorf("%s host got Authorization %q", repo, got)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": 1, "items": []interface{}{map[string]interface{}{
+			"path":       "app.env",
+			"html_url":   "https://example.com/" + repo + "/blob/main/app.env",
+			"repository": map[string]string{"full_name": repo},
+			"text_matches": []interface{}{map[string]interface{}{
+				"property": "content",
+				"fragment": "password=hunter2",
+				"matches":  []interface{}{map[string]interface{}{"text": "password", "indices": []int{0, 8}}},
+			}},
+		}}})
+	})))
+	return httptest.NewServer(mux)
+}
+
+func TestMultiHostScanMergesFindings(t *testing.T) {
+	public := hostSearchServer(t, "", "ghp_public", "o/public")
+	defer public.Close()
+	enterprise := hostSearchServer(t, "/api/v3", "ghp_enterprise", "corp/internal")
+	defer enterprise.Close()
+
+	config, err := parseConfig([]byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"], "hosts": [
+		{"name": "github", "api_url": "` + public.URL + `", "token": "ghp_public"},
+		{"name": "ghe", "api_url": "` + enterprise.URL + `/api/v3", "token": "ghp_enterprise"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := runScan(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Host+":"+f.Repository)
+	}
+	sort.Strings(got)
+	if want := "ghe:corp/internal,github:o/public"; strings.Join(got, ",") != want {
+		t.Errorf("findings %v, want %s", got, want)
+	}
+}
+
+func TestEachHostRespectsItsConcurrencyLimit(t *testing.T) {
+	slowMock := &pagedSearchServer{total: 8 * searchPerPage}
+	slow := httptest.NewServer(slowMock)
+	defer slow.Close()
+	otherMock := &pagedSearchServer{total: 8 * searchPerPage}
+	other := httptest.NewServer(otherMock)
+	defer other.Close()
+
+	config, err := parseConfig([]byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"],
+		"page_concurrency": 4, "max_concurrency_per_host": 2, "hosts": [
+		{"name": "ghe", "api_url": "` + slow.URL + `", "token": "t", "max_concurrency": 1},
+		{"name": "github", "api_url": "` + other.URL + `", "token": "t"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := runScan(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	if len(findings) != 2*8*searchPerPage {
+		t.Fatalf("got %d findings, want every page from both hosts", len(findings))
+	}
+	if slowMock.maxInFlight != 1 {
+		t.Errorf("ghe had %d requests in flight, want its max_concurrency of 1", slowMock.maxInFlight)
+	}
+	if otherMock.maxInFlight != 2 {
+		t.Errorf("github had %d requests in flight, want max_concurrency_per_host of 2", otherMock.maxInFlight)
+	}
+}
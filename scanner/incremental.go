@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+const defaultStateFile = "scan_state.json"
+
+// compareFilesLimit is the most files the compare API lists for a whole
+// comparison, however it is paginated; a list this long may be cut short.
+const compareFilesLimit = 300
+
+// scanState records the last fully scanned commit SHA for each repository
+// so incremental scans only need to look at what changed since.
+type scanState struct {
+	Repos map[string]string `json:"repos"`
+}
+
+func (c *Config) stateFilePath() string {
+	if c.StateFile != "" {
+		return c.StateFile
+	}
+	return defaultStateFile
+}
+
+// loadScanState reads the state file, returning an empty state if it does
+// not exist yet.
+func loadScanState(path string) (*scanState, error) {
+	state := &scanState{Repos: make(map[string]string)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state file: %v", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error parsing state file: %v", err)
+	}
+	if state.Repos == nil {
+		state.Repos = make(map[string]string)
+	}
+	return state, nil
+}
+
+func (s *scanState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling state: %v", err)
+	}
+	if err := writeBytesAtomic(path, data); err != nil {
+		return fmt.Errorf("error writing state file: %v", err)
+	}
+	return nil
+}
+
+// compareChangedFiles lists the files added or modified between base and
+// head. complete is false when the comparison lists compareFilesLimit
+// files, since the API silently drops the rest.
+func compareChangedFiles(ctx context.Context, client *http.Client, config *Config, repo, base, head string, stats *RequestStats) (paths []string, complete bool, err error) {
+	var comparison struct {
+		Files []struct {
+			Filename string `json:"filename"`
+			Status   string `json:"status"`
+		} `json:"files"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/compare/%s...%s", config.apiURL(), repo, base, head)
+	if err := getJSON(ctx, client, config, url, stats, &comparison); err != nil {
+		return nil, false, fmt.Errorf("error comparing %s %s...%s: %v", repo, shortSHA(base), shortSHA(head), err)
+	}
+
+	for _, f := range comparison.Files {
+		if f.Status != "removed" {
+			paths = append(paths, f.Filename)
+		}
+	}
+	return paths, len(comparison.Files) < compareFilesLimit, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// compareServer mocks the repository endpoints an incremental scan uses.
+// compareFiles is how many files the compare API lists (0 answers 404),
+// and truncatedTree marks the full tree as truncated.
+type compareServer struct {
+	compareFiles  int
+	truncatedTree bool
+
+	mu      sync.Mutex
+	fetched []string
+}
+
+func (s *compareServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/repos/o/r/commits/HEAD":
+		fmt.Fprint(w, `{"sha": "new"}`)
+	case r.URL.Path == "/repos/o/r/compare/old...new":
+		if s.compareFiles == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		files := []map[string]string{{"filename": "changed.env", "status": "modified"}}
+		for i := 1; i < s.compareFiles; i++ {
+			files = append(files, map[string]string{"filename": "gone" + strconv.Itoa(i) + ".env", "status": "removed"})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"files": files})
+	case r.URL.Path == "/repos/o/r/git/trees/new":
+		fmt.Fprintf(w, `{"tree": [{"path": "changed.env", "type": "blob"}, {"path": "untouched.env", "type": "blob"}], "truncated": %t}`, s.truncatedTree)
+	case strings.HasPrefix(r.URL.Path, "/repos/o/r/contents/"):
+		s.mu.Lock()
+		s.fetched = append(s.fetched, strings.TrimPrefix(r.URL.Path, "/repos/o/r/contents/"))
+		s.mu.Unlock()
+		fmt.Fprint(w, "password=hunter2\n")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// runIncremental scans o/r against mock with the state recording "old"
+// and returns the files fetched and the commit recorded afterwards.
+func runIncremental(t *testing.T, mock *compareServer) ([]string, string) {
+	t.Helper()
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(stateFile, []byte(`{"repos": {"o/r": "old"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"],
+		"repositories": ["o/r"], "incremental": true, "state_file": ` + strconv.Quote(stateFile) + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	if _, err := collectRepositories(context.Background(), config, &RequestStats{}); err != nil {
+		t.Fatalf("scanRepositories: %v", err)
+	}
+
+	state, err := loadScanState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(mock.fetched)
+	return mock.fetched, state.Repos["o/r"]
+}
+
+func TestIncrementalScansOnlyChangedFiles(t *testing.T) {
+	fetched, recorded := runIncremental(t, &compareServer{compareFiles: 1})
+	if strings.Join(fetched, ",") != "changed.env" {
+		t.Errorf("fetched %v, want only changed.env", fetched)
+	}
+	if recorded != "new" {
+		t.Errorf("state records %q, want new", recorded)
+	}
+}
+
+func TestIncrementalTruncatedCompareScansWholeTree(t *testing.T) {
+	fetched, recorded := runIncremental(t, &compareServer{compareFiles: compareFilesLimit})
+	if strings.Join(fetched, ",") != "changed.env,untouched.env" {
+		t.Errorf("fetched %v, want the whole tree", fetched)
+	}
+	if recorded != "new" {
+		t.Errorf("state records %q after a complete tree scan, want new", recorded)
+	}
+}
+
+func TestIncrementalFailedCompareScansWholeTree(t *testing.T) {
+	fetched, recorded := runIncremental(t, &compareServer{compareFiles: 0})
+	if strings.Join(fetched, ",") != "changed.env,untouched.env" {
+		t.Errorf("fetched %v, want the whole tree", fetched)
+	}
+	if recorded != "new" {
+		t.Errorf("state records %q after a complete tree scan, want new", recorded)
+	}
+}
+
+func TestIncrementalIncompleteListKeepsState(t *testing.T) {
+	_, recorded := runIncremental(t, &compareServer{compareFiles: compareFilesLimit, truncatedTree: true})
+	if recorded != "old" {
+		t.Errorf("state records %q after a truncated tree, want old", recorded)
+	}
+}
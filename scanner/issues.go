@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// issueCreateDelay spaces out issue creation; GitHub's content creation
+// limits are much stricter than its read limits.
+const issueCreateDelay = time.Second
+
+// issueFingerprintMarker embeds a finding's fingerprint in the issue body so
+// later runs can recognize issues they already filed.
+const issueFingerprintMarker = "<!-- scanner-fingerprint: %s -->"
+
+var issueFingerprintRe = regexp.MustCompile(`<!-- scanner-fingerprint: ([0-9a-f]+) -->`)
+
+// issuesConfig is the opt-in github_issues setting: Repo is the owner/name
+// repository issues are filed in, with Labels applied to each one.
+type issuesConfig struct {
+	Repo   string   `json:"repo"`
+	Labels []string `json:"labels"`
+}
+
+// issueFingerprints returns the fingerprints recorded in the repo's issues,
+// open and closed, pull requests excluded. Closed issues count so a finding
+// whose issue was closed, as fixed or as a false positive, is not filed
+// again.
+func issueFingerprints(ctx context.Context, client *http.Client, config *Config, repo string, stats *RequestStats) (map[string]bool, error) {
+	const perPage = 100
+	fingerprints := make(map[string]bool)
+	for page := 1; ; page++ {
+		var issues []struct {
+			Body        string          `json:"body"`
+			PullRequest json.RawMessage `json:"pull_request"`
+		}
+		url := fmt.Sprintf("%s/repos/%s/issues?state=all&per_page=%d&page=%d", config.apiURL(), repo, perPage, page)
+		if err := getJSON(ctx, client, config, url, stats, &issues); err != nil {
+			return nil, fmt.Errorf("error listing issues in %s: %v", repo, err)
+		}
+		for _, issue := range issues {
+			if issue.PullRequest != nil {
+				continue
+			}
+			for _, m := range issueFingerprintRe.FindAllStringSubmatch(issue.Body, -1) {
+				fingerprints[m[1]] = true
+			}
+		}
+		if len(issues) < perPage {
+			return fingerprints, nil
+		}
+	}
+}
+
+// issueTitle and issueBody describe a finding. The snippet is left out on
+// purpose: it may contain the secret, and the issue tracker may be more
+// widely readable than the repository it was found in.
+func issueTitle(f Finding) string {
+	return fmt.Sprintf("[%s] Potential secret (%s) in %s/%s", f.Severity, f.Pattern, f.Repository, f.FilePath)
+}
+
+func issueBody(f Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The security scanner found a potential secret.\n\n")
+	fmt.Fprintf(&b, "- Repository: %s\n", f.Repository)
+	fmt.Fprintf(&b, "- File: %s\n", f.FilePath)
+	if f.Line > 0 {
+		fmt.Fprintf(&b, "- Line: %d\n", f.Line)
+	}
+	fmt.Fprintf(&b, "- Pattern: %s\n", f.Pattern)
+	fmt.Fprintf(&b, "- Severity: %s\n", f.Severity)
+	if f.URL != "" {
+		fmt.Fprintf(&b, "- Link: %s\n", f.URL)
+	}
+	if f.ScanID != "" {
+		fmt.Fprintf(&b, "- Scan ID: %s\n", f.ScanID)
+	}
+	fmt.Fprintf(&b, "\n"+issueFingerprintMarker+"\n", f.Fingerprint)
+	return b.String()
+}
+
+// fileIssues opens one issue in github_issues.repo per finding that has no
+// issue yet, open or closed, matched by fingerprint. Findings already alerted on
+// through the suppression file are skipped too. It returns how many issues
+// were created.
+func fileIssues(ctx context.Context, config *Config, findings []Finding, stats *RequestStats) (int, error) {
+	target := config.GitHubIssues
+	client := newHTTPClient(config)
+	policy := newRetryPolicy(config)
+
+	existing, err := issueFingerprints(ctx, client, config, target.Repo, stats)
+	if err != nil {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/issues", config.apiURL(), target.Repo)
+	created := 0
+	for _, f := range findings {
+		if f.AlreadyKnown || f.Fingerprint == "" || existing[f.Fingerprint] {
+			continue
+		}
+		if created > 0 {
+			stats.AddWaitTime(issueCreateDelay)
+			if err := sleepContext(ctx, issueCreateDelay); err != nil {
+				return created, err
+			}
+		}
+
+		payload, err := json.Marshal(map[string]interface{}{
+			"title":  issueTitle(f),
+			"body":   issueBody(f),
+			"labels": append([]string{}, target.Labels...),
+		})
+		if err != nil {
+			return created, fmt.Errorf("error encoding issue: %v", err)
+		}
+		resp, err := doWithRetry(ctx, client, func() (*http.Request, error) {
+			req, err := newGitHubRequestWithBody(ctx, config, http.MethodPost, url, bytes.NewReader(payload))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			return req, nil
+		}, policy, stats)
+		if err != nil {
+			return created, fmt.Errorf("error creating issue for %s/%s: %v", f.Repository, f.FilePath, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			stats.IncrementFailed()
+			return created, fmt.Errorf("error creating issue for %s/%s: unexpected status code: %d", f.Repository, f.FilePath, resp.StatusCode)
+		}
+		stats.IncrementSuccess()
+		existing[f.Fingerprint] = true
+		created++
+	}
+	return created, nil
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// issuesServer mocks the issues API of o/tracker: listing returns
+// existing, and created issues and the listed states are recorded.
+type issuesServer struct {
+	existing []map[string]interface{}
+
+	mu      sync.Mutex
+	states  []string
+	created []map[string]interface{}
+}
+
+func (s *issuesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/repos/o/tracker/issues" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		s.states = append(s.states, r.URL.Query().Get("state"))
+		json.NewEncoder(w).Encode(s.existing)
+	case http.MethodPost:
+		var issue map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&issue)
+		s.created = append(s.created, issue)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{}`)
+	}
+}
+
+func TestFileIssuesSkipsExistingFingerprints(t *testing.T) {
+	mock := &issuesServer{existing: []map[string]interface{}{
+		{"body": fmt.Sprintf(issueFingerprintMarker, "aaa"), "state": "open"},
+		{"body": fmt.Sprintf(issueFingerprintMarker, "bbb"), "state": "closed"},
+		{"body": fmt.Sprintf(issueFingerprintMarker, "ccc"), "pull_request": map[string]string{"url": "x"}},
+	}}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "github_issues": {"repo": "o/tracker", "labels": ["security"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings := []Finding{
+		{Repository: "o/r", FilePath: "a.env", Pattern: "password", Severity: "HIGH", Fingerprint: "aaa"},
+		{Repository: "o/r", FilePath: "b.env", Pattern: "password", Severity: "HIGH", Fingerprint: "bbb"},
+		{Repository: "o/r", FilePath: "c.env", Pattern: "password", Severity: "HIGH", Fingerprint: "ccc"},
+		{Repository: "o/s", FilePath: "c.env", Pattern: "password", Severity: "HIGH", Fingerprint: "ccc"},
+		{Repository: "o/r", FilePath: "d.env", Pattern: "password", Severity: "HIGH", Fingerprint: "ddd", AlreadyKnown: true},
+	}
+	created, err := fileIssues(context.Background(), config, findings, &RequestStats{})
+	if err != nil {
+		t.Fatalf("fileIssues: %v", err)
+	}
+	if created != 1 || len(mock.created) != 1 {
+		t.Fatalf("created %d issue(s), %d posted; want 1", created, len(mock.created))
+	}
+	if body, _ := mock.created[0]["body"].(string); !strings.Contains(body, fmt.Sprintf(issueFingerprintMarker, "ccc")) {
+		t.Errorf("created issue body %q, want the ccc fingerprint", body)
+	}
+	if strings.Join(mock.states, ",") != "all" {
+		t.Errorf("listed issues with state %v, want all", mock.states)
+	}
+}
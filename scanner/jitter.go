@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// deterministicJitterSeed seeds jitter in deterministic mode so runs wait
+// the same amounts in the same order.
+const deterministicJitterSeed = 1
+
+// jitter produces random delays that spread scans started at the same time
+// (e.g. on the same cron minute) so they do not hit GitHub together.
+type jitter struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newJitter(deterministic bool) *jitter {
+	seed := time.Now().UnixNano()
+	if deterministic {
+		seed = deterministicJitterSeed
+	}
+	return &jitter{rng: rand.New(rand.NewSource(seed))}
+}
+
+// upTo returns a delay in [0, max). It returns 0 when max is not positive
+// or j is nil.
+func (j *jitter) upTo(max time.Duration) time.Duration {
+	if j == nil || max <= 0 {
+		return 0
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return time.Duration(j.rng.Int63n(int64(max)))
+}
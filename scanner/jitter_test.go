@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterWithinBounds(t *testing.T) {
+	j := newJitter(false)
+	const max = 50 * time.Millisecond
+	for i := 0; i < 1000; i++ {
+		if d := j.upTo(max); d < 0 || d >= max {
+			t.Fatalf("upTo(%v) = %v, out of bounds", max, d)
+		}
+	}
+	if d := j.upTo(0); d != 0 {
+		t.Errorf("upTo(0) = %v, want 0", d)
+	}
+	var disabled *jitter
+	if d := disabled.upTo(max); d != 0 {
+		t.Errorf("nil jitter waited %v", d)
+	}
+}
+
+func TestDeterministicJitterRepeats(t *testing.T) {
+	a, b := newJitter(true), newJitter(true)
+	for i := 0; i < 20; i++ {
+		if da, db := a.upTo(time.Second), b.upTo(time.Second); da != db {
+			t.Fatalf("delay %d differs between deterministic runs: %v and %v", i, da, db)
+		}
+	}
+}
+
+func TestRequestJitterPolicyBound(t *testing.T) {
+	config, err := parseConfig([]byte(`{"request_jitter": 0.25}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := newRetryPolicy(config)
+	if policy.jitter == nil || policy.maxJitter != 250*time.Millisecond {
+		t.Errorf("policy jitter bound %v, want 250ms", policy.maxJitter)
+	}
+}
@@ -0,0 +1,185 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	jsonlOrderStream = "stream"
+	jsonlOrderSorted = "sorted"
+)
+
+// jsonlStream writes findings to --stream-jsonl as the scan finds them,
+// one JSON object per line. The order is a tradeoff chosen with
+// --jsonl-order: "stream" writes each line at once, so the file can be
+// tailed, but concurrent scanning makes the line order vary between runs;
+// "sorted" buffers every finding, in a spool that spills to disk past
+// spill_threshold, and writes them sorted when the stream is closed, so
+// identical scans produce identical files but nothing appears until the
+// scan ends. Each finding gets its group, severity, tags, fingerprint and
+// scan ID before it is written; steps that need the whole scan, such as
+// the confirmation threshold, filtering and suppressions, are not applied.
+// With --compress gzip the stream is gzipped and ".gz" is appended to its
+// name.
+type jsonlStream struct {
+	path   string
+	sorted bool
+	gzip   bool
+
+	config       *Config
+	placeholders *placeholderAllowlist
+	scanID       string
+
+	mu      sync.Mutex
+	file    *os.File
+	gz      *gzip.Writer
+	out     io.Writer
+	pending *findingSpool
+	err     error
+}
+
+// newJSONLStream creates the stream file. In sorted mode the file is
+// created only at close, with the sorted lines, and findings are held
+// until then in a spool with the given spill threshold.
+func newJSONLStream(path, order, compress string, spillThreshold int) (*jsonlStream, error) {
+	s := &jsonlStream{path: path}
+	if compress == "gzip" {
+		s.gzip = true
+		s.path += ".gz"
+	}
+	switch order {
+	case "", jsonlOrderStream:
+		file, err := os.Create(s.path)
+		if err != nil {
+			return nil, fmt.Errorf("error creating %s: %v", s.path, err)
+		}
+		s.file, s.out = file, file
+		if s.gzip {
+			s.gz = gzip.NewWriter(file)
+			s.out = s.gz
+		}
+	case jsonlOrderSorted:
+		s.sorted = true
+		s.pending = newFindingSpool(spillThreshold, nil)
+	default:
+		return nil, fmt.Errorf("unsupported JSONL order: %s (valid: %s, %s)", order, jsonlOrderStream, jsonlOrderSorted)
+	}
+	return s, nil
+}
+
+// classifyWith makes the stream classify findings with config and tag
+// them with scanID before writing them. Without it findings are written
+// as given.
+func (s *jsonlStream) classifyWith(config *Config, scanID string) {
+	s.config = config
+	s.placeholders = newPlaceholderAllowlist(config.Placeholders)
+	s.scanID = scanID
+}
+
+// write adds findings to the stream. The first write error is kept and
+// returned by close; later findings are dropped. The findings passed in
+// are not modified.
+func (s *jsonlStream) write(findings []Finding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range findings {
+		if s.err != nil {
+			return
+		}
+		if s.config != nil {
+			f.Group = s.config.groupOf[f.Pattern]
+			classifyFinding(s.config, &f, s.placeholders)
+			f.Fingerprint = fingerprint(f)
+			f.ScanID = s.scanID
+		}
+		if s.sorted {
+			s.pending.add([]Finding{f})
+			continue
+		}
+		data, err := json.Marshal(f)
+		if err != nil {
+			s.err = fmt.Errorf("error marshaling finding: %v", err)
+			return
+		}
+		data = append(data, '\n')
+		if _, err := s.out.Write(data); err != nil {
+			s.err = fmt.Errorf("error writing %s: %v", s.path, err)
+		}
+	}
+}
+
+// close finishes the stream, writing the sorted lines in sorted mode.
+func (s *jsonlStream) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.sorted {
+		if s.gz != nil {
+			if err := s.gz.Close(); err != nil && s.err == nil {
+				s.err = fmt.Errorf("error compressing %s: %v", s.path, err)
+			}
+		}
+		if err := s.file.Close(); err != nil && s.err == nil {
+			s.err = fmt.Errorf("error closing %s: %v", s.path, err)
+		}
+		return s.err
+	}
+	defer s.pending.close()
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.pending.sort(jsonlLess); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, func(w io.Writer) error {
+		if !s.gzip {
+			return writeJSONLines(w, s.pending)
+		}
+		gz := gzip.NewWriter(w)
+		if err := writeJSONLines(gz, s.pending); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("error compressing %s: %v", s.path, err)
+		}
+		return nil
+	})
+}
+
+// writeJSONLines writes each finding of src as one line of JSON.
+func writeJSONLines(w io.Writer, src findingSource) error {
+	return src.each(func(f Finding) error {
+		data, err := json.Marshal(f)
+		if err != nil {
+			return fmt.Errorf("error marshaling finding: %v", err)
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// jsonlLess orders findings by repository, path, line and pattern, with
+// the encoded finding itself breaking any remaining tie, so the order does
+// not depend on the order the findings were found in.
+func jsonlLess(a, b Finding) bool {
+	if a.Repository != b.Repository {
+		return a.Repository < b.Repository
+	}
+	if a.FilePath != b.FilePath {
+		return a.FilePath < b.FilePath
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	if a.Pattern != b.Pattern {
+		return a.Pattern < b.Pattern
+	}
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	return bytes.Compare(da, db) < 0
+}
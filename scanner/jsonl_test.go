@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func jsonlTestFindings() []Finding {
+	var findings []Finding
+	for _, repo := range []string{"acme/api", "acme/web", "other/tools"} {
+		for _, path := range []string{".env", "config/prod.yml"} {
+			for _, pattern := range []string{"password", "AKIA[0-9A-Z]{16}"} {
+				findings = append(findings, Finding{Repository: repo, FilePath: path, Pattern: pattern, Severity: "HIGH", Line: 3})
+			}
+		}
+	}
+	return findings
+}
+
+// writeJSONLConcurrently feeds findings to a stream from several
+// goroutines in an order picked by seed, as a concurrent scan would.
+func writeJSONLConcurrently(t *testing.T, path, order string, spill int, seed int64) []byte {
+	t.Helper()
+	s, err := newJSONLStream(path, order, "", spill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := jsonlTestFindings()
+	rand.New(rand.NewSource(seed)).Shuffle(len(findings), func(i, j int) {
+		findings[i], findings[j] = findings[j], findings[i]
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < len(findings); i += 3 {
+		wg.Add(1)
+		go func(batch []Finding) {
+			defer wg.Done()
+			s.write(batch)
+		}(findings[i : i+3])
+	}
+	wg.Wait()
+	if err := s.close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSortedJSONLIdenticalAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	first := writeJSONLConcurrently(t, filepath.Join(dir, "run1.jsonl"), jsonlOrderSorted, 0, 1)
+	second := writeJSONLConcurrently(t, filepath.Join(dir, "run2.jsonl"), jsonlOrderSorted, 0, 2)
+	if !bytes.Equal(first, second) {
+		t.Fatalf("sorted JSONL differs between runs:\n%s\n---\n%s", first, second)
+	}
+	spilled := writeJSONLConcurrently(t, filepath.Join(dir, "run3.jsonl"), jsonlOrderSorted, 4, 3)
+	if !bytes.Equal(first, spilled) {
+		t.Fatalf("sorted JSONL differs when spilled:\n%s\n---\n%s", first, spilled)
+	}
+	if n := strings.Count(string(first), "\n"); n != len(jsonlTestFindings()) {
+		t.Errorf("got %d lines, want %d", n, len(jsonlTestFindings()))
+	}
+}
+
+func TestStreamJSONLWritesEveryFinding(t *testing.T) {
+	data := writeJSONLConcurrently(t, filepath.Join(t.TempDir(), "stream.jsonl"), jsonlOrderStream, 0, 1)
+	if n := strings.Count(string(data), "\n"); n != len(jsonlTestFindings()) {
+		t.Errorf("got %d lines, want %d", n, len(jsonlTestFindings()))
+	}
+}
+
+func TestJSONLOrderRejectsUnknown(t *testing.T) {
+	if _, err := newJSONLStream(filepath.Join(t.TempDir(), "x.jsonl"), "random", "", 0); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
+
+func TestJSONLStreamClassifiesAndCompresses(t *testing.T) {
+	for _, order := range []string{jsonlOrderStream, jsonlOrderSorted} {
+		path := filepath.Join(t.TempDir(), "stream.jsonl")
+		s, err := newJSONLStream(path, order, "gzip", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.classifyWith(&Config{SeverityOverrides: map[string]string{"password": "CRITICAL"}}, "scan-1")
+		findings := []Finding{{Repository: "acme/api", FilePath: ".env", Pattern: "password", Line: 3}}
+		s.write(findings)
+		if err := s.close(); err != nil {
+			t.Fatal(err)
+		}
+		if findings[0].Severity != "" {
+			t.Errorf("%s: write modified the caller's finding: %+v", order, findings[0])
+		}
+
+		file, err := os.Open(path + ".gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("%s: stream is not gzipped: %v", order, err)
+		}
+		var got Finding
+		err = json.NewDecoder(gz).Decode(&got)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Severity != "CRITICAL" || got.ScanID != "scan-1" || got.Fingerprint != fingerprint(findings[0]) {
+			t.Errorf("%s: got %+v, want a classified finding", order, got)
+		}
+	}
+}
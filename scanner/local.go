@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// scanLocalPath implements --local-path: it walks root and matches the
+// files that pass the file filters with the same content matcher used for
+// repositories, so a working tree can be checked before anything is
+// pushed. No GitHub requests are made. Findings name the directory as
+// local:<root> and link to the file with a file:// URL.
+func scanLocalPath(ctx context.Context, config *Config, root string, stats *RequestStats) ([]Finding, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %v", root, err)
+	}
+	matcher := newContentMatcher(config)
+	repo := "local:" + abs
+
+	var findings []Finding
+	err = filepath.Walk(abs, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			config.logf("Warning: %v\n", err)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if config.repoHit(repo) {
+			return filepath.SkipAll
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(abs, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !config.matchesFile(rel) {
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			config.logf("Warning: could not read %s: %v\n", rel, err)
+			return nil
+		}
+		if isBinaryContent(content) {
+			stats.IncrementSkippedBinary()
+			return nil
+		}
+		fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+		for _, f := range matcher.scan(repo, rel, fileURL, content) {
+			if !config.claimRepoHit(repo) || !config.claimFinding() {
+				break
+			}
+			config.logf("Found %s: %s (line %d)\n", severityLabel(f.Severity), f.FilePath, f.Line)
+			findings = append(findings, f)
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		return findings, fmt.Errorf("error walking %s: %v", root, err)
+	}
+	recordFindingsByPattern(stats, config.SearchPatterns, findings)
+	return findings, nil
+}
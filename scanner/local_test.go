@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalPathFindsPlantedSecret(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config/prod.env": "# production\nAPI_HOST=example.com\npassword=hunter2\n",
+		"README.md":       "password=not-a-config-file\n",
+		".git/config.env": "password=in-git-metadata\n",
+		"config/dev.env":  "API_HOST=localhost\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config, err := parseConfig([]byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("local scan requested %s", r.URL)
+	}))
+	defer api.Close()
+	config.apiBase = api.URL
+
+	findings, err := scanLocalPath(context.Background(), config, dir, &RequestStats{})
+	if err != nil {
+		t.Fatalf("scanLocalPath: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("got %+v, want only the planted secret", findings)
+	}
+	f := findings[0]
+	abs, _ := filepath.Abs(dir)
+	if f.Repository != "local:"+abs || f.FilePath != "config/prod.env" || f.Line != 3 {
+		t.Errorf("finding %s %s line %d, want local:%s config/prod.env line 3", f.Repository, f.FilePath, f.Line, abs)
+	}
+	if want := "file://" + filepath.ToSlash(filepath.Join(abs, "config", "prod.env")); f.URL != want {
+		t.Errorf("URL = %s, want %s", f.URL, want)
+	}
+	if f.Severity != "HIGH" || f.Snippet != "password=hunter2" {
+		t.Errorf("severity %s snippet %q, want HIGH and the matching line", f.Severity, f.Snippet)
+	}
+}
@@ -0,0 +1,20 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// logWriter returns w, or stdout when w is nil.
+func logWriter(w io.Writer) io.Writer {
+	if w == nil {
+		return os.Stdout
+	}
+	return w
+}
+
+// logf writes scan progress and warnings to the config's Log writer.
+func (c *Config) logf(format string, args ...interface{}) {
+	fmt.Fprintf(logWriter(c.Log), format, args...)
+}
@@ -0,0 +1,1280 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	GitHubToken           string              `json:"github_token"`
+	SearchPatterns        []string            `json:"search_patterns"`
+	FilePatterns          []string            `json:"file_patterns"`
+	FileGlobs             []string            `json:"file_globs"`
+	RateLimit             int                 `json:"rate_limit"`
+	RetryBudget           int                 `json:"retry_budget"`
+	MaxRetries            int                 `json:"max_retries"`
+	RetryBaseDelay        float64             `json:"retry_base_delay"`
+	RetryMaxDelay         float64             `json:"retry_max_delay"`
+	RequestJitter         float64             `json:"request_jitter"`
+	Topics                []string            `json:"topics"`
+	Repositories          []string            `json:"repositories"`
+	Incremental           bool                `json:"incremental"`
+	StateFile             string              `json:"state_file"`
+	Refs                  map[string][]string `json:"refs"`
+	Placeholders          []string            `json:"placeholders"`
+	MaxPages              int                 `json:"max_pages"`
+	ScanGists             []string            `json:"scan_gists"`
+	GitHubTokens          []string            `json:"github_tokens"`
+	PerTokenConcurrency   int                 `json:"per_token_concurrency"`
+	FileExtensions        []string            `json:"file_extensions"`
+	Deterministic         bool                `json:"deterministic"`
+	TokensFile            string              `json:"tokens_file"`
+	PerPatternTimeout     int                 `json:"per_pattern_timeout"`
+	MaxConcurrentScans    int                 `json:"max_concurrent_scans"`
+	ScanQueueMode         string              `json:"scan_queue_mode"`
+	ScanQueueTimeout      int                 `json:"scan_queue_timeout"`
+	ServeToken            string              `json:"serve_token"`
+	ServeScanTimeout      int                 `json:"serve_scan_timeout"`
+	SuppressionFile       string              `json:"suppression_file"`
+	SuppressionDays       int                 `json:"suppression_days"`
+	DNSServer             string              `json:"dns_server"`
+	PreferIPv6            bool                `json:"prefer_ipv6"`
+	ConnectTimeout        float64             `json:"connect_timeout"`
+	TLSHandshakeTimeout   float64             `json:"tls_handshake_timeout"`
+	ResponseHeaderTimeout float64             `json:"response_header_timeout"`
+	ClientTimeout         float64             `json:"client_timeout"`
+	MinPatternMatches     int                 `json:"min_pattern_matches"`
+	MinPatternRepos       int                 `json:"min_pattern_repos"`
+	SeverityOverrides     map[string]string   `json:"severity_overrides"`
+	Detectors             []string            `json:"detectors"`
+	Tags                  map[string][]string `json:"tags"`
+	Hosts                 []hostEntry         `json:"hosts"`
+	ScanOrg               *orgScanConfig      `json:"scan_org"`
+	ContextEscalation     bool                `json:"context_escalation"`
+	CaseInsensitive       bool                `json:"case_insensitive"`
+	NormalizeURLs         bool                `json:"normalize_urls"`
+	PrivateRepos          bool                `json:"private_repos"`
+	CSVColumns            []string            `json:"csv_columns"`
+	FetchQueueFile        string              `json:"fetch_queue_file"`
+	MaxRedirects          int                 `json:"max_redirects"`
+	NotifyWebhookURL      string              `json:"notify_webhook_url"`
+	NotifyBatchSize       int                 `json:"notify_batch_size"`
+	NotifyMinSeverity     string              `json:"notify_min_severity"`
+	DefaultSeverity       string              `json:"default_severity"`
+	StrictSeverity        bool                `json:"strict_severity"`
+	EscalatePublic        bool                `json:"escalate_public"`
+	GitHubIssues          *issuesConfig       `json:"github_issues"`
+	MaxConcurrencyPerHost int                 `json:"max_concurrency_per_host"`
+	RecordQueries         bool                `json:"record_queries"`
+	PageConcurrency       int                 `json:"page_concurrency"`
+	MaxTotalFindings      int                 `json:"max_total_findings"`
+	ValidateTokens        bool                `json:"validate_tokens"`
+	SpillThreshold        int                 `json:"spill_threshold"`
+	CACertFile            string              `json:"ca_cert_file"`
+	InsecureSkipVerify    bool                `json:"insecure_skip_verify"`
+	FirstHitPerRepo       bool                `json:"first_hit_per_repo"`
+	DecodeBase64          bool                `json:"decode_base64"`
+	RateLimitPollInterval float64             `json:"rate_limit_poll_interval"`
+	RetryableStatusCodes  []int               `json:"retryable_status_codes"`
+	Syslog                *syslogConfig       `json:"syslog"`
+	ExpandPatterns        []string            `json:"expand_patterns"`
+	RequestTimeout        float64             `json:"request_timeout"`
+	TimeoutEscalation     float64             `json:"request_timeout_escalation"`
+	MaxRequestTimeout     float64             `json:"max_request_timeout"`
+	PatternGroups         patternGroups       `json:"pattern_groups"`
+
+	// Log receives the progress and warning messages of a scan; nil means
+	// stdout. Programs embedding the scanner can set it to their own
+	// writer, or to ioutil.Discard to silence it.
+	Log io.Writer `json:"-"`
+
+	tokenPool  *TokenPool
+	transport  http.RoundTripper
+	files      *fileMatcher
+	fileTokens []string
+	apiBase    string
+	webBase    string
+	jitter     *jitter
+	// patternBudgets holds the max_requests of search patterns given in
+	// object form.
+	patternBudgets map[string]int
+	csvColumns     []csvColumn
+	fetchQueue     *fetchQueue
+	notifier       *notifier
+	hostLimiters   map[string]*hostLimiter
+	repoHits       *repoHits
+	findingCap     *findingCap
+	rootCAs        *x509.CertPool
+	// groupOf maps each pattern_groups pattern to its group.
+	groupOf map[string]string
+	// host is the hosts entry a multi-host scan's per-host config targets.
+	host string
+	// onFindings and onError, when set, receive findings and non-fatal
+	// errors as the scan produces them; Scan uses them to stream.
+	onFindings func([]Finding)
+	onError    func(error)
+}
+
+const githubAPIURL = "https://api.github.com"
+
+// missingRateLimitDelay paces requests when responses carry no rate limit
+// headers, matching the authenticated code search limit of 30 per minute.
+const missingRateLimitDelay = 2 * time.Second
+
+// downgradeFloor is the severity --downgrade-below assigns.
+const downgradeFloor = "INFO"
+
+type GitHubCodeSearchResult struct {
+	TotalCount int              `json:"total_count"`
+	Items      []codeSearchItem `json:"items"`
+}
+
+type codeSearchItem struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	HTMLURL string `json:"html_url"`
+	Repo    struct {
+		FullName string `json:"full_name"`
+		Private  bool   `json:"private"`
+	} `json:"repository"`
+	TextMatches []textMatch `json:"text_matches"`
+}
+
+type Finding struct {
+	Repository   string `json:"repository"`
+	FilePath     string `json:"file_path"`
+	URL          string `json:"url"`
+	OriginalURL  string `json:"original_url,omitempty"`
+	Pattern      string `json:"pattern"`
+	Severity     string `json:"severity"`
+	Line         int    `json:"line,omitempty"`
+	Snippet      string `json:"snippet,omitempty"`
+	Ref          string `json:"ref,omitempty"`
+	Host         string `json:"host,omitempty"`
+	Confidence   string `json:"confidence,omitempty"`
+	Explanation  string `json:"explanation,omitempty"`
+	ScanID       string `json:"scan_id,omitempty"`
+	Fingerprint  string `json:"fingerprint,omitempty"`
+	AlreadyKnown bool   `json:"already_known,omitempty"`
+	Public       bool   `json:"public"`
+	// Group is the pattern_groups group of the finding's pattern.
+	Group string `json:"group,omitempty"`
+	// Encoding is "base64" when the match was found in a decoded base64
+	// segment rather than the file as written.
+	Encoding string `json:"encoding,omitempty"`
+	// Query is the code search query that produced the finding, recorded
+	// with record_queries.
+	Query string `json:"query,omitempty"`
+	// Tags are the tags config entries and detector tags of the rules
+	// that matched, for routing findings downstream.
+	Tags []string `json:"tags,omitempty"`
+
+	// pendingFetch marks a search result whose content still has to be
+	// fetched through the fetch queue.
+	pendingFetch bool
+}
+
+type RateLimitInfo struct {
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+	Reset     int `json:"reset"`
+}
+
+type RequestStats struct {
+	TotalRequests      int
+	SuccessfulRequests int
+	FailedRequests     int
+	RateLimitHits      int
+	RetriedRequests    int
+	TotalWaitTime      time.Duration
+	PatternFindings    map[string]int
+	PatternRequests    map[string]int
+	SkippedBinary      int
+	NotAllowlisted     int
+	mu                 sync.Mutex
+}
+
+func (rs *RequestStats) IncrementTotal() {
+	rs.mu.Lock()
+	rs.TotalRequests++
+	rs.mu.Unlock()
+}
+
+func (rs *RequestStats) IncrementSuccess() {
+	rs.mu.Lock()
+	rs.SuccessfulRequests++
+	rs.mu.Unlock()
+}
+
+func (rs *RequestStats) IncrementFailed() {
+	rs.mu.Lock()
+	rs.FailedRequests++
+	rs.mu.Unlock()
+}
+
+func (rs *RequestStats) IncrementRateLimit() {
+	rs.mu.Lock()
+	rs.RateLimitHits++
+	rs.mu.Unlock()
+}
+
+func (rs *RequestStats) IncrementRetried() {
+	rs.mu.Lock()
+	rs.RetriedRequests++
+	rs.mu.Unlock()
+}
+
+// IncrementSkippedBinary counts a fetched file that was not scanned because
+// its content is binary.
+func (rs *RequestStats) IncrementSkippedBinary() {
+	rs.mu.Lock()
+	rs.SkippedBinary++
+	rs.mu.Unlock()
+}
+
+// RecordPatternFindings adds n to the number of findings produced by pattern.
+// Recording zero still marks the pattern as having been scanned.
+func (rs *RequestStats) RecordPatternFindings(pattern string, n int) {
+	rs.mu.Lock()
+	if rs.PatternFindings == nil {
+		rs.PatternFindings = make(map[string]int)
+	}
+	rs.PatternFindings[pattern] += n
+	rs.mu.Unlock()
+}
+
+// ZeroFindingPatterns returns the patterns, in the given order, that were
+// scanned without producing a single finding.
+func (rs *RequestStats) ZeroFindingPatterns(patterns []string) []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	var zero []string
+	for _, p := range patterns {
+		if n, ok := rs.PatternFindings[p]; ok && n == 0 {
+			zero = append(zero, p)
+		}
+	}
+	return zero
+}
+
+// UnscannedPatterns returns the patterns, in the given order, that were
+// never scanned, because the scan timed out or their search failed.
+func (rs *RequestStats) UnscannedPatterns(patterns []string) []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	var unscanned []string
+	for _, p := range patterns {
+		if _, ok := rs.PatternFindings[p]; !ok {
+			unscanned = append(unscanned, p)
+		}
+	}
+	return unscanned
+}
+
+// AddWaitTime records time spent backing off or waiting out a rate limit.
+func (rs *RequestStats) AddWaitTime(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	rs.mu.Lock()
+	rs.TotalWaitTime += d
+	rs.mu.Unlock()
+}
+
+// loadConfig reads and parses the config file, decompressing it first when
+// it is gzipped: named *.gz or starting with the gzip magic bytes.
+func loadConfig(configPath string) (*Config, error) {
+	file, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+	if strings.HasSuffix(configPath, ".gz") || bytes.HasPrefix(file, gzipMagic) {
+		if file, err = gunzip(file); err != nil {
+			return nil, fmt.Errorf("error decompressing config file: %v", err)
+		}
+	}
+	return parseConfig(file)
+}
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+func parseConfig(data []byte) (*Config, error) {
+	// search_patterns entries may be objects, so they are decoded apart
+	// from the rest of the config.
+	var raw struct {
+		Config
+		SearchPatterns []patternSpec `json:"search_patterns"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %v", err)
+	}
+	config := raw.Config
+	config.SearchPatterns, config.patternBudgets = splitPatternSpecs(raw.SearchPatterns)
+	if err := config.init(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// init validates a decoded config and sets up its runtime state: tokens,
+// transport, jitter and compiled file filters.
+func (c *Config) init() error {
+	if c.TokensFile != "" {
+		tokens, err := readTokensFile(c.TokensFile)
+		if err != nil {
+			return err
+		}
+		c.fileTokens = tokens
+	}
+	if n := c.duplicateTokens(); n > 0 {
+		c.logf("Warning: %d duplicate token(s) in github_token, github_tokens and tokens_file ignored; a repeated token shares one rate limit\n", n)
+	}
+	c.tokenPool = newTokenPool(c.configTokens(), c.PerTokenConcurrency)
+	if c.CACertFile != "" {
+		pool, err := loadCACertFile(c.CACertFile)
+		if err != nil {
+			return err
+		}
+		c.rootCAs = pool
+	}
+	if c.InsecureSkipVerify {
+		c.logf("Warning: insecure_skip_verify is set, TLS certificates are not verified; use it only against test servers\n")
+	}
+	c.transport = newBaseTransport(c)
+	c.jitter = newJitter(c.Deterministic)
+
+	files, err := newFileMatcher(c)
+	if err != nil {
+		return err
+	}
+	c.files = files
+
+	switch c.ScanQueueMode {
+	case "", "reject", "queue":
+	default:
+		return fmt.Errorf("scan_queue_mode: must be reject or queue, got %q", c.ScanQueueMode)
+	}
+
+	if err := validateRetryPolicy(c); err != nil {
+		return err
+	}
+	if err := validateHosts(c.Hosts); err != nil {
+		return err
+	}
+	c.initHostLimiters()
+	if c.ScanOrg != nil && c.ScanOrg.Name == "" {
+		return fmt.Errorf("scan_org: name is required")
+	}
+	if c.ScanOrg != nil && c.ScanOrg.UseGraphQL {
+		if err := validateGraphQLOrg(c.ScanOrg); err != nil {
+			return err
+		}
+	}
+	if c.GitHubIssues != nil && strings.Count(c.GitHubIssues.Repo, "/") != 1 {
+		return fmt.Errorf("github_issues: repo must be owner/name, got %q", c.GitHubIssues.Repo)
+	}
+	if c.Syslog != nil {
+		if err := c.Syslog.validate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := enabledDetectors(c.Detectors); err != nil {
+		return err
+	}
+	if c.csvColumns, err = parseCSVColumns(c.CSVColumns); err != nil {
+		return err
+	}
+	if c.notifier, err = newNotifier(c); err != nil {
+		return err
+	}
+	c.repoHits = newRepoHits()
+
+	if err := validateSeverityRules(c); err != nil {
+		return err
+	}
+	if err := c.initPatterns(); err != nil {
+		return err
+	}
+	if err := validateTags(c); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// initPatterns completes search_patterns with the expand_patterns variants
+// and the pattern_groups members, drops duplicates and applies
+// strict_severity. Serve mode runs it again on a request's patterns.
+func (c *Config) initPatterns() error {
+	if err := c.expandPatterns(); err != nil {
+		return err
+	}
+	if err := c.initPatternGroups(); err != nil {
+		return err
+	}
+	var collapsed int
+	c.SearchPatterns, collapsed = dedupePatterns(c.SearchPatterns)
+	if collapsed > 0 {
+		c.logf("Collapsed %d duplicate search pattern(s)\n", collapsed)
+	}
+	return checkStrictSeverity(c)
+}
+
+// conservativeDelay is the wait used when rate limit headers are missing:
+// rate_limit, but never less than missingRateLimitDelay.
+func conservativeDelay(config *Config) time.Duration {
+	delay := time.Duration(config.RateLimit) * time.Second
+	if delay < missingRateLimitDelay {
+		delay = missingRateLimitDelay
+	}
+	return delay
+}
+
+func getRateLimitInfo(resp *http.Response) (*RateLimitInfo, error) {
+	limit := resp.Header.Get("X-RateLimit-Limit")
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	reset := resp.Header.Get("X-RateLimit-Reset")
+
+	if limit == "" || remaining == "" || reset == "" {
+		return nil, fmt.Errorf("rate limit headers not found")
+	}
+
+	limitInt, _ := strconv.Atoi(limit)
+	remainingInt, _ := strconv.Atoi(remaining)
+	resetInt, _ := strconv.Atoi(reset)
+
+	return &RateLimitInfo{
+		Limit:     limitInt,
+		Remaining: remainingInt,
+		Reset:     resetInt,
+	}, nil
+}
+
+func newGitHubRequest(ctx context.Context, config *Config, url string) (*http.Request, error) {
+	return newGitHubRequestWithBody(ctx, config, "GET", url, nil)
+}
+
+// newGitHubRequestWithBody is newGitHubRequest for other methods.
+func newGitHubRequestWithBody(ctx context.Context, config *Config, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "GitHubScanner-Demo")
+	return req, nil
+}
+
+// searchGitHub runs code search for pattern, once per file qualifier when
+// the file filters translate to qualifiers, merging results that more than
+// one qualifier returned.
+func searchGitHub(ctx context.Context, config *Config, pattern string, stats *RequestStats, topics *topicCache) ([]Finding, error) {
+	qualifiers := searchQualifiers(config)
+	scope := config.groupScope(pattern)
+	if len(qualifiers) == 0 {
+		return searchGitHubQuery(ctx, config, pattern, scope, stats, topics)
+	}
+	if scope != "" {
+		for i, q := range qualifiers {
+			qualifiers[i] = scope + "+" + q
+		}
+	}
+
+	var allFindings []Finding
+	seen := make(map[string]bool)
+	for _, qualifier := range qualifiers {
+		findings, err := searchGitHubQuery(ctx, config, pattern, qualifier, stats, topics)
+		for _, f := range findings {
+			if !seen[f.URL] {
+				seen[f.URL] = true
+				allFindings = append(allFindings, f)
+			}
+		}
+		if err != nil {
+			return allFindings, err
+		}
+	}
+	return allFindings, nil
+}
+
+// querySearch is the state shared by the page requests of one code search
+// query.
+type querySearch struct {
+	config  *Config
+	pattern string
+	query   string
+	client  *http.Client
+	policy  retryPolicy
+	matcher *contentMatcher
+	stats   *RequestStats
+	topics  *topicCache
+}
+
+func searchGitHubQuery(ctx context.Context, config *Config, pattern, qualifier string, stats *RequestStats, topics *topicCache) ([]Finding, error) {
+	s := &querySearch{
+		config:  config,
+		pattern: pattern,
+		query:   buildSearchQuery(pattern, qualifier),
+		client:  newHTTPClient(config),
+		policy:  newRetryPolicy(config),
+		matcher: newContentMatcher(config),
+		stats:   stats,
+		topics:  topics,
+	}
+
+	var allFindings []Finding
+	for page := 1; ; page++ {
+		if !s.takeBudget() {
+			return allFindings, nil
+		}
+		result, err := s.fetchPage(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil || len(result.Items) == 0 {
+			return allFindings, nil
+		}
+		allFindings = append(allFindings, s.findings(ctx, result.Items)...)
+
+		if len(result.Items) < searchPerPage {
+			return allFindings, nil
+		}
+
+		if config.MaxPages > 0 && page >= config.MaxPages {
+			config.logf("Reached max_pages limit (%d) for %s, results truncated\n", config.MaxPages, pattern)
+			return allFindings, nil
+		}
+
+		// Once the first page gives the total, the remaining pages are
+		// independent and can be fetched together.
+		if page == 1 && config.pageConcurrency() > 1 && result.TotalCount > 0 {
+			rest, err := s.fetchPages(ctx, 2, s.lastPage(result.TotalCount))
+			if err != nil {
+				return nil, err
+			}
+			return append(allFindings, rest...), nil
+		}
+
+		sleepContext(ctx, time.Duration(config.RateLimit)*time.Second)
+	}
+}
+
+// takeBudget reserves a request against the pattern's max_requests,
+// reporting false once the budget is spent.
+func (s *querySearch) takeBudget() bool {
+	limit := s.config.patternBudgets[s.pattern]
+	if s.stats.TakePatternRequest(s.pattern, limit) {
+		return true
+	}
+	s.config.logf("Reached max_requests budget (%d) for %s, results truncated\n", limit, s.pattern)
+	return false
+}
+
+// fetchPage requests one page of results. It returns nil without an error
+// when ctx ends first.
+func (s *querySearch) fetchPage(ctx context.Context, page int) (*GitHubCodeSearchResult, error) {
+	config, stats := s.config, s.stats
+	url := fmt.Sprintf("%s/search/code?q=%s&per_page=%d&page=%d",
+		config.apiURL(), s.query, searchPerPage, page)
+
+	newRequest := func() (*http.Request, error) {
+		req, err := newGitHubRequest(ctx, config, url)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", textMatchMediaType)
+		return req, nil
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+		resp, err := doWithRetry(ctx, s.client, newRequest, s.policy, stats)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		rateLimit, err := getRateLimitInfo(resp)
+		if err == nil {
+			config.logf("API Calls: %d/%d remaining (resets in %d seconds)\n",
+				rateLimit.Remaining, rateLimit.Limit, rateLimit.Reset)
+
+			// If we're running low on remaining calls, increase the delay
+			if rateLimit.Remaining < 10 {
+				waitTime := time.Duration(config.RateLimit*2) * time.Second
+				config.logf("Low on API calls, increasing delay to %v\n", waitTime)
+				stats.AddWaitTime(waitTime)
+				sleepContext(ctx, waitTime)
+			}
+		} else {
+			// Without headers there is no way to tell how close we are
+			// to the limit, so pace conservatively instead of running
+			// at full speed.
+			waitTime := conservativeDelay(config)
+			config.logf("Rate limit headers missing, pacing requests at %v\n", waitTime)
+			stats.AddWaitTime(waitTime)
+			sleepContext(ctx, waitTime)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized {
+			resp.Body.Close()
+			stats.IncrementFailed()
+			return nil, errUnauthorized
+		}
+
+		if resp.StatusCode == http.StatusForbidden {
+			// doWithRetry already counted a secondary rate limit.
+			if !isSecondaryRateLimit(resp) {
+				stats.IncrementRateLimit()
+			}
+			resp.Body.Close()
+			if rateLimit != nil && rateLimit.Remaining == 0 {
+				resetTime := time.Unix(int64(rateLimit.Reset), 0)
+				waitTime := time.Until(resetTime)
+				config.logf("Rate limit exceeded. Waiting %v before retrying...\n", waitTime)
+				stats.IncrementRetried()
+				stats.AddWaitTime(waitTime)
+				sleepContext(ctx, waitTime)
+				continue
+			}
+			return nil, fmt.Errorf("rate limit exceeded or unauthorized")
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			stats.IncrementFailed()
+			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+
+		stats.IncrementSuccess()
+
+		var result GitHubCodeSearchResult
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("error decoding response: %v", err)
+		}
+		resp.Body.Close()
+		return &result, nil
+	}
+}
+
+// findings turns a page's items into findings, applying the file and topic
+// filters.
+func (s *querySearch) findings(ctx context.Context, items []codeSearchItem) []Finding {
+	config := s.config
+	var findings []Finding
+	for _, item := range items {
+		if !config.matchesFile(item.Path) || config.repoHit(item.Repo.FullName) {
+			continue
+		}
+		if placeholderTextMatch(item, s.matcher.placeholders) {
+			continue
+		}
+		if len(config.Topics) > 0 {
+			repoTopics, err := s.topics.get(ctx, s.client, config, item.Repo.FullName, s.stats)
+			if err != nil {
+				config.logf("Warning: %v\n", err)
+				continue
+			}
+			if !matchesTopics(repoTopics, config.Topics) {
+				continue
+			}
+		}
+		finding := Finding{
+			Repository: item.Repo.FullName,
+			FilePath:   item.Path,
+			URL:        item.HTMLURL,
+			Pattern:    s.pattern,
+			Severity:   config.severityFor(s.pattern),
+			Confidence: confidenceLow,
+			Public:     !item.Repo.Private,
+		}
+		if config.RecordQueries {
+			finding.Query = s.query
+		}
+		// Claimed before the content fetch, which is what the fast mode
+		// saves.
+		if !config.claimRepoHit(item.Repo.FullName) || !config.claimFinding() {
+			continue
+		}
+		if config.fetchQueue == nil {
+			if !describeMatch(ctx, s.client, config, item, s.matcher, &finding, s.stats) {
+				continue
+			}
+		} else if !describeFromTextMatches(item, &finding) {
+			// searchPattern queues the fetch once the search is
+			// done.
+			finding.pendingFetch = true
+		}
+		findings = append(findings, finding)
+		config.logf("Found %s: %s in %s\n", severityLabel(finding.Severity), item.Path, item.Repo.FullName)
+	}
+	return findings
+}
+
+func determineSeverity(pattern string) string {
+	severity, _ := severityRule(pattern)
+	return severity
+}
+
+// severityRule applies the built-in severity rules to pattern and returns
+// the severity along with the high-severity keyword that fired, if any.
+func severityRule(pattern string) (string, string) {
+	highSeverityPatterns := []string{
+		"password",
+		"secret",
+		"key",
+		"token",
+		"credential",
+	}
+
+	for _, p := range highSeverityPatterns {
+		if matched, _ := regexp.MatchString(p, pattern); matched {
+			return "HIGH", p
+		}
+	}
+	return "MEDIUM", ""
+}
+
+// Main runs the command-line scanner. It parses os.Args, exits the process
+// on fatal errors and is what the binary's main calls; programs embedding
+// the scanner use Scan instead.
+func Main() {
+	start := time.Now()
+	if isSubcommand("reclassify") {
+		runReclassify(os.Args[2:])
+		return
+	}
+	if isSubcommand("diff") {
+		runDiff(os.Args[2:])
+		return
+	}
+	if isSubcommand("list-detectors") {
+		runListDetectors(os.Args[2:])
+		return
+	}
+
+	fmt.Println("GitHub Security Scanner Demo")
+	fmt.Println("===========================")
+	fmt.Println("This demo shows potential security issues found in public repositories.")
+	fmt.Println("Note: This is a simplified demo version for learning purposes.")
+	fmt.Println()
+
+	configPath := flag.String("config", "config.json", "Path to configuration file")
+	outputFormat := flag.String("output", "json", "Output format (json, csv, template, export, or table to print to stdout)")
+	templateText := flag.String("template", "", "Go text/template rendered per finding when --output=template")
+	exportMappingName := flag.String("export-mapping", "", "Built-in mapping name (defectdojo) or mapping file for --output=export")
+	errorFormat := flag.String("error-format", "text", "Format for fatal errors (text or json, written to stderr)")
+	sortBy := flag.String("sort-by", defaultSortBy, "Comma-separated sort keys (severity, repository, path) or none")
+	maxPages := flag.Int("max-pages", 0, "Maximum result pages fetched per query (overrides max_pages, 0 uses config)")
+	selfTest := flag.Bool("self-test", false, "Run against built-in fixture data without contacting GitHub")
+	compress := flag.String("compress", "", "Compress the findings file and --stream-jsonl (gzip)")
+	deterministic := flag.Bool("deterministic", false, "Scan in sorted order without concurrency and produce byte-identical output across runs")
+	scanIDFlag := flag.String("scan-id", "", "ID recorded in every output of this run (default: a random UUID, none with --deterministic)")
+	startupJitter := flag.Duration("startup-jitter", 0, "Wait a random time up to this long before scanning")
+	timeout := flag.Duration("timeout", 0, "Maximum time for the scan itself, before output and post-scan steps (0 for no limit)")
+	localPath := flag.String("local-path", "", "Scan files in this local directory instead of GitHub, making no API requests")
+	serveAddr := flag.String("serve", "", "Serve scans over HTTP on this address (e.g. :8080) instead of running once")
+	repoAllowlistFile := flag.String("repo-allowlist-file", "", "File of owner/name or glob lines; findings from other repositories are dropped")
+	downgradeBelowSeverity := flag.String("downgrade-below", "", "Downgrade findings below this severity to INFO instead of dropping them")
+	explain := flag.Bool("explain", false, "Attach an explanation of the matching rule and severity to each finding")
+	filterTag := flag.String("filter-tag", "", "Keep only findings carrying one of these comma-separated tags")
+	minConfidence := flag.String("min-confidence", "", "Drop findings less confident than this (low, medium or high)")
+	countOnly := flag.Bool("count-only", false, "Print finding counts by severity without writing any output file; exit 2 if anything was found")
+	writeCompleteMarker := flag.Bool("write-complete-marker", false, "Write a .done file next to each output file once it and the scan metadata are complete")
+	splitBySeverity := flag.Bool("split-by-severity", false, "Write a separate findings file per severity (e.g. findings-high.json)")
+	splitByGroup := flag.Bool("split-by-group", false, "Write a separate findings file per pattern group (e.g. findings-cloud.json)")
+	includeEmptyFindings := flag.Bool("include-empty-findings", true, "Write the output file even when nothing is found (an empty JSON array, a CSV header, and so on); set to false to write no file")
+	postHook := flag.String("post-hook", "", "Shell command run after the scan with findings JSON on stdin")
+	outputDir := flag.String("output-dir", "", "Write all output files and a manifest into a new timestamped subfolder of this directory")
+	maxConcurrencyPerHost := flag.Int("max-concurrency-per-host", 0, "Requests in flight per host when scanning multiple hosts, for hosts without their own max_concurrency")
+	diffSeverity := flag.Bool("diff-severity", false, "Report findings in --input whose severity the current rules would change, without scanning")
+	diffInput := flag.String("input", "findings.json", "Findings JSON file from a previous run, for --diff-severity")
+	firstHitPerRepo := flag.Bool("first-hit-per-repo", false, "Stop at the first finding in each repository, for a quick list of affected repositories")
+	streamJSONL := flag.String("stream-jsonl", "", "Also write findings to this JSONL file as they are found, classified but before filtering and suppressions")
+	jsonlOrder := flag.String("jsonl-order", jsonlOrderStream, "Line order of --stream-jsonl: stream (written immediately, order varies under concurrency) or sorted (buffered and written sorted at the end, identical across runs)")
+	syslogAddr := flag.String("syslog", "", "Send each finding, without its snippet, to syslog: local, udp://host:port or tcp://host:port")
+	recordQueries := flag.Bool("record-queries", false, "Record in each finding the search query that produced it")
+	maxRuntime := flag.Duration("max-runtime", 0, "Hard cap on total run time; when reached the scan stops, partial results are saved and the exit status is 3")
+	outputS3 := flag.String("output-s3", "", "Upload the output files to s3://bucket/prefix after the scan, with credentials from the AWS_* environment variables")
+	s3Endpoint := flag.String("s3-endpoint", "", "Endpoint URL of an S3-compatible server such as MinIO for --output-s3 (default AWS, or AWS_ENDPOINT_URL_S3)")
+	postHookTimeout := flag.Duration("post-hook-timeout", defaultHookTimeout, "Maximum time the post-scan hook may run")
+	flag.Parse()
+
+	if *errorFormat != "text" && *errorFormat != "json" {
+		exitWithError("text", errCodeUsage, "Invalid --error-format", fmt.Errorf("unsupported error format: %s", *errorFormat))
+	}
+
+	if *splitByGroup && *splitBySeverity {
+		exitWithError(*errorFormat, errCodeUsage, "Invalid flags", fmt.Errorf("--split-by-group and --split-by-severity cannot be combined"))
+	}
+	var s3 *s3Target
+	if *outputS3 != "" {
+		var err error
+		if s3, err = newS3Target(*outputS3, *s3Endpoint); err != nil {
+			exitWithError(*errorFormat, errCodeUsage, "Invalid --output-s3", err)
+		}
+	}
+
+	sortKeys, err := parseSortKeys(*sortBy)
+	if err != nil {
+		exitWithError(*errorFormat, errCodeUsage, "Invalid --sort-by", err)
+	}
+	if *downgradeBelowSeverity != "" {
+		if *downgradeBelowSeverity, err = parseSeverity(*downgradeBelowSeverity); err != nil {
+			exitWithError(*errorFormat, errCodeUsage, "Invalid --downgrade-below", err)
+		}
+	}
+	if *minConfidence != "" {
+		if *minConfidence, err = parseConfidence(*minConfidence); err != nil {
+			exitWithError(*errorFormat, errCodeUsage, "Invalid --min-confidence", err)
+		}
+	}
+
+	var config *Config
+	if *selfTest {
+		fmt.Println("Running self-test against built-in fixture data (no network access)")
+		config, err = loadSelfTestConfig()
+		sortKeys, _ = parseSortKeys(defaultSortBy)
+	} else {
+		config, err = loadConfig(*configPath)
+	}
+	if err != nil {
+		exitWithError(*errorFormat, errCodeConfig, "Error loading config", err)
+	}
+	if *diffSeverity {
+		runDiffSeverity(config, *diffInput, *errorFormat)
+		return
+	}
+	if *maxPages > 0 {
+		config.MaxPages = *maxPages
+	}
+	if *firstHitPerRepo {
+		config.FirstHitPerRepo = true
+	}
+	if *syslogAddr != "" {
+		if config.Syslog == nil {
+			config.Syslog = &syslogConfig{}
+		}
+		config.Syslog.Address = *syslogAddr
+		if err := config.Syslog.validate(); err != nil {
+			exitWithError(*errorFormat, errCodeUsage, "Invalid --syslog", err)
+		}
+	}
+	if *recordQueries {
+		config.RecordQueries = true
+	}
+	if *maxConcurrencyPerHost > 0 {
+		config.MaxConcurrencyPerHost = *maxConcurrencyPerHost
+		config.initHostLimiters()
+	}
+	if *deterministic || config.Deterministic {
+		applyDeterministic(config)
+		if len(sortKeys) == 0 {
+			sortKeys, _ = parseSortKeys(defaultSortBy)
+		}
+	}
+
+	var allowlist *repoAllowlist
+	if *repoAllowlistFile != "" {
+		if allowlist, err = loadRepoAllowlist(*repoAllowlistFile); err != nil {
+			exitWithError(*errorFormat, errCodeConfig, "Error loading repo allowlist", err)
+		}
+	}
+
+	scanID := *scanIDFlag
+	if scanID == "" && !config.Deterministic {
+		scanID = newScanID()
+	}
+
+	opts := outputOptions{format: *outputFormat, compress: *compress, csvColumns: config.csvColumns}
+	if opts.compress != "" && opts.compress != "none" && opts.compress != "gzip" {
+		exitWithError(*errorFormat, errCodeUsage, "Invalid --compress", fmt.Errorf("unsupported compression: %s", opts.compress))
+	}
+	if opts.format == "template" {
+		opts.template, err = parseOutputTemplate(*templateText)
+		if err != nil {
+			exitWithError(*errorFormat, errCodeUsage, "Error parsing template", err)
+		}
+	}
+	if opts.format == "export" {
+		opts.mapping, err = loadExportMapping(*exportMappingName)
+		if err != nil {
+			exitWithError(*errorFormat, errCodeUsage, "Error loading export mapping", err)
+		}
+	}
+
+	if *serveAddr != "" {
+		if err := serve(*serveAddr, config); err != nil {
+			exitWithError(*errorFormat, errCodeServe, "Server failed", err)
+		}
+		return
+	}
+
+	// runCtx bounds the whole run, post-scan steps included, by
+	// --max-runtime; ctx also bounds the scan itself by --timeout.
+	runCtx := context.Background()
+	if *maxRuntime > 0 {
+		var cancelRuntime context.CancelFunc
+		runCtx, cancelRuntime = context.WithDeadline(runCtx, start.Add(*maxRuntime))
+		defer cancelRuntime()
+	}
+	ctx := runCtx
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(runCtx, *timeout)
+		defer cancel()
+	}
+
+	if wait := config.jitter.upTo(*startupJitter); wait > 0 {
+		fmt.Printf("Waiting %v of startup jitter\n", wait.Round(time.Millisecond))
+		sleepContext(ctx, wait)
+	}
+
+	if *localPath == "" {
+		printCostEstimate(estimateAPICost(config))
+	}
+
+	stats := &RequestStats{}
+	if config.ValidateTokens && !*selfTest && *localPath == "" {
+		if err := validateTokens(ctx, config, stats); errors.Is(err, errUnauthorized) {
+			exitWithError(*errorFormat, errCodeAuth, "Authentication failed", fmt.Errorf("no valid token in the pool"))
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+	if config.wantsPrivateRepos() && !*selfTest && *localPath == "" {
+		if err := checkTokenScopes(ctx, config, stats); errors.Is(err, errUnauthorized) {
+			exitWithError(*errorFormat, errCodeAuth, "Authentication failed", err)
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+
+	tracer := newTracerFromEnv()
+	ctx = withTracer(ctx, tracer)
+	ctx, scanSpan := startSpan(ctx, "scan")
+	scanSpan.setAttribute("scan.id", scanID)
+	if scanID != "" {
+		fmt.Printf("Scan ID: %s\n", scanID)
+	}
+
+	var stream *jsonlStream
+	if *streamJSONL != "" {
+		if stream, err = newJSONLStream(*streamJSONL, *jsonlOrder, opts.compress, config.SpillThreshold); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error opening JSONL stream", err)
+		}
+		stream.classifyWith(config, scanID)
+		config.onFindings = stream.write
+	}
+
+	var spool *findingSpool
+	if *localPath != "" {
+		fmt.Printf("Scanning local directory %s\n", *localPath)
+		localCtx, stop := config.limitFindings(ctx)
+		var findings []Finding
+		findings, err = scanLocalPath(localCtx, config, *localPath, stats)
+		stop()
+		if stream != nil {
+			stream.write(findings)
+		}
+		spool = newFindingSpool(config.SpillThreshold, config.Log)
+		spool.add(findings)
+	} else {
+		spool, err = spoolScan(ctx, config, stats)
+	}
+	defer spool.close()
+	if stream != nil {
+		if err := stream.close(); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error writing JSONL stream", err)
+		}
+	}
+	scanSpan.setAttribute("findings", spool.len())
+	scanSpan.recordError(err)
+	scanSpan.finish()
+	tracer.shutdown()
+	if errors.Is(err, errUnauthorized) {
+		exitWithError(*errorFormat, errCodeAuth, "Authentication failed", err)
+	}
+	truncated := *maxRuntime > 0 && !time.Now().Before(start.Add(*maxRuntime))
+	if truncated {
+		fmt.Printf("\nMaximum runtime of %v reached, saving partial results\n", *maxRuntime)
+	}
+	if config.findingCapReached() {
+		truncated = true
+		fmt.Printf("\nWarning: max_total_findings cap of %d reached, scan stopped; saving the findings collected so far\n", config.MaxTotalFindings)
+	}
+	if err != nil && *localPath != "" {
+		exitWithError(*errorFormat, errCodeConfig, "Error scanning local path", err)
+	}
+
+	// Post-processing runs over the spool a batch at a time, so findings
+	// spilled to disk are never all back in memory. The confirmation
+	// threshold counts every finding between the two passes.
+	err = spool.rewrite(func(findings []Finding) []Finding {
+		if config.NormalizeURLs {
+			normalizeFindingURLs(findings)
+		}
+		if allowlist != nil {
+			var dropped int
+			findings, dropped = filterAllowlisted(findings, allowlist)
+			stats.NotAllowlisted += dropped
+		}
+		tagScanID(findings, scanID)
+		tagPatternGroups(config, findings)
+		classifySeverities(config, findings)
+		return findings
+	})
+	if err != nil {
+		exitWithError(*errorFormat, errCodeOutput, "Error reading spilled findings", err)
+	}
+	unconfirmed, err := unconfirmedPatterns(config, spool)
+	if err != nil {
+		exitWithError(*errorFormat, errCodeOutput, "Error reading spilled findings", err)
+	}
+	tagFilter := parseTagFilter(*filterTag)
+	err = spool.rewrite(func(findings []Finding) []Finding {
+		demotePatterns(findings, unconfirmed)
+		if *explain {
+			explainFindings(config, findings)
+		}
+		if *downgradeBelowSeverity != "" {
+			downgradeBelow(findings, *downgradeBelowSeverity, downgradeFloor)
+		}
+		if *minConfidence != "" {
+			findings = filterByConfidence(findings, *minConfidence)
+		}
+		if *filterTag != "" {
+			findings = filterByTags(findings, tagFilter)
+		}
+		assignFingerprints(findings)
+		return findings
+	})
+	if err != nil {
+		exitWithError(*errorFormat, errCodeOutput, "Error reading spilled findings", err)
+	}
+	suppressions, err := applySpooledSuppressions(config, spool)
+	if err != nil {
+		exitWithError(*errorFormat, errCodeOutput, "Error applying suppressions", err)
+	}
+
+	if len(sortKeys) > 0 {
+		if err := spool.sort(findingLess(sortKeys)); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error sorting findings", err)
+		}
+	}
+	// The findings file is written straight from the spool; the steps
+	// below that need every finding at once read it back into memory.
+	findingCount := spool.len()
+	var inMemory []Finding
+	allFindings := func() []Finding {
+		if inMemory == nil {
+			var err error
+			if inMemory, err = spool.findings(); err != nil {
+				exitWithError(*errorFormat, errCodeOutput, "Error reading spilled findings", err)
+			}
+		}
+		return inMemory
+	}
+	if *selfTest {
+		if err := verifySelfTest(allFindings()); err != nil {
+			exitWithError(*errorFormat, errCodeSelfTest, "Self-test failed", err)
+		}
+		fmt.Println("\nSelf-test passed")
+	}
+	if *countOnly {
+		if err := printSeverityCounts(spool); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error reading spilled findings", err)
+		}
+		if err := saveSuppressions(config, suppressions); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error saving suppressions", err)
+		}
+		if truncated {
+			os.Exit(exitCodeTruncated)
+		}
+		if findingCount > 0 {
+			os.Exit(exitCodeFindings)
+		}
+		return
+	}
+	fmt.Printf("\nDemo complete! Found %d potential security issues.\n", findingCount)
+	fmt.Printf("\nAPI Request Statistics:\n")
+	fmt.Printf("Total Requests: %d (estimated %s)\n", stats.TotalRequests, estimateAPICost(config))
+	fmt.Printf("Successful Requests: %d\n", stats.SuccessfulRequests)
+	fmt.Printf("Failed Requests: %d\n", stats.FailedRequests)
+	fmt.Printf("Rate Limit Hits: %d\n", stats.RateLimitHits)
+	fmt.Printf("Retried Requests: %d\n", stats.RetriedRequests)
+	fmt.Printf("Time Spent Waiting: %v\n", stats.TotalWaitTime.Round(time.Millisecond))
+	if stats.SkippedBinary > 0 {
+		fmt.Printf("Binary Files Skipped: %d\n", stats.SkippedBinary)
+	}
+	if stats.NotAllowlisted > 0 {
+		fmt.Printf("Findings Dropped (not allowlisted): %d\n", stats.NotAllowlisted)
+	}
+
+	if zero := stats.ZeroFindingPatterns(config.SearchPatterns); len(zero) > 0 {
+		fmt.Printf("\nPatterns with zero findings (%d):\n", len(zero))
+		for _, p := range zero {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+	if unscanned := stats.UnscannedPatterns(config.SearchPatterns); len(unscanned) > 0 {
+		fmt.Printf("\nPatterns not scanned (%d):\n", len(unscanned))
+		for _, p := range unscanned {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+
+	if *outputDir != "" {
+		if opts.dir, err = createRunDir(*outputDir, start); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error creating output directory", err)
+		}
+	}
+
+	// Everything but the final saved-to line is logged before any output
+	// file appears, so a watcher that reacts to the file sees a finished
+	// log.
+	os.Stdout.Sync()
+	// A run with nothing found still writes a valid, empty document (a split
+	// run writes its unsplit file) unless --include-empty-findings=false.
+	outputFiles := []string{opts.fileName()}
+	if opts.format == "table" {
+		// The table is for a person at the terminal; nothing is saved.
+		outputFiles = nil
+		fmt.Println()
+		if err := writeTable(os.Stdout, allFindings(), tableWidth()); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error printing findings", err)
+		}
+	} else if findingCount == 0 && !*includeEmptyFindings {
+		outputFiles = nil
+	} else if *splitByGroup && findingCount > 0 {
+		var err error
+		if outputFiles, err = saveFindingsByGroup(allFindings(), opts); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error saving findings", err)
+		}
+	} else if *splitBySeverity && findingCount > 0 {
+		var err error
+		if outputFiles, err = saveFindingsBySeverity(allFindings(), opts); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error saving findings", err)
+		}
+	} else if err := saveFindingsFrom(spool, opts); err != nil {
+		// A split run with nothing found still writes the unsplit file, so
+		// consumers always find a valid, empty document.
+		exitWithError(*errorFormat, errCodeOutput, "Error saving findings", err)
+	}
+	if opts.format == "json" {
+		if err := saveScanMetadata(opts.metadataFile(), findingCount, stats, config.Deterministic, scanID); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error saving scan metadata", err)
+		}
+	}
+	if opts.dir != "" {
+		manifest := runManifest{
+			ScanID:       scanID,
+			StartedAt:    start.UTC().Format(time.RFC3339),
+			FinishedAt:   time.Now().UTC().Format(time.RFC3339),
+			Args:         os.Args[1:],
+			FindingCount: findingCount,
+			Truncated:    truncated,
+		}
+		files := outputFiles
+		if opts.format == "json" {
+			files = append(files[:len(files):len(files)], opts.metadataFile())
+		}
+		if err := writeRunManifest(opts.dir, manifest, files); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error writing run manifest", err)
+		}
+	}
+	if *writeCompleteMarker {
+		if err := writeCompleteMarkers(outputFiles); err != nil {
+			exitWithError(*errorFormat, errCodeOutput, "Error writing completion marker", err)
+		}
+	}
+	if err := saveSuppressions(config, suppressions); err != nil {
+		exitWithError(*errorFormat, errCodeOutput, "Error saving suppressions", err)
+	}
+
+	if len(outputFiles) > 0 {
+		fmt.Printf("\nResults have been saved to %s\n", strings.Join(outputFiles, ", "))
+	} else if opts.format != "table" {
+		fmt.Println("\nNo findings to save")
+	}
+	if *timeout > 0 {
+		fmt.Println("\nTo run a full scan, remove the timeout and adjust the configuration.")
+	}
+
+	// A failed upload is reported at exit, after the steps below, so the
+	// local files and everything else the run does are not lost to it.
+	var uploadErr error
+	if s3 != nil && len(outputFiles) > 0 {
+		files := outputFiles
+		if opts.format == "json" {
+			files = append(files[:len(files):len(files)], opts.metadataFile())
+		}
+		uploaded, err := uploadToS3(runCtx, config, s3, files, opts.dir)
+		if len(uploaded) > 0 {
+			fmt.Printf("Uploaded %s\n", strings.Join(uploaded, ", "))
+		}
+		if err != nil {
+			uploadErr = err
+			fmt.Printf("Error uploading to S3: %v; local files are kept\n", err)
+		}
+	}
+
+	if config.GitHubIssues != nil {
+		// --timeout only bounds the scan; --max-runtime bounds this too.
+		created, err := fileIssues(runCtx, config, allFindings(), stats)
+		fmt.Printf("Created %d issue(s) in %s\n", created, config.GitHubIssues.Repo)
+		if err != nil {
+			fmt.Printf("Error filing issues: %v\n", err)
+		}
+	}
+
+	if config.Syslog != nil {
+		sent, err := sendSyslog(runCtx, config.Syslog, allFindings())
+		fmt.Printf("Sent %d finding(s) to syslog\n", sent)
+		if err != nil {
+			fmt.Printf("Error sending to syslog: %v\n", err)
+		}
+	}
+
+	if *postHook != "" {
+		if err := runPostHook(runCtx, *postHook, *postHookTimeout, allFindings(), strings.Join(outputFiles, ","), scanID); err != nil {
+			exitWithError(*errorFormat, errCodeHook, "Post-scan hook failed", err)
+		}
+	}
+	if uploadErr != nil {
+		exitWithError(*errorFormat, errCodeOutput, "Error uploading results", uploadErr)
+	}
+	if truncated {
+		os.Exit(exitCodeTruncated)
+	}
+}
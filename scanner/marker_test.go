@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompleteMarkerFollowsOutput(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "app.env"), []byte("password=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(config, []byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "findings.json")
+	marker := output + completeMarkerSuffix
+
+	// Watch for the marker the way a consumer would: once it exists the
+	// findings file and the scan metadata must already be complete.
+	stop := make(chan struct{})
+	seen := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				seen <- nil
+				return
+			case <-time.After(time.Millisecond):
+			}
+			if _, err := os.Stat(marker); err != nil {
+				continue
+			}
+			var findings []Finding
+			data, err := os.ReadFile(output)
+			if err == nil {
+				err = json.Unmarshal(data, &findings)
+			}
+			if err == nil {
+				_, err = os.Stat(filepath.Join(dir, metadataFileName))
+			}
+			seen <- err
+			return
+		}
+	}()
+
+	stdout, stderr, err := runMain(t, dir, "-config", config, "-local-path", src, "-write-complete-marker")
+	close(stop)
+	if err != nil {
+		t.Fatalf("scan failed: %v\n%s%s", err, stdout, stderr)
+	}
+	if err := <-seen; err != nil {
+		t.Fatalf("marker appeared before the output was complete: %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("no completion marker: %v", err)
+	}
+
+	if err := os.Remove(marker); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := runMain(t, dir, "-config", config, "-local-path", src); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("marker written without -write-complete-marker")
+	}
+}
@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNotifyBatchSize = 20
+	notifyTimeout          = 10 * time.Second
+	// notifyMaxRepos caps how many repositories a batch summary names.
+	notifyMaxRepos = 5
+)
+
+// notifier posts findings to notify_webhook_url as they are found. Findings
+// below notify_min_severity are ignored; the rest are buffered and sent as
+// one summary message per notify_batch_size findings, with whatever is left
+// sent when the scan ends, so a busy scan cannot flood the channel or trip
+// the webhook's rate limit. The payload is a Slack-style {"text": ...}
+// message, which most chat webhooks accept.
+type notifier struct {
+	url         string
+	batchSize   int
+	minSeverity string
+	client      *http.Client
+	log         io.Writer
+
+	mu      sync.Mutex
+	pending []Finding
+}
+
+// newNotifier returns the notifier for the config, or nil when no webhook
+// is configured.
+func newNotifier(config *Config) (*notifier, error) {
+	if config.NotifyWebhookURL == "" {
+		return nil, nil
+	}
+	n := &notifier{
+		url:       config.NotifyWebhookURL,
+		batchSize: config.NotifyBatchSize,
+		client:    &http.Client{Transport: config.transport, Timeout: notifyTimeout},
+		log:       config.Log,
+	}
+	if n.batchSize <= 0 {
+		n.batchSize = defaultNotifyBatchSize
+	}
+	if config.NotifyMinSeverity != "" {
+		severity, err := parseSeverity(config.NotifyMinSeverity)
+		if err != nil {
+			return nil, fmt.Errorf("notify_min_severity: %v", err)
+		}
+		n.minSeverity = severity
+	}
+	return n, nil
+}
+
+// add buffers findings and sends every full batch. Batches are taken off
+// the buffer under the lock and sent after it is released, so a slow
+// webhook does not hold up other goroutines adding findings.
+func (n *notifier) add(findings []Finding) {
+	if n == nil {
+		return
+	}
+	var batches [][]Finding
+	n.mu.Lock()
+	for _, f := range findings {
+		if n.minSeverity == "" || severityRank(f.Severity) >= severityRank(n.minSeverity) {
+			n.pending = append(n.pending, f)
+		}
+	}
+	for len(n.pending) >= n.batchSize {
+		batches = append(batches, append([]Finding(nil), n.pending[:n.batchSize]...))
+		n.pending = n.pending[n.batchSize:]
+	}
+	n.mu.Unlock()
+	for _, batch := range batches {
+		n.send(batch)
+	}
+}
+
+// flush sends whatever is buffered as a final, possibly short, batch.
+func (n *notifier) flush() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	batch := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+	if len(batch) > 0 {
+		n.send(batch)
+	}
+}
+
+// send posts the summary of batch. Failures are logged rather than
+// returned; a missed notification should not fail the scan.
+func (n *notifier) send(batch []Finding) {
+	body, err := json.Marshal(map[string]string{"text": summarizeBatch(batch)})
+	if err != nil {
+		fmt.Fprintf(logWriter(n.log), "Warning: could not encode notification: %v\n", err)
+		return
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(logWriter(n.log), "Warning: notification failed: %v\n", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		fmt.Fprintf(logWriter(n.log), "Warning: notification failed: unexpected status code: %d\n", resp.StatusCode)
+	}
+}
+
+// summarizeBatch describes a batch by severity counts and the repositories
+// involved instead of listing every finding.
+func summarizeBatch(batch []Finding) string {
+	counts := countBySeverity(batch)
+	var parts []string
+	for _, severity := range severityLevels {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+
+	perRepo := make(map[string]int)
+	for _, f := range batch {
+		perRepo[f.Repository]++
+	}
+	repos := make([]string, 0, len(perRepo))
+	for repo := range perRepo {
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if perRepo[repos[i]] != perRepo[repos[j]] {
+			return perRepo[repos[i]] > perRepo[repos[j]]
+		}
+		return repos[i] < repos[j]
+	})
+	var named []string
+	for _, repo := range repos {
+		if len(named) == notifyMaxRepos {
+			break
+		}
+		named = append(named, fmt.Sprintf("%s (%d)", repo, perRepo[repo]))
+	}
+
+	text := fmt.Sprintf("Secret scan: %d potential issue(s)", len(batch))
+	if len(parts) > 0 {
+		text += ": " + strings.Join(parts, ", ")
+	}
+	text += "\nRepositories: " + strings.Join(named, ", ")
+	if extra := len(repos) - len(named); extra > 0 {
+		text += fmt.Sprintf(" and %d more", extra)
+	}
+	return text
+}
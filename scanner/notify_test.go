@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookServer records the text of every notification; while hold is
+// open each request waits on it.
+type webhookServer struct {
+	hold chan struct{}
+
+	mu    sync.Mutex
+	texts []string
+}
+
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var msg struct {
+		Text string `json:"text"`
+	}
+	json.NewDecoder(r.Body).Decode(&msg)
+	s.mu.Lock()
+	s.texts = append(s.texts, msg.Text)
+	s.mu.Unlock()
+	if s.hold != nil {
+		<-s.hold
+	}
+}
+
+func newTestNotifier(t *testing.T, url string) *notifier {
+	t.Helper()
+	config, err := parseConfig([]byte(`{"notify_webhook_url": "` + url + `", "notify_batch_size": 2, "notify_min_severity": "high"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.notifier
+}
+
+func TestNotifierBatchesFindings(t *testing.T) {
+	mock := &webhookServer{}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	n := newTestNotifier(t, srv.URL)
+
+	n.add([]Finding{
+		{Repository: "o/a", Severity: "HIGH"},
+		{Repository: "o/a", Severity: "LOW"},
+		{Repository: "o/b", Severity: "CRITICAL"},
+		{Repository: "o/c", Severity: "HIGH"},
+	})
+	if len(mock.texts) != 1 {
+		t.Fatalf("%d notifications before flush, want 1 full batch", len(mock.texts))
+	}
+	n.flush()
+	if len(mock.texts) != 2 {
+		t.Fatalf("%d notifications after flush, want 2", len(mock.texts))
+	}
+	if !strings.HasPrefix(mock.texts[0], "Secret scan: 2 potential issue(s): 1 CRITICAL, 1 HIGH") {
+		t.Errorf("first batch %q", mock.texts[0])
+	}
+	if !strings.HasPrefix(mock.texts[1], "Secret scan: 1 potential issue(s): 1 HIGH") {
+		t.Errorf("final batch %q", mock.texts[1])
+	}
+}
+
+func TestNotifierSendsOutsideLock(t *testing.T) {
+	mock := &webhookServer{hold: make(chan struct{})}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	n := newTestNotifier(t, srv.URL)
+
+	sent := make(chan struct{})
+	go func() {
+		n.add([]Finding{{Repository: "o/a", Severity: "HIGH"}, {Repository: "o/a", Severity: "HIGH"}})
+		close(sent)
+	}()
+	for {
+		mock.mu.Lock()
+		posted := len(mock.texts)
+		mock.mu.Unlock()
+		if posted > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	added := make(chan struct{})
+	go func() {
+		n.add([]Finding{{Repository: "o/b", Severity: "HIGH"}})
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(2 * time.Second):
+		t.Error("add blocked behind a notification in flight")
+	}
+	close(mock.hold)
+	<-sent
+	<-added
+}
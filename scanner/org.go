@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// orgScanConfig enumerates an organization's repositories for direct
+// scanning, which covers every file in every tree instead of only what code
+// search happens to index. Type is passed to the API (all, public, private,
+// forks, sources or member); Visibility further keeps only public, private
+// or internal repositories. Archived repositories are skipped unless
+// include_archived is set. UseGraphQL enumerates through the GraphQL API,
+// which needs one request per 100 repositories whatever the filters.
+type orgScanConfig struct {
+	Name            string `json:"name"`
+	Type            string `json:"type"`
+	Visibility      string `json:"visibility"`
+	IncludeArchived bool   `json:"include_archived"`
+	UseGraphQL      bool   `json:"use_graphql"`
+}
+
+// scansRepositories reports whether the config selects direct repository
+// scanning, from repositories or scan_org.
+func (c *Config) scansRepositories() bool {
+	return len(c.Repositories) > 0 || c.ScanOrg != nil
+}
+
+// listOrgRepos returns the full names of the organization's repositories
+// that pass the scan_org filters.
+func listOrgRepos(ctx context.Context, client *http.Client, config *Config, org *orgScanConfig, stats *RequestStats) ([]string, error) {
+	const perPage = 100
+	repoType := org.Type
+	if repoType == "" {
+		repoType = "all"
+	}
+
+	var repos []string
+	for page := 1; ; page++ {
+		var result []struct {
+			FullName   string `json:"full_name"`
+			Archived   bool   `json:"archived"`
+			Visibility string `json:"visibility"`
+		}
+		url := fmt.Sprintf("%s/orgs/%s/repos?type=%s&per_page=%d&page=%d", config.apiURL(), org.Name, repoType, perPage, page)
+		if err := getJSON(ctx, client, config, url, stats, &result); err != nil {
+			return repos, fmt.Errorf("error listing repositories for %s: %v", org.Name, err)
+		}
+		for _, r := range result {
+			if r.Archived && !org.IncludeArchived {
+				continue
+			}
+			if org.Visibility != "" && r.Visibility != org.Visibility {
+				continue
+			}
+			repos = append(repos, r.FullName)
+		}
+		if len(result) < perPage {
+			return repos, nil
+		}
+	}
+}
+
+// repositoriesToScan returns the configured repositories followed by those
+// enumerated from scan_org, without duplicates.
+func repositoriesToScan(ctx context.Context, client *http.Client, config *Config, stats *RequestStats) ([]string, error) {
+	repos := append([]string(nil), config.Repositories...)
+	if config.ScanOrg == nil {
+		return repos, nil
+	}
+
+	list := listOrgRepos
+	if config.ScanOrg.UseGraphQL {
+		list = listOrgReposGraphQL
+	}
+	orgRepos, err := list(ctx, client, config, config.ScanOrg, stats)
+	if err != nil {
+		return repos, err
+	}
+	sort.Strings(orgRepos)
+	config.logf("Found %d repositories in %s to scan\n", len(orgRepos), config.ScanOrg.Name)
+
+	seen := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		seen[repo] = true
+	}
+	for _, repo := range orgRepos {
+		if !seen[repo] {
+			seen[repo] = true
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
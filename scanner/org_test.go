@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// orgServer lists acme's repositories over two pages, only two of which
+// are public and not archived, and serves one secret file in each tree.
+type orgServer struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	scanned []string
+}
+
+func (s *orgServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/orgs/acme/repos" {
+		if got := r.URL.Query().Get("type"); got != "sources" {
+			s.t.Errorf("listing type %q, want sources", got)
+		}
+		var repos []map[string]interface{}
+		if r.URL.Query().Get("page") == "1" {
+			for i := 0; i < 98; i++ {
+				repos = append(repos, map[string]interface{}{"full_name": fmt.Sprintf("acme/old%d", i), "archived": true, "visibility": "public"})
+			}
+			repos = append(repos,
+				map[string]interface{}{"full_name": "acme/private", "visibility": "private"},
+				map[string]interface{}{"full_name": "acme/app", "visibility": "public"})
+		} else {
+			repos = append(repos, map[string]interface{}{"full_name": "acme/lib", "visibility": "public"})
+		}
+		json.NewEncoder(w).Encode(repos)
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/repos/"), "/", 3)
+	if len(parts) < 3 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	repo := parts[0] + "/" + parts[1]
+	switch {
+	case parts[2] == "commits/HEAD":
+		s.mu.Lock()
+		s.scanned = append(s.scanned, repo)
+		s.mu.Unlock()
+		fmt.Fprint(w, `{"sha": "head"}`)
+	case parts[2] == "git/trees/head":
+		fmt.Fprint(w, `{"tree": [{"path": "secret.env", "type": "blob"}]}`)
+	case strings.HasPrefix(parts[2], "contents/"):
+		fmt.Fprint(w, "password=hunter2\n")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestScanOrgEnumeratesRepositories(t *testing.T) {
+	mock := &orgServer{t: t}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"],
+		"scan_org": {"name": "acme", "type": "sources", "visibility": "public"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := collectRepositories(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("scanRepositories: %v", err)
+	}
+	sort.Strings(mock.scanned)
+	if got := strings.Join(mock.scanned, ","); got != "acme/app,acme/lib" {
+		t.Errorf("scanned %s, want only the public, unarchived repositories", got)
+	}
+	var repos []string
+	for _, f := range findings {
+		repos = append(repos, f.Repository)
+	}
+	sort.Strings(repos)
+	if got := strings.Join(repos, ","); got != "acme/app,acme/lib" {
+		t.Errorf("findings in %s, want one per scanned repository", got)
+	}
+}
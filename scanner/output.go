@@ -0,0 +1,323 @@
+package scanner
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const metadataFileName = "findings.meta.json"
+
+// outputOptions holds the settings that control how findings are written.
+type outputOptions struct {
+	format   string
+	template *template.Template
+	compress string
+	mapping  *exportMapping
+	// csvColumns is the csv_columns layout; nil means the default.
+	csvColumns []csvColumn
+	// suffix is appended to the base file name, e.g. "-high" when
+	// splitting output by severity.
+	suffix string
+	// dir is the directory output is written to; empty means the current
+	// directory.
+	dir string
+}
+
+// outputFileName returns the file findings are written to for a format.
+func outputFileName(format, suffix string) string {
+	base := "findings" + suffix
+	switch format {
+	case "template":
+		return base + ".txt"
+	case "export":
+		return base + ".export.json"
+	default:
+		return base + "." + format
+	}
+}
+
+// fileName returns the findings file name, including any compression suffix.
+func (o outputOptions) fileName() string {
+	name := outputFileName(o.format, o.suffix)
+	if o.compress == "gzip" {
+		name += ".gz"
+	}
+	return filepath.Join(o.dir, name)
+}
+
+// metadataFile returns where the scan metadata is written.
+func (o outputOptions) metadataFile() string {
+	return filepath.Join(o.dir, metadataFileName)
+}
+
+// printSeverityCounts prints the total and per-severity finding counts,
+// the only output of --count-only.
+func printSeverityCounts(src findingSource) error {
+	counts := make(map[string]int)
+	total := 0
+	err := src.each(func(f Finding) error {
+		counts[strings.ToUpper(f.Severity)]++
+		total++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nFindings: %d\n", total)
+	for _, severity := range severityLevels {
+		fmt.Printf("  %-8s %d\n", severity, counts[severity])
+	}
+	return nil
+}
+
+// partitionBySeverity groups findings by upper-cased severity, keeping the
+// order of findings within each group.
+func partitionBySeverity(findings []Finding) map[string][]Finding {
+	groups := make(map[string][]Finding)
+	for _, f := range findings {
+		severity := strings.ToUpper(f.Severity)
+		groups[severity] = append(groups[severity], f)
+	}
+	return groups
+}
+
+// saveFindingsBySeverity writes one output file per severity present, such
+// as findings-high.json, most severe first. It returns the files written.
+func saveFindingsBySeverity(findings []Finding, opts outputOptions) ([]string, error) {
+	groups := partitionBySeverity(findings)
+	severities := make([]string, 0, len(groups))
+	for severity := range groups {
+		severities = append(severities, severity)
+	}
+	sort.Slice(severities, func(i, j int) bool {
+		ri, rj := severityRank(severities[i]), severityRank(severities[j])
+		if ri != rj {
+			return ri > rj
+		}
+		return severities[i] < severities[j]
+	})
+
+	var files []string
+	for _, severity := range severities {
+		o := opts
+		o.suffix = "-" + strings.ToLower(severity)
+		if err := saveFindings(groups[severity], o); err != nil {
+			return files, err
+		}
+		files = append(files, o.fileName())
+	}
+	return files, nil
+}
+
+func saveFindings(findings []Finding, opts outputOptions) error {
+	return saveFindingsFrom(findingSlice(findings), opts)
+}
+
+// saveFindingsFrom is saveFindings for findings read from src, such as a
+// spool that spilled to disk.
+func saveFindingsFrom(src findingSource, opts outputOptions) error {
+	switch opts.format {
+	case "json", "csv", "template", "export":
+	default:
+		return fmt.Errorf("unsupported output format: %s", opts.format)
+	}
+
+	return writeFileAtomic(opts.fileName(), func(file io.Writer) error {
+		if opts.compress != "gzip" {
+			return writeFindingsFrom(file, src, opts)
+		}
+		gz := gzip.NewWriter(file)
+		if err := writeFindingsFrom(gz, src, opts); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("error compressing output: %v", err)
+		}
+		return nil
+	})
+}
+
+// completeMarkerSuffix is appended to an output file's name for the
+// --write-complete-marker sentinel.
+const completeMarkerSuffix = ".done"
+
+// writeCompleteMarkers writes an empty name.done file for each output
+// file. Markers are written only after every output file and the scan
+// metadata have been renamed into place, so a consumer that waits for the
+// marker never reads a partial scan.
+func writeCompleteMarkers(files []string) error {
+	for _, name := range files {
+		if err := writeBytesAtomic(name+completeMarkerSuffix, nil); err != nil {
+			return fmt.Errorf("error writing marker for %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// findingSource yields findings one at a time, in order, stopping at the
+// first error fn returns. Writers read from one so findings spilled to disk
+// are written without being read back into memory.
+type findingSource interface {
+	each(fn func(Finding) error) error
+}
+
+// findingSlice is a findingSource over findings in memory.
+type findingSlice []Finding
+
+func (s findingSlice) each(fn func(Finding) error) error {
+	for _, f := range s {
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFindings encodes findings in the configured format.
+func writeFindings(w io.Writer, findings []Finding, opts outputOptions) error {
+	return writeFindingsFrom(w, findingSlice(findings), opts)
+}
+
+// writeFindingsFrom is writeFindings for findings read from src. JSON,
+// CSV and template output are written a finding at a time; an export is
+// built in memory, since its mapping needs the whole document.
+func writeFindingsFrom(w io.Writer, src findingSource, opts outputOptions) error {
+	switch opts.format {
+	case "json":
+		return writeJSONArray(w, src)
+	case "csv":
+		return writeCSVFrom(w, src, opts.csvColumns)
+	case "template":
+		return writeTemplate(w, opts.template, src)
+	case "export":
+		var findings []Finding
+		if err := src.each(func(f Finding) error {
+			findings = append(findings, f)
+			return nil
+		}); err != nil {
+			return err
+		}
+		return writeExport(w, opts.mapping, findings)
+	default:
+		return fmt.Errorf("unsupported output format: %s", opts.format)
+	}
+}
+
+// writeJSONArray writes the findings as an indented JSON array, one
+// element at a time, byte for byte what json.MarshalIndent would write for
+// the whole slice. An empty run is written as [], never null.
+func writeJSONArray(w io.Writer, src findingSource) error {
+	n := 0
+	err := src.each(func(f Finding) error {
+		data, err := json.MarshalIndent(f, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling findings: %v", err)
+		}
+		sep := ",\n  "
+		if n == 0 {
+			sep = "[\n  "
+		}
+		n++
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	end := "\n]"
+	if n == 0 {
+		end = "[]"
+	}
+	_, err = io.WriteString(w, end)
+	return err
+}
+
+// parseOutputTemplate parses a per-finding text/template so syntax errors
+// surface before any API calls are made.
+func parseOutputTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, fmt.Errorf("output format template requires --template")
+	}
+	tmpl, err := template.New("finding").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing output template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// writeTemplate renders tmpl once per finding, one finding per line.
+func writeTemplate(w io.Writer, tmpl *template.Template, src findingSource) error {
+	var sb strings.Builder
+	return src.each(func(f Finding) error {
+		sb.Reset()
+		if err := tmpl.Execute(&sb, f); err != nil {
+			return fmt.Errorf("error rendering template for %s/%s: %v", f.Repository, f.FilePath, err)
+		}
+		line := sb.String()
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		_, err := io.WriteString(w, line)
+		return err
+	})
+}
+
+// scanMetadata summarises a run alongside the findings themselves, which
+// stay a bare JSON array for existing consumers.
+type scanMetadata struct {
+	ScanID             string  `json:"scan_id,omitempty"`
+	GeneratedAt        string  `json:"generated_at,omitempty"`
+	FindingCount       int     `json:"finding_count"`
+	TotalRequests      int     `json:"total_requests"`
+	SuccessfulRequests int     `json:"successful_requests"`
+	FailedRequests     int     `json:"failed_requests"`
+	RateLimitHits      int     `json:"rate_limit_hits"`
+	RetriedRequests    int     `json:"retried_requests"`
+	TotalWaitSeconds   float64 `json:"total_wait_seconds"`
+	SkippedBinary      int     `json:"skipped_binary_files"`
+	NotAllowlisted     int     `json:"not_allowlisted_findings,omitempty"`
+}
+
+func newScanMetadata(findingCount int, stats *RequestStats) *scanMetadata {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	return &scanMetadata{
+		FindingCount:       findingCount,
+		TotalRequests:      stats.TotalRequests,
+		SuccessfulRequests: stats.SuccessfulRequests,
+		FailedRequests:     stats.FailedRequests,
+		RateLimitHits:      stats.RateLimitHits,
+		RetriedRequests:    stats.RetriedRequests,
+		TotalWaitSeconds:   stats.TotalWaitTime.Seconds(),
+		SkippedBinary:      stats.SkippedBinary,
+		NotAllowlisted:     stats.NotAllowlisted,
+	}
+}
+
+// saveScanMetadata writes the run summary to path. Deterministic runs leave
+// out the timestamp and wait time so the file is reproducible.
+func saveScanMetadata(path string, findingCount int, stats *RequestStats, deterministic bool, scanID string) error {
+	meta := newScanMetadata(findingCount, stats)
+	meta.ScanID = scanID
+	if deterministic {
+		meta.TotalWaitSeconds = 0
+	} else {
+		meta.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling scan metadata: %v", err)
+	}
+	return writeBytesAtomic(path, data)
+}
@@ -0,0 +1,198 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmptyJSONOutputIsArray(t *testing.T) {
+	for _, findings := range [][]Finding{nil, {}} {
+		var buf bytes.Buffer
+		if err := writeFindings(&buf, findings, outputOptions{format: "json"}); err != nil {
+			t.Fatal(err)
+		}
+		var decoded []Finding
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("empty output %q is not valid JSON: %v", buf.String(), err)
+		}
+		if strings.TrimSpace(buf.String()) != "[]" {
+			t.Errorf("empty output is %q, want []", buf.String())
+		}
+	}
+}
+
+func TestEmptyCSVOutputHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeFindings(&buf, nil, outputOptions{format: "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("empty output is not valid CSV: %v", err)
+	}
+	columns, _ := parseCSVColumns(nil)
+	if len(records) != 1 || len(records[0]) != len(columns) {
+		t.Fatalf("got %v, want only the %d-column header", records, len(columns))
+	}
+	if records[0][0] != columns[0].name {
+		t.Errorf("header starts with %q, want %q", records[0][0], columns[0].name)
+	}
+}
+
+func TestEmptyExportOutputIsValid(t *testing.T) {
+	mapping, err := loadExportMapping("defectdojo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeFindings(&buf, nil, outputOptions{format: "export", mapping: mapping}); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string][]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("empty output %q is not valid JSON: %v", buf.String(), err)
+	}
+	if items, ok := decoded["findings"]; !ok || items == nil || len(items) != 0 {
+		t.Errorf("got %q, want an empty findings array", buf.String())
+	}
+}
+
+func TestEmptyTemplateOutputIsEmpty(t *testing.T) {
+	tmpl, err := parseOutputTemplate("{{.Repository}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeFindings(&buf, nil, outputOptions{format: "template", template: tmpl}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got %q, want no lines", buf.String())
+	}
+}
+
+func TestSaveEmptyFindingsWritesFile(t *testing.T) {
+	dir := t.TempDir()
+	opts := outputOptions{format: "json", dir: dir}
+	if err := saveFindings(nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "findings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "[]" {
+		t.Errorf("findings.json is %q, want []", data)
+	}
+}
+
+func TestTemplateOutputRendersEachFinding(t *testing.T) {
+	tmpl, err := parseOutputTemplate("{{.Severity}} {{.Repository}}:{{.FilePath}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{
+		{Repository: "o/a", FilePath: ".env", Severity: "HIGH"},
+		{Repository: "o/b", FilePath: "config/secrets.yml", Severity: "LOW"},
+	}
+	var buf bytes.Buffer
+	if err := writeFindings(&buf, findings, outputOptions{format: "template", template: tmpl}); err != nil {
+		t.Fatal(err)
+	}
+	want := "HIGH o/a:.env\nLOW o/b:config/secrets.yml\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestInvalidTemplateFailsAtParse(t *testing.T) {
+	for _, text := range []string{"", "{{.Repository", "{{end}}"} {
+		if _, err := parseOutputTemplate(text); err == nil {
+			t.Errorf("parseOutputTemplate(%q) succeeded, want an error", text)
+		}
+	}
+}
+
+func TestSaveCompressedFindings(t *testing.T) {
+	findings := []Finding{
+		{Repository: "o/a", FilePath: ".env", Pattern: "password", Severity: "HIGH"},
+		{Repository: "o/b", FilePath: "id_rsa", Pattern: "BEGIN", Severity: "CRITICAL"},
+	}
+	for _, format := range []string{"json", "csv"} {
+		dir := t.TempDir()
+		opts := outputOptions{format: format, compress: "gzip", dir: dir}
+		if err := saveFindings(findings, opts); err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.Open(filepath.Join(dir, "findings."+format+".gz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("%s output is not gzip: %v", format, err)
+		}
+		var got, want bytes.Buffer
+		if _, err := got.ReadFrom(zr); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeFindings(&want, findings, outputOptions{format: format}); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("decompressed %s output %q, want %q", format, got.String(), want.String())
+		}
+	}
+
+}
+
+func TestSplitBySeverityFiles(t *testing.T) {
+	dir := t.TempDir()
+	findings := []Finding{
+		{Repository: "o/a", FilePath: "a.env", Severity: "HIGH"},
+		{Repository: "o/b", FilePath: "b.env", Severity: "low"},
+		{Repository: "o/c", FilePath: "c.env", Severity: "HIGH"},
+		{Repository: "o/d", FilePath: "id_rsa", Severity: "CRITICAL"},
+	}
+	files, err := saveFindingsBySeverity(findings, outputOptions{format: "json", dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	if got := strings.Join(names, ","); got != "findings-critical.json,findings-high.json,findings-low.json" {
+		t.Errorf("wrote %s, want one file per severity, most severe first", got)
+	}
+
+	want := map[string]string{
+		"findings-critical.json": "o/d",
+		"findings-high.json":     "o/a,o/c",
+		"findings-low.json":      "o/b",
+	}
+	for name, repos := range want {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded []Finding
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range decoded {
+			got = append(got, f.Repository)
+		}
+		if strings.Join(got, ",") != repos {
+			t.Errorf("%s holds %v, want %s", name, got, repos)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultPageConcurrency is how many pages of one query are fetched at a
+// time when page_concurrency is not set.
+const defaultPageConcurrency = 3
+
+// pageConcurrency returns how many pages of a query may be in flight.
+// Deterministic scans fetch pages one at a time.
+func (c *Config) pageConcurrency() int {
+	if c.Deterministic {
+		return 1
+	}
+	if c.PageConcurrency > 0 {
+		return c.PageConcurrency
+	}
+	return defaultPageConcurrency
+}
+
+// lastPage returns the last page worth requesting for a query with total
+// results: code search serves at most maxSearchResults, and max_pages caps
+// it further.
+func (s *querySearch) lastPage(total int) int {
+	if total > maxSearchResults {
+		total = maxSearchResults
+	}
+	last := (total + searchPerPage - 1) / searchPerPage
+	if max := s.config.MaxPages; max > 0 && last > max {
+		s.config.logf("Reached max_pages limit (%d) for %s, results truncated\n", max, s.pattern)
+		last = max
+	}
+	return last
+}
+
+// fetchPages fetches pages first through last with up to page_concurrency
+// requests in flight and returns their findings in page order. Page starts
+// are still spaced by rate_limit, as in a sequential scan, so concurrency
+// overlaps the requests and content fetches rather than raising the request
+// rate. The first error stops the pages not yet started and is returned
+// without any findings.
+func (s *querySearch) fetchPages(ctx context.Context, first, last int) ([]Finding, error) {
+	if last < first {
+		return nil, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]Finding, last-first+1)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	pages := make(chan int)
+	for i := 0; i < s.config.pageConcurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range pages {
+				result, err := s.fetchPage(ctx, page)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+					continue
+				}
+				if result != nil {
+					results[page-first] = s.findings(ctx, result.Items)
+				}
+			}
+		}()
+	}
+
+	pace := time.Duration(s.config.RateLimit) * time.Second
+feed:
+	for page := first; page <= last; page++ {
+		if sleepContext(ctx, pace) != nil || !s.takeBudget() {
+			break
+		}
+		select {
+		case pages <- page:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(pages)
+	wg.Wait()
+	if firstErr != nil {
+		// As in a sequential search, a failed query yields no findings
+		// rather than a partial set that looks complete.
+		return nil, firstErr
+	}
+
+	var findings []Finding
+	for _, r := range results {
+		findings = append(findings, r...)
+	}
+	return findings, firstErr
+}
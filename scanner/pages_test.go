@@ -0,0 +1,219 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pagedSearchServer serves total results of code search, searchPerPage at a
+// time, recording how many page requests were in flight at once. failPage,
+// when non-zero, answers 422 for that page.
+type pagedSearchServer struct {
+	total    int
+	failPage int
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *pagedSearchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	w.Header().Set("X-RateLimit-Limit", "30")
+	w.Header().Set("X-RateLimit-Remaining", "29")
+	w.Header().Set("X-RateLimit-Reset", "0")
+	if page == s.failPage {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	var items []map[string]interface{}
+	for i := (page - 1) * searchPerPage; i < page*searchPerPage && i < s.total; i++ {
+		items = append(items, map[string]interface{}{
+			"path":       fmt.Sprintf("file%03d.env", i),
+			"html_url":   "https://github.com/o/r/blob/main/x",
+			"repository": map[string]string{"full_name": "o/r"},
+			"text_matches": []interface{}{map[string]interface{}{
+				"property": "content",
+				"fragment": "password=hunter2",
+				"matches":  []interface{}{map[string]interface{}{"text": "password", "indices": []int{0, 8}}},
+			}},
+		})
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"total_count": s.total, "items": items})
+}
+
+func newPagedSearchConfig(t *testing.T, url string) *Config {
+	t.Helper()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = url
+	return config
+}
+
+func TestSearchFetchesPagesConcurrentlyInOrder(t *testing.T) {
+	mock := &pagedSearchServer{total: 4*searchPerPage + 5}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	config := newPagedSearchConfig(t, srv.URL)
+
+	findings, err := searchGitHubQuery(context.Background(), config, "password", "", &RequestStats{}, newTopicCache())
+	if err != nil {
+		t.Fatalf("searchGitHubQuery: %v", err)
+	}
+	if len(findings) != mock.total {
+		t.Fatalf("got %d findings, want %d", len(findings), mock.total)
+	}
+	for i, f := range findings {
+		if want := fmt.Sprintf("file%03d.env", i); f.FilePath != want {
+			t.Fatalf("finding %d is %s, want %s", i, f.FilePath, want)
+		}
+	}
+	if mock.maxInFlight < 2 {
+		t.Errorf("at most %d page request(s) in flight, want concurrent pages", mock.maxInFlight)
+	}
+}
+
+func TestSearchPageErrorReturnsNoFindings(t *testing.T) {
+	mock := &pagedSearchServer{total: 4 * searchPerPage, failPage: 3}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	config := newPagedSearchConfig(t, srv.URL)
+
+	findings, err := searchGitHubQuery(context.Background(), config, "password", "", &RequestStats{}, newTopicCache())
+	if err == nil {
+		t.Fatal("expected an error for the failed page")
+	}
+	if findings != nil {
+		t.Errorf("got %d findings with the error, want none", len(findings))
+	}
+}
+
+func TestSearchPagesSequentialWhenDeterministic(t *testing.T) {
+	mock := &pagedSearchServer{total: 3 * searchPerPage}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	config := newPagedSearchConfig(t, srv.URL)
+	config.Deterministic = true
+
+	findings, err := searchGitHubQuery(context.Background(), config, "password", "", &RequestStats{}, newTopicCache())
+	if err != nil {
+		t.Fatalf("searchGitHubQuery: %v", err)
+	}
+	if len(findings) != mock.total {
+		t.Fatalf("got %d findings, want %d", len(findings), mock.total)
+	}
+	if mock.maxInFlight != 1 {
+		t.Errorf("%d page requests in flight, want 1", mock.maxInFlight)
+	}
+}
+
+func TestMaxPagesStopsPagination(t *testing.T) {
+	var mu sync.Mutex
+	var pages []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		mu.Lock()
+		pages = append(pages, page)
+		mu.Unlock()
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		var items []map[string]interface{}
+		for i := 0; i < searchPerPage; i++ {
+			items = append(items, map[string]interface{}{
+				"path":       fmt.Sprintf("p%d/file%d.env", page, i),
+				"html_url":   "https://github.com/o/r/blob/main/x",
+				"repository": map[string]string{"full_name": "o/r"},
+				"text_matches": []interface{}{map[string]interface{}{
+					"property": "content",
+					"fragment": "password=hunter2",
+					"matches":  []interface{}{map[string]interface{}{"text": "password", "indices": []int{0, 8}}},
+				}},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": 8 * searchPerPage, "items": items})
+	}))
+	defer srv.Close()
+	config := newPagedSearchConfig(t, srv.URL)
+	config.MaxPages = 2
+
+	findings, err := searchGitHubQuery(context.Background(), config, "password", "", &RequestStats{}, newTopicCache())
+	if err != nil {
+		t.Fatalf("searchGitHubQuery: %v", err)
+	}
+	if len(findings) != 2*searchPerPage {
+		t.Errorf("got %d findings, want the first 2 pages (%d)", len(findings), 2*searchPerPage)
+	}
+	for _, page := range pages {
+		if page > 2 {
+			t.Fatalf("requested pages %v, want none past max_pages", pages)
+		}
+	}
+}
+
+func TestPerPatternTimeoutMovesOn(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Query().Get("q"), "slow") {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+			}
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": 1, "items": []interface{}{map[string]interface{}{
+			"path":       "app.env",
+			"html_url":   "https://github.com/o/r/blob/main/app.env",
+			"repository": map[string]string{"full_name": "o/r"},
+			"text_matches": []interface{}{map[string]interface{}{
+				"property": "content",
+				"fragment": "fast=hunter2",
+				"matches":  []interface{}{map[string]interface{}{"text": "fast", "indices": []int{0, 4}}},
+			}},
+		}}})
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["slow", "fast"], "file_patterns": ["\\.env$"], "per_pattern_timeout": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	start := time.Now()
+	findings, err := runScan(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("scan took %v, want the slow pattern cut off after 1s", elapsed)
+	}
+	if len(findings) != 1 || findings[0].Pattern != "fast" {
+		t.Errorf("got %+v, want the fast pattern's finding", findings)
+	}
+}
@@ -0,0 +1,56 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestZeroFindingPatternsSkipsUnscanned(t *testing.T) {
+	stats := &RequestStats{}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats.RecordPatternFindings("password", 1)
+			stats.RecordPatternFindings("token", 0)
+		}()
+	}
+	wg.Wait()
+
+	patterns := []string{"password", "token", "secret"}
+	if got := stats.ZeroFindingPatterns(patterns); !reflect.DeepEqual(got, []string{"token"}) {
+		t.Errorf("zero-finding patterns %v, want [token]", got)
+	}
+	if got := stats.UnscannedPatterns(patterns); !reflect.DeepEqual(got, []string{"secret"}) {
+		t.Errorf("unscanned patterns %v, want [secret]", got)
+	}
+	if stats.PatternFindings["password"] != 50 {
+		t.Errorf("password has %d findings, want 50", stats.PatternFindings["password"])
+	}
+}
+
+func TestLocalScanMarksEveryPatternScanned(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.env"), []byte("password=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password", "aws_secret"], "file_patterns": ["\\.env$"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := &RequestStats{}
+	if _, err := scanLocalPath(context.Background(), config, dir, stats); err != nil {
+		t.Fatal(err)
+	}
+	if got := stats.ZeroFindingPatterns(config.SearchPatterns); !reflect.DeepEqual(got, []string{"aws_secret"}) {
+		t.Errorf("zero-finding patterns %v, want [aws_secret]", got)
+	}
+	if got := stats.UnscannedPatterns(config.SearchPatterns); len(got) != 0 {
+		t.Errorf("unscanned patterns %v, want none", got)
+	}
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import "fmt"
+
+const (
+	searchPerPage    = 30 // Reduced for demo purposes
+	maxSearchResults = 1000
+)
+
+// costEstimate is a rough range of API requests a scan will issue. Max is
+// -1 when the upper bound depends on data only known during the scan, such
+// as how many files a repository tree contains.
+type costEstimate struct {
+	Min   int
+	Max   int
+	Notes []string
+}
+
+func (e costEstimate) String() string {
+	if e.Max < 0 {
+		return fmt.Sprintf("at least %d", e.Min)
+	}
+	if e.Min == e.Max {
+		return fmt.Sprintf("%d", e.Min)
+	}
+	return fmt.Sprintf("%d-%d", e.Min, e.Max)
+}
+
+// estimateAPICost estimates the number of API requests the configured scan
+// will make, not counting retries. Each host of a multi-host scan runs the
+// whole scan, so the estimate is multiplied by the number of hosts.
+func estimateAPICost(config *Config) costEstimate {
+	var est costEstimate
+	unbounded := false
+
+	if len(config.Repositories) > 0 {
+		for _, repo := range config.Repositories {
+			// Resolve HEAD plus a tree listing or compare per target.
+			est.Min += 2
+			if len(refsForRepo(config.Refs, repo)) > 0 {
+				est.Min++
+			}
+		}
+		unbounded = true
+		est.Notes = append(est.Notes, "plus one content request per matching file in each scanned tree")
+	}
+
+	if config.ScanOrg != nil {
+		est.Min++
+		unbounded = true
+		est.Notes = append(est.Notes, "plus per-repository requests for every repository in "+config.ScanOrg.Name)
+	}
+
+	if len(config.ScanGists) > 0 {
+		est.Min += len(config.ScanGists)
+		unbounded = true
+		est.Notes = append(est.Notes, "plus one request per gist")
+	}
+
+	if !config.scansRepositories() && len(config.ScanGists) == 0 {
+		pages := (maxSearchResults + searchPerPage - 1) / searchPerPage
+		if config.MaxPages > 0 && config.MaxPages < pages {
+			pages = config.MaxPages
+		}
+		// Worst case every result needs its file fetched, which only
+		// happens when the search returned no text match for it.
+		perPattern := pages + pages*searchPerPage
+		est.Notes = append(est.Notes, "the upper bound includes one content request per result without a text match")
+		if len(config.Topics) > 0 {
+			// Worst case every result comes from a repository not yet cached.
+			perPattern += pages * searchPerPage
+			est.Notes = append(est.Notes, "topic lookups are cached per repository, so the upper bound is rarely reached")
+		}
+		queries := len(config.SearchPatterns)
+		if qualifiers := len(searchQualifiers(config)); qualifiers > 0 {
+			queries *= qualifiers
+		}
+		est.Min += queries
+		est.Max += queries * perPattern
+	}
+
+	if hosts := len(config.Hosts); hosts > 1 {
+		est.Min *= hosts
+		est.Max *= hosts
+		est.Notes = append(est.Notes, fmt.Sprintf("across %d hosts", hosts))
+	}
+	if unbounded {
+		est.Max = -1
+	} else if est.Max < est.Min {
+		est.Max = est.Min
+	}
+	return est
+}
+
+func printCostEstimate(est costEstimate) {
+	fmt.Printf("Estimated API requests: %s\n", est)
+	for _, note := range est.Notes {
+		fmt.Printf("  (%s)\n", note)
+	}
+}
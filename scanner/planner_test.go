@@ -0,0 +1,32 @@
+package scanner
+
+import "testing"
+
+func TestEstimateAPICost(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   string
+		min, max int
+	}{
+		// 2 patterns x (2 pages + 60 content fetches).
+		{"search", `"max_pages": 2`, 2, 124},
+		// Each result may also cost a topic lookup.
+		{"topics", `"max_pages": 2, "topics": ["go"]`, 2, 244},
+		// One search per filename qualifier.
+		{"qualifiers", `"max_pages": 2, "file_patterns": ["^a\\.env$", "^b\\.env$"]`, 4, 248},
+		// Every host runs the whole scan.
+		{"hosts", `"max_pages": 2, "hosts": [{"name": "a", "api_url": "https://a.example/api/v3"}, {"name": "b", "api_url": "https://b.example/api/v3"}]`, 4, 248},
+		// Repository scans depend on the trees' size.
+		{"repositories", `"repositories": ["o/r", "o/s"]`, 4, -1},
+	}
+	for _, c := range cases {
+		config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password", "token"], ` + c.config + `}`))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		est := estimateAPICost(config)
+		if est.Min != c.min || est.Max != c.max {
+			t.Errorf("%s: estimate %d-%d, want %d-%d", c.name, est.Min, est.Max, c.min, c.max)
+		}
+	}
+}
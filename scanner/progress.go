@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// scanProgress tracks how far the pattern loop has got so it can project
+// when the scan will finish and warn when that is past the deadline.
+type scanProgress struct {
+	start    time.Time
+	total    int
+	done     int
+	deadline time.Time
+	warned   bool
+	log      io.Writer
+}
+
+func newScanProgress(total int, deadline time.Time, log io.Writer) *scanProgress {
+	return &scanProgress{start: time.Now(), total: total, deadline: deadline, log: log}
+}
+
+// estimateRemaining projects the time left from the average time per
+// completed item. It returns 0 before anything has completed.
+func estimateRemaining(done, total int, elapsed time.Duration) time.Duration {
+	if done <= 0 || done >= total {
+		return 0
+	}
+	return elapsed / time.Duration(done) * time.Duration(total-done)
+}
+
+// complete records one finished pattern and prints the ETA, warning once if
+// the projected finish is after the deadline.
+func (p *scanProgress) complete(now time.Time) {
+	p.done++
+	if p.done >= p.total {
+		return
+	}
+	remaining := estimateRemaining(p.done, p.total, now.Sub(p.start))
+	fmt.Fprintf(logWriter(p.log), "Progress: %d/%d patterns, about %v remaining\n", p.done, p.total, remaining.Round(time.Second))
+
+	if p.warned || p.deadline.IsZero() {
+		return
+	}
+	if left := p.deadline.Sub(now); remaining > left {
+		fmt.Fprintf(logWriter(p.log), "Warning: projected completion in %v exceeds the time left (%v); about %d pattern(s) may not run. Raise --timeout or reduce search_patterns.\n",
+			remaining.Round(time.Second), left.Round(time.Second), p.patternsPastDeadline(remaining, left))
+		p.warned = true
+	}
+}
+
+// patternsPastDeadline estimates how many remaining patterns will not fit
+// in the time left.
+func (p *scanProgress) patternsPastDeadline(remaining, left time.Duration) int {
+	perPattern := remaining / time.Duration(p.total-p.done)
+	if perPattern <= 0 {
+		return 0
+	}
+	fit := int(left / perPattern)
+	return p.total - p.done - fit
+}
@@ -0,0 +1,58 @@
+package scanner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateRemaining(t *testing.T) {
+	cases := []struct {
+		done, total int
+		elapsed     time.Duration
+		want        time.Duration
+	}{
+		{0, 10, time.Minute, 0},
+		{2, 10, 20 * time.Second, 80 * time.Second},
+		{5, 10, 50 * time.Second, 50 * time.Second},
+		{9, 10, 90 * time.Second, 10 * time.Second},
+		{10, 10, 100 * time.Second, 0},
+	}
+	for _, c := range cases {
+		if got := estimateRemaining(c.done, c.total, c.elapsed); got != c.want {
+			t.Errorf("estimateRemaining(%d, %d, %v) = %v, want %v", c.done, c.total, c.elapsed, got, c.want)
+		}
+	}
+}
+
+func TestProgressWarnsOncePastDeadline(t *testing.T) {
+	var log bytes.Buffer
+	start := time.Now()
+	// 10 patterns at 10s each need 100s; only 35s are allowed.
+	p := &scanProgress{start: start, total: 10, deadline: start.Add(35 * time.Second), log: &log}
+	p.complete(start.Add(10 * time.Second))
+	p.complete(start.Add(20 * time.Second))
+
+	out := log.String()
+	if !strings.Contains(out, "Progress: 1/10 patterns, about 1m30s remaining") {
+		t.Errorf("log %q has no ETA for the first pattern", out)
+	}
+	if n := strings.Count(out, "Warning: projected completion"); n != 1 {
+		t.Fatalf("warned %d times, want once:\n%s", n, out)
+	}
+	// 25s left at 10s per pattern fits 2 of the 9 remaining patterns.
+	if !strings.Contains(out, "in 1m30s exceeds the time left (25s); about 7 pattern(s) may not run") {
+		t.Errorf("warning does not project the shortfall:\n%s", out)
+	}
+}
+
+func TestProgressNoWarningWithinDeadline(t *testing.T) {
+	var log bytes.Buffer
+	start := time.Now()
+	p := &scanProgress{start: start, total: 4, deadline: start.Add(time.Hour), log: &log}
+	p.complete(start.Add(time.Second))
+	if strings.Contains(log.String(), "Warning") {
+		t.Errorf("warned within the deadline: %s", log.String())
+	}
+}
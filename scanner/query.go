@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// buildSearchQuery returns the code search query issued for a pattern,
+// narrowed by qualifier (such as "extension:env") when one is given.
+func buildSearchQuery(pattern, qualifier string) string {
+	q := strings.TrimSpace(pattern) + "+in:file"
+	if qualifier != "" {
+		q += "+" + qualifier
+	}
+	return q
+}
+
+// anchoredNamePattern is a file pattern that is a fully anchored literal
+// file name, such as `^credentials\.json$`.
+var anchoredNamePattern = regexp.MustCompile(`^\^([A-Za-z0-9_-]+(?:\\\.[A-Za-z0-9_-]+)*)\$$`)
+
+// fileQualifier translates a file pattern into a code search qualifier
+// when the pattern is a fully anchored literal: `^credentials\.json$`
+// becomes filename:credentials.json, which returns every file the pattern
+// matches. Anything else, including unanchored names and `\.ext$`
+// suffixes, which GitHub's qualifiers do not match the same way, reports
+// false.
+func fileQualifier(pattern string) (string, bool) {
+	m := anchoredNamePattern.FindStringSubmatch(pattern)
+	if m == nil {
+		return "", false
+	}
+	return "filename:" + strings.ReplaceAll(m[1], `\.`, "."), true
+}
+
+// searchQualifiers returns the qualifiers to run each pattern's search
+// under, so GitHub filters by file rather than returning results that are
+// then discarded. Qualifiers cannot be OR-ed in one query, so there is one
+// search per qualifier, and only when every file filter translates; if any
+// file pattern or glob cannot, there are none and results are only
+// post-filtered. Each qualifier returns a superset of the files its filter
+// matches, and results are always post-filtered as well.
+func searchQualifiers(config *Config) []string {
+	if len(config.FileGlobs) > 0 {
+		return nil
+	}
+	var qualifiers []string
+	for _, ext := range config.FileExtensions {
+		ext = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+		if ext == "" || strings.Contains(ext, ".") {
+			return nil
+		}
+		qualifiers = append(qualifiers, "extension:"+ext)
+	}
+	for _, p := range config.FilePatterns {
+		q, ok := fileQualifier(p)
+		if !ok {
+			return nil
+		}
+		qualifiers = append(qualifiers, q)
+	}
+	return qualifiers
+}
+
+// dedupePatterns drops patterns whose effective search query has already
+// been seen, keeping the first occurrence and the original order. It returns
+// the remaining patterns and how many duplicates were collapsed.
+func dedupePatterns(patterns []string) ([]string, int) {
+	seen := make(map[string]bool, len(patterns))
+	unique := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		q := buildSearchQuery(p, "")
+		if seen[q] {
+			continue
+		}
+		seen[q] = true
+		unique = append(unique, p)
+	}
+	return unique, len(patterns) - len(unique)
+}
@@ -0,0 +1,162 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestFileQualifierOnlyTranslatesAnchoredNames(t *testing.T) {
+	cases := []struct {
+		pattern string
+		want    string
+	}{
+		{`^credentials\.json$`, "filename:credentials.json"},
+		{`^id_rsa$`, "filename:id_rsa"},
+		{`credentials\.json`, ""},
+		{`^credentials\.json`, ""},
+		{`\.env$`, ""},
+		{`^config/`, ""},
+		{`^\.env$`, ""},
+		{`^(a|b)\.json$`, ""},
+	}
+	for _, c := range cases {
+		got, ok := fileQualifier(c.pattern)
+		if ok != (c.want != "") || got != c.want {
+			t.Errorf("fileQualifier(%q) = %q, %v, want %q", c.pattern, got, ok, c.want)
+		}
+	}
+}
+
+// querySearchServer records the q parameter of every code search and
+// returns the same credentials.json result for each.
+type querySearchServer struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func (s *querySearchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.queries = append(s.queries, r.URL.Query().Get("q"))
+	s.mu.Unlock()
+	w.Header().Set("X-RateLimit-Limit", "30")
+	w.Header().Set("X-RateLimit-Remaining", "29")
+	w.Header().Set("X-RateLimit-Reset", "0")
+	json.NewEncoder(w).Encode(map[string]interface{}{"total_count": 2, "items": []interface{}{
+		map[string]interface{}{
+			"path":         "credentials.json",
+			"html_url":     "https://github.com/o/r/blob/main/credentials.json",
+			"repository":   map[string]string{"full_name": "o/r"},
+			"text_matches": []interface{}{map[string]interface{}{"property": "content", "fragment": "password=hunter2", "matches": []interface{}{map[string]interface{}{"text": "password", "indices": []int{0, 8}}}}},
+		},
+		map[string]interface{}{
+			"path":         "nested/credentials.json",
+			"html_url":     "https://github.com/o/r/blob/main/nested/credentials.json",
+			"repository":   map[string]string{"full_name": "o/r"},
+			"text_matches": []interface{}{map[string]interface{}{"property": "content", "fragment": "password=hunter2", "matches": []interface{}{map[string]interface{}{"text": "password", "indices": []int{0, 8}}}}},
+		},
+	}})
+}
+
+func searchWithFilePatterns(t *testing.T, filePatterns string) ([]string, []Finding) {
+	t.Helper()
+	mock := &querySearchServer{}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ` + filePatterns + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	findings, err := searchGitHub(context.Background(), config, "password", &RequestStats{}, newTopicCache())
+	if err != nil {
+		t.Fatalf("searchGitHub: %v", err)
+	}
+	sort.Strings(mock.queries)
+	return mock.queries, findings
+}
+
+func TestAnchoredNamesSearchWithQualifiers(t *testing.T) {
+	queries, findings := searchWithFilePatterns(t, `["^credentials\\.json$", "^id_rsa$"]`)
+	want := []string{"password in:file filename:credentials.json", "password in:file filename:id_rsa"}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("queries %q, want %q", queries, want)
+	}
+	// filename: also returns the nested file, which the post-filter drops.
+	if len(findings) != 1 || findings[0].FilePath != "credentials.json" {
+		t.Errorf("got %+v, want only the root credentials.json", findings)
+	}
+}
+
+func TestUnanchoredPatternsSearchUnqualified(t *testing.T) {
+	queries, findings := searchWithFilePatterns(t, `["^credentials\\.json$", "credentials\\.json"]`)
+	if len(queries) != 1 || queries[0] != "password in:file" {
+		t.Errorf("queries %q, want one unqualified search", queries)
+	}
+	if len(findings) != 2 {
+		t.Errorf("got %d findings, want both credentials.json files", len(findings))
+	}
+}
+
+func TestDuplicatePatternsSearchOnce(t *testing.T) {
+	mock := &querySearchServer{}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password", " password", "token", "password "], "file_patterns": ["^credentials\\.json$"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	if want := []string{"password", "token"}; !reflect.DeepEqual(config.SearchPatterns, want) {
+		t.Errorf("patterns %q, want %q", config.SearchPatterns, want)
+	}
+	if _, err := runScan(context.Background(), config, &RequestStats{}); err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	sort.Strings(mock.queries)
+	want := []string{"password in:file filename:credentials.json", "token in:file filename:credentials.json"}
+	if !reflect.DeepEqual(mock.queries, want) {
+		t.Errorf("queries %q, want each distinct query once: %q", mock.queries, want)
+	}
+}
+
+func TestRecordedQueryMatchesIssued(t *testing.T) {
+	for _, record := range []bool{true, false} {
+		mock := &querySearchServer{}
+		srv := httptest.NewServer(mock)
+		config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["^credentials\\.json$"]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.apiBase = srv.URL
+		config.RecordQueries = record
+
+		findings, err := runScan(context.Background(), config, &RequestStats{})
+		srv.Close()
+		if err != nil {
+			t.Fatalf("runScan: %v", err)
+		}
+		if len(findings) != 1 || len(mock.queries) != 1 {
+			t.Fatalf("got %d findings from queries %q, want one of each", len(findings), mock.queries)
+		}
+		if !record {
+			if findings[0].Query != "" {
+				t.Errorf("query %q recorded without record_queries", findings[0].Query)
+			}
+			continue
+		}
+		issued, err := url.QueryUnescape(findings[0].Query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if issued != mock.queries[0] || issued != "password in:file filename:credentials.json" {
+			t.Errorf("recorded query %q, server received %q", findings[0].Query, mock.queries[0])
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMissingRateLimitHeadersPaceConservatively(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxy that strips the X-RateLimit-* headers.
+		w.Write([]byte(`{"total_count": 0, "items": []}`))
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"], "rate_limit": 0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	stats := &RequestStats{}
+	start := time.Now()
+	if _, err := searchGitHubQuery(context.Background(), config, "password", "", stats, newTopicCache()); err != nil {
+		t.Fatalf("searchGitHubQuery: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < missingRateLimitDelay {
+		t.Errorf("search took %v, want at least the %v conservative delay", elapsed, missingRateLimitDelay)
+	}
+	if stats.TotalWaitTime < missingRateLimitDelay {
+		t.Errorf("TotalWaitTime = %v, want the pacing delay counted", stats.TotalWaitTime)
+	}
+}
+
+func TestConservativeDelayUsesRateLimitFloor(t *testing.T) {
+	for rateLimit, want := range map[int]time.Duration{0: missingRateLimitDelay, 1: missingRateLimitDelay, 5: 5 * time.Second} {
+		if got := conservativeDelay(&Config{RateLimit: rateLimit}); got != want {
+			t.Errorf("rate_limit %d: delay %v, want %v", rateLimit, got, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// rateLimitResponse is the body of GET /rate_limit.
+type rateLimitResponse struct {
+	Resources map[string]RateLimitInfo `json:"resources"`
+}
+
+// updateResources records a /rate_limit poll for token idx, replacing
+// whatever the response headers last reported for each polled resource.
+func (tp *TokenPool) updateResources(idx int, resources map[string]RateLimitInfo) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	for resource, info := range resources {
+		tp.setResourceLocked(idx, resource, info)
+	}
+}
+
+// Resource returns token idx's last polled budget for resource.
+func (tp *TokenPool) Resource(idx int, resource string) (RateLimitInfo, bool) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	info, ok := tp.resources[idx][resource]
+	return info, ok
+}
+
+// pollRateLimits asks /rate_limit for every token's budget and feeds it to
+// the token pool. The endpoint does not count against any rate limit, so
+// the requests are left out of the stats. A token that fails to poll keeps
+// its previous view.
+func pollRateLimits(ctx context.Context, config *Config) {
+	pool := config.tokenPool
+	client := newHTTPClient(config)
+	url := config.apiURL() + "/rate_limit"
+	for idx, token := range pool.tokens {
+		req, err := newGitHubRequest(ctx, config, url)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Authorization", "token "+token)
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() == nil {
+				config.logf("Warning: rate limit poll failed: %v\n", err)
+			}
+			continue
+		}
+		var body rateLimitResponse
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			config.logf("Warning: rate limit poll failed: unexpected status code: %d\n", resp.StatusCode)
+			continue
+		}
+		if err != nil {
+			config.logf("Warning: rate limit poll failed: error decoding response: %v\n", err)
+			continue
+		}
+		pool.updateResources(idx, body.Resources)
+	}
+}
+
+// startRateLimitPoll polls /rate_limit every rate_limit_poll_interval
+// seconds until ctx ends or the returned stop func is called. Header-based
+// budgets only change for the token that made a request and can go stale
+// for the others, especially as windows reset; the poll keeps every
+// token's view current. It does nothing when the interval is not set.
+func startRateLimitPoll(ctx context.Context, config *Config) (stop func()) {
+	if config.RateLimitPollInterval <= 0 || config.tokenPool.Len() == 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(secondsToDuration(config.RateLimitPollInterval))
+		defer ticker.Stop()
+		for {
+			pollRateLimits(ctx, config)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
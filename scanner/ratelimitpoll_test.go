@@ -0,0 +1,77 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPollRateLimitsUpdatesTokenPool(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	remaining := map[string]int{"token low": 3, "token high": 25}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rate_limit" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"resources": {"core": {"limit": 5000, "remaining": 4999, "reset": %d}, "search": {"limit": 30, "remaining": %d, "reset": %d}}}`,
+			reset, remaining[r.Header.Get("Authorization")], reset)
+	}))
+	defer srv.Close()
+
+	config, err := parseConfig([]byte(`{"github_tokens": ["low", "high"], "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	pool := config.tokenPool
+
+	pollRateLimits(context.Background(), config)
+
+	now := time.Now()
+	if got := pool.budget(0, "search", now); got != 3 {
+		t.Errorf("low token budget = %d, want 3", got)
+	}
+	if got := pool.budget(1, "search", now); got != 25 {
+		t.Errorf("high token budget = %d, want 25", got)
+	}
+	if info, ok := pool.Resource(0, "core"); !ok || info.Remaining != 4999 {
+		t.Errorf("low token core = %+v, %v; want 4999 remaining", info, ok)
+	}
+	if token := pool.GetNextToken("code_search"); token != "high" {
+		t.Errorf("next token = %s, want the one with more search budget", token)
+	}
+}
+
+func TestRateLimitPollRunsPeriodically(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&polls, 1)
+		w.Write([]byte(`{"resources": {}}`))
+	}))
+	defer srv.Close()
+
+	config, err := parseConfig([]byte(`{"github_token": "t", "rate_limit_poll_interval": 0.02}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	stop := startRateLimitPoll(context.Background(), config)
+	time.Sleep(100 * time.Millisecond)
+	stop()
+	// Let a request cancelled by stop finish reaching the server.
+	time.Sleep(20 * time.Millisecond)
+	n := atomic.LoadInt32(&polls)
+	if n < 2 {
+		t.Errorf("%d polls in 100ms at a 20ms interval, want several", n)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if after := atomic.LoadInt32(&polls); after != n {
+		t.Errorf("polling continued after stop (%d -> %d)", n, after)
+	}
+}
@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+)
+
+// runReclassify implements the reclassify subcommand: it re-applies the
+// current severity rules to a findings file from an earlier run without
+// scanning again.
+func runReclassify(args []string) {
+	fs := flag.NewFlagSet("reclassify", flag.ExitOnError)
+	input := fs.String("input", "findings.json", "Findings JSON file from a previous run")
+	out := fs.String("out", "", "File to write reclassified findings to (defaults to --input)")
+	configPath := fs.String("config", "", "Configuration file with severity_overrides and thresholds (optional)")
+	errorFormat := fs.String("error-format", "text", "Format for fatal errors (text or json, written to stderr)")
+	fs.Parse(args)
+
+	config := &Config{}
+	if *configPath != "" {
+		var err error
+		if config, err = loadConfig(*configPath); err != nil {
+			exitWithError(*errorFormat, errCodeConfig, "Error loading config", err)
+		}
+	}
+
+	findings, err := loadFindings(*input)
+	if err != nil {
+		exitWithError(*errorFormat, errCodeUsage, "Error loading findings", err)
+	}
+
+	changed := len(severityChanges(config, findings))
+
+	if *out == "" {
+		*out = *input
+	}
+	var buf bytes.Buffer
+	if err := writeFindings(&buf, findings, outputOptions{format: "json"}); err != nil {
+		exitWithError(*errorFormat, errCodeOutput, "Error encoding findings", err)
+	}
+	if err := writeBytesAtomic(*out, buf.Bytes()); err != nil {
+		exitWithError(*errorFormat, errCodeOutput, "Error saving findings", err)
+	}
+	fmt.Printf("Reclassified %d finding(s), %d changed severity; saved to %s\n", len(findings), changed, *out)
+}
+
+// severityChange is a finding whose recorded severity differs from the one
+// the current rules assign.
+type severityChange struct {
+	Finding
+	Previous string
+}
+
+// severityChanges reclassifies findings in place under the config's rules
+// and returns the ones whose severity changed.
+func severityChanges(config *Config, findings []Finding) []severityChange {
+	before := make([]string, len(findings))
+	for i, f := range findings {
+		before[i] = f.Severity
+	}
+	classifyFindings(config, findings)
+	var changes []severityChange
+	for i, f := range findings {
+		if f.Severity != before[i] {
+			changes = append(changes, severityChange{Finding: f, Previous: before[i]})
+		}
+	}
+	return changes
+}
+
+// runDiffSeverity implements --diff-severity: it reports which findings in
+// input would change severity under the loaded config's rules, without
+// scanning and without modifying input.
+func runDiffSeverity(config *Config, input, errorFormat string) {
+	findings, err := loadFindings(input)
+	if err != nil {
+		exitWithError(errorFormat, errCodeUsage, "Error loading findings", err)
+	}
+	changes := severityChanges(config, findings)
+	if len(changes) == 0 {
+		fmt.Printf("No severity changes among %d finding(s) in %s\n", len(findings), input)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tFILE\tPATTERN\tRECORDED\tNEW")
+	for _, c := range changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Repository, c.FilePath, c.Pattern, c.Previous, c.Severity)
+	}
+	w.Flush()
+	fmt.Printf("\n%d of %d finding(s) in %s would change severity\n", len(changes), len(findings), input)
+}
+
+// loadFindings reads a JSON findings file written by an earlier run.
+func loadFindings(path string) ([]Finding, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading findings file: %v", err)
+	}
+	var findings []Finding
+	if err := json.Unmarshal(data, &findings); err != nil {
+		return nil, fmt.Errorf("error parsing findings file: %v", err)
+	}
+	return findings, nil
+}
+
+// isSubcommand reports whether the command line starts with name.
+func isSubcommand(name string) bool {
+	return len(os.Args) > 1 && os.Args[1] == name
+}
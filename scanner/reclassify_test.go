@@ -0,0 +1,104 @@
+package scanner
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingTransport fails every request, so a test can assert that no HTTP
+// call is made.
+type failingTransport struct{ t *testing.T }
+
+func (f failingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	f.t.Errorf("unexpected request to %s", r.URL)
+	return nil, http.ErrHandlerTimeout
+}
+
+func TestReclassifyAppliesUpdatedRules(t *testing.T) {
+	saved := http.DefaultTransport
+	http.DefaultTransport = failingTransport{t}
+	defer func() { http.DefaultTransport = saved }()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "findings.json")
+	if err := os.WriteFile(input, []byte(`[
+		{"repository": "o/r", "file_path": ".env", "pattern": "password", "severity": "HIGH"},
+		{"repository": "o/r", "file_path": "app.yml", "pattern": "internal_url", "severity": "MEDIUM"}
+	]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"severity_overrides": {"password": "critical", "internal_url": "low"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	runReclassify([]string{"--input", input, "--config", configPath})
+
+	findings, err := loadFindings(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findings[0].Severity != "CRITICAL" || findings[1].Severity != "LOW" {
+		t.Errorf("severities %s, %s, want CRITICAL, LOW", findings[0].Severity, findings[1].Severity)
+	}
+}
+
+func TestSeverityOverridesValidated(t *testing.T) {
+	_, err := parseConfig([]byte(`{"severity_overrides": {"password": "urgent"}}`))
+	if err == nil || !strings.Contains(err.Error(), "severity_overrides: password") {
+		t.Errorf("unknown override severity returned %v", err)
+	}
+
+	config, err := parseConfig([]byte(`{"severity_overrides": {"password": " critical "}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.severityFor("password"); got != "CRITICAL" {
+		t.Errorf("override severity %q, want CRITICAL", got)
+	}
+}
+
+func TestDiffSeverityReportsOnlyChanges(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "previous.json")
+	recorded := []byte(`[
+		{"repository": "o/r", "file_path": ".env", "pattern": "password", "severity": "HIGH"},
+		{"repository": "o/r", "file_path": "app.yml", "pattern": "internal_url", "severity": "MEDIUM"},
+		{"repository": "o/r", "file_path": "dns.txt", "pattern": "zone", "severity": "MEDIUM"}
+	]`)
+	if err := os.WriteFile(input, recorded, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(config, []byte(`{"github_token": "t", "search_patterns": ["password", "internal_url", "zone"],
+		"severity_overrides": {"internal_url": "low"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runMain(t, dir, "-config", config, "-diff-severity", "-input", input)
+	if err != nil {
+		t.Fatalf("diff failed: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "1 of 3 finding(s)") {
+		t.Errorf("summary missing:\n%s", stdout)
+	}
+	var rows []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.HasPrefix(line, "o/r") {
+			rows = append(rows, strings.Join(strings.Fields(line), " "))
+		}
+	}
+	if len(rows) != 1 || rows[0] != "o/r app.yml internal_url MEDIUM LOW" {
+		t.Errorf("reported %q, want only internal_url going from MEDIUM to LOW", rows)
+	}
+
+	if data, _ := os.ReadFile(input); string(data) != string(recorded) {
+		t.Error("--diff-severity modified its input")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "findings.json")); !os.IsNotExist(err) {
+		t.Error("--diff-severity wrote findings")
+	}
+}
@@ -0,0 +1,44 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// defaultMaxRedirects matches net/http's own limit.
+const defaultMaxRedirects = 10
+
+// apiHost returns the host of the API URL, the only host requests carry a
+// token to.
+func (c *Config) apiHost() string {
+	u, err := url.Parse(c.apiURL())
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// checkRedirect follows at most max_redirects redirects (default 10, or
+// none when negative). Content endpoints redirect to hosts such as
+// raw.githubusercontent.com or S3, which must never see the token, so
+// Authorization is dropped whenever a redirect leaves the original host;
+// authTransport likewise only authenticates requests to the API host.
+func checkRedirect(config *Config) func(req *http.Request, via []*http.Request) error {
+	max := config.MaxRedirects
+	if max == 0 {
+		max = defaultMaxRedirects
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if max < 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= max {
+			return fmt.Errorf("stopped after %d redirects", max)
+		}
+		if req.URL.Host != via[0].URL.Host {
+			req.Header.Del("Authorization")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedirectStripsTokenAcrossHosts(t *testing.T) {
+	authSeen := make(map[string]string)
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authSeen["storage"] = r.Header.Get("Authorization")
+		w.Write([]byte("password=hunter2"))
+	}))
+	defer storage.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authSeen[r.URL.Path] = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/content", http.StatusFound)
+		case "/content":
+			http.Redirect(w, r, storage.URL+"/blob", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}
+	}))
+	defer api.Close()
+	config, err := parseConfig([]byte(`{"github_token": "secret-token", "search_patterns": ["password"], "max_redirects": 3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = api.URL
+	client := newHTTPClient(config)
+	get := func(path string) (*http.Response, error) {
+		req, err := newGitHubRequest(context.Background(), config, api.URL+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client.Do(req)
+	}
+
+	resp, err := get("/moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !strings.Contains(authSeen["/content"], "secret-token") {
+		t.Errorf("same-host redirect lost the token: %q", authSeen["/content"])
+	}
+	if got, ok := authSeen["storage"]; !ok || got != "" {
+		t.Errorf("cross-host redirect sent Authorization %q (reached: %v)", got, ok)
+	}
+
+	if _, err := get("/loop"); err == nil || !strings.Contains(err.Error(), "stopped after 3 redirects") {
+		t.Errorf("got %v, want max_redirects to stop the loop", err)
+	}
+}
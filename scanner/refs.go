@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+)
+
+type branchInfo struct {
+	name string
+	sha  string
+}
+
+// refsForRepo returns the ref patterns configured for repo. Keys in the refs
+// map are either exact "owner/name" repositories or globs such as "org/*".
+func refsForRepo(refs map[string][]string, repo string) []string {
+	var patterns []string
+	for key, refPatterns := range refs {
+		if key == repo {
+			patterns = append(patterns, refPatterns...)
+			continue
+		}
+		if matched, _ := path.Match(key, repo); matched {
+			patterns = append(patterns, refPatterns...)
+		}
+	}
+	return patterns
+}
+
+// matchesRef reports whether a branch name matches any of the ref patterns,
+// which may be literal names or globs like "release/*".
+func matchesRef(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if p == name {
+			return true
+		}
+		if matched, _ := path.Match(p, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// listBranches returns every branch of repo along with its head commit.
+func listBranches(ctx context.Context, client *http.Client, config *Config, repo string, stats *RequestStats) ([]branchInfo, error) {
+	const perPage = 100
+	var branches []branchInfo
+	for page := 1; ; page++ {
+		var result []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				SHA string `json:"sha"`
+			} `json:"commit"`
+		}
+		url := fmt.Sprintf("%s/repos/%s/branches?per_page=%d&page=%d", config.apiURL(), repo, perPage, page)
+		if err := getJSON(ctx, client, config, url, stats, &result); err != nil {
+			return branches, fmt.Errorf("error listing branches for %s: %v", repo, err)
+		}
+		for _, b := range result {
+			branches = append(branches, branchInfo{name: b.Name, sha: b.Commit.SHA})
+		}
+		if len(result) < perPage {
+			return branches, nil
+		}
+	}
+}
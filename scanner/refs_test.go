@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// refsServer mocks a repository whose default branch is clean and whose
+// release branches each hold one secret file.
+func refsServer() *httptest.Server {
+	trees := map[string]string{"main1": "clean.env", "rel1": "one.env", "rel2": "two.env", "feat1": "feature.env"}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/o/r/commits/HEAD":
+			fmt.Fprint(w, `{"sha": "main1"}`)
+		case r.URL.Path == "/repos/o/r/branches":
+			fmt.Fprint(w, `[{"name": "main", "commit": {"sha": "main1"}},
+				{"name": "release/1", "commit": {"sha": "rel1"}},
+				{"name": "release/2", "commit": {"sha": "rel2"}},
+				{"name": "feature", "commit": {"sha": "feat1"}}]`)
+		case strings.HasPrefix(r.URL.Path, "/repos/o/r/git/trees/"):
+			path, ok := trees[strings.TrimPrefix(r.URL.Path, "/repos/o/r/git/trees/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"tree": [{"path": %q, "type": "blob"}]}`, path)
+		case strings.HasPrefix(r.URL.Path, "/repos/o/r/contents/"):
+			if r.URL.Query().Get("ref") == "main1" {
+				fmt.Fprint(w, "nothing to see\n")
+				return
+			}
+			fmt.Fprint(w, "password=hunter2\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestRefsFindingsRecordTheirBranch(t *testing.T) {
+	srv := refsServer()
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"],
+		"repositories": ["o/r"], "refs": {"o/*": ["release/*"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := collectRepositories(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("scanRepositories: %v", err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Ref+":"+f.FilePath)
+	}
+	sort.Strings(got)
+	if want := "release/1:one.env,release/2:two.env"; strings.Join(got, ",") != want {
+		t.Errorf("findings %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,320 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const githubWebURL = "https://github.com"
+
+// getJSON performs an authenticated GET against the API and decodes a 200
+// response into v.
+func getJSON(ctx context.Context, client *http.Client, config *Config, url string, stats *RequestStats, v interface{}) error {
+	resp, err := doWithRetry(ctx, client, func() (*http.Request, error) {
+		return newGitHubRequest(ctx, config, url)
+	}, newRetryPolicy(config), stats)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		stats.IncrementFailed()
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	stats.IncrementSuccess()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
+
+// resolveRef returns the commit SHA that ref currently points to.
+func resolveRef(ctx context.Context, client *http.Client, config *Config, repo, ref string, stats *RequestStats) (string, error) {
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/commits/%s", config.apiURL(), repo, url.PathEscape(ref))
+	if err := getJSON(ctx, client, config, endpoint, stats, &commit); err != nil {
+		return "", fmt.Errorf("error resolving %s@%s: %v", repo, ref, err)
+	}
+	return commit.SHA, nil
+}
+
+// listRepoTree returns the paths of all files in the repository at sha.
+// complete is false when the API truncated the tree.
+func listRepoTree(ctx context.Context, client *http.Client, config *Config, repo, sha string, stats *RequestStats) (paths []string, complete bool, err error) {
+	var tree struct {
+		Tree []struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+		} `json:"tree"`
+		Truncated bool `json:"truncated"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/git/trees/%s?recursive=1", config.apiURL(), repo, sha)
+	if err := getJSON(ctx, client, config, url, stats, &tree); err != nil {
+		return nil, false, fmt.Errorf("error listing tree for %s: %v", repo, err)
+	}
+	if tree.Truncated {
+		config.logf("Warning: tree for %s was truncated by the API, some files will not be scanned\n", repo)
+	}
+
+	for _, entry := range tree.Tree {
+		if entry.Type == "blob" {
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths, !tree.Truncated, nil
+}
+
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// fetchFileContent downloads the raw contents of path at ref. Concurrent
+// fetches of the same URL share a single request.
+func fetchFileContent(ctx context.Context, client *http.Client, config *Config, repo, path, ref string, stats *RequestStats) ([]byte, error) {
+	url := fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s", config.apiURL(), repo, escapePath(path), ref)
+	content, err, _ := contentFetches.do(ctx, url, func(ctx context.Context) ([]byte, error) {
+		return fetchURLContent(ctx, client, config, url, repo, path, stats)
+	})
+	return content, err
+}
+
+func fetchURLContent(ctx context.Context, client *http.Client, config *Config, url, repo, path string, stats *RequestStats) ([]byte, error) {
+	resp, err := doWithRetry(ctx, client, func() (*http.Request, error) {
+		req, err := newGitHubRequest(ctx, config, url)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github.raw")
+		return req, nil
+	}, newRetryPolicy(config), stats)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		stats.IncrementFailed()
+		return nil, fmt.Errorf("unexpected status code fetching %s/%s: %d", repo, path, resp.StatusCode)
+	}
+	stats.IncrementSuccess()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s/%s: %v", repo, path, err)
+	}
+	return content, nil
+}
+
+// scanRepoFiles fetches each path at sha that matches the configured file
+// patterns and scans its contents. Findings are attributed to ref, which is
+// empty for the default branch.
+func scanRepoFiles(ctx context.Context, client *http.Client, config *Config, repo, ref, sha string, paths []string, matcher *contentMatcher, stats *RequestStats) ([]Finding, error) {
+	var findings []Finding
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+		if config.repoHit(repo) {
+			break
+		}
+		if !config.matchesFile(path) {
+			continue
+		}
+
+		content, err := fetchFileContent(ctx, client, config, repo, path, sha, stats)
+		if err != nil {
+			return findings, err
+		}
+		if isBinaryContent(content) {
+			config.logf("Skipping binary file: %s in %s\n", path, repo)
+			stats.IncrementSkippedBinary()
+			continue
+		}
+
+		fileURL := fmt.Sprintf("%s/%s/blob/%s/%s", config.webURL(), repo, sha, escapePath(path))
+		for _, f := range matcher.scan(repo, path, fileURL, content) {
+			if !config.claimRepoHit(repo) || !config.claimFinding() {
+				break
+			}
+			f.Ref = ref
+			config.logf("Found %s: %s in %s (line %d)\n", severityLabel(f.Severity), f.FilePath, f.Repository, f.Line)
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// scanTarget is a single commit of a repository to scan, either the default
+// branch (empty ref) or a configured branch.
+type scanTarget struct {
+	ref string
+	sha string
+}
+
+// stateKey identifies a target in the incremental scan state.
+func (t scanTarget) stateKey(repo string) string {
+	if t.ref == "" {
+		return repo
+	}
+	return repo + "@" + t.ref
+}
+
+// repoTargets resolves the default branch plus any configured refs for repo.
+// Refs pointing at a commit that is already being scanned are skipped.
+func repoTargets(ctx context.Context, client *http.Client, config *Config, repo string, stats *RequestStats) ([]scanTarget, error) {
+	head, err := resolveRef(ctx, client, config, repo, "HEAD", stats)
+	if err != nil {
+		return nil, err
+	}
+	targets := []scanTarget{{sha: head}}
+
+	refPatterns := refsForRepo(config.Refs, repo)
+	if len(refPatterns) == 0 {
+		return targets, nil
+	}
+
+	branches, err := listBranches(ctx, client, config, repo, stats)
+	if err != nil {
+		return targets, err
+	}
+	seen := map[string]bool{head: true}
+	for _, b := range branches {
+		if !matchesRef(refPatterns, b.name) {
+			continue
+		}
+		if seen[b.sha] {
+			config.logf("Branch %s is at an already scanned commit (%s), skipping\n", b.name, shortSHA(b.sha))
+			continue
+		}
+		seen[b.sha] = true
+		targets = append(targets, scanTarget{ref: b.name, sha: b.sha})
+	}
+	return targets, nil
+}
+
+// scanRepositories scans the configured repositories directly rather than
+// through code search, adding each repository's findings to spool as it is
+// done. In incremental mode only files changed since the last scanned
+// commit are fetched, and the new commit is recorded on success.
+func scanRepositories(ctx context.Context, config *Config, stats *RequestStats, spool *findingSpool) error {
+	client := newHTTPClient(config)
+	matcher := newContentMatcher(config)
+
+	var state *scanState
+	if config.Incremental {
+		var err error
+		state, err = loadScanState(config.stateFilePath())
+		if err != nil {
+			return err
+		}
+	}
+
+	repos, err := repositoriesToScan(ctx, client, config, stats)
+	if err != nil {
+		config.reportError(err)
+	}
+
+	visibility := newVisibilityCache()
+	recordFindingsByPattern(stats, config.SearchPatterns, nil)
+	for _, repo := range repos {
+		if ctx.Err() != nil {
+			break
+		}
+
+		config.logf("\nScanning repository: %s\n", repo)
+		repoCtx, repoSpan := startSpan(ctx, "repository")
+		repoSpan.setAttribute("repository", repo)
+		findings := scanRepository(repoCtx, client, config, repo, state, matcher, stats)
+		visibility.markPublic(repoCtx, client, config, repo, findings, stats)
+		repoSpan.setAttribute("findings", len(findings))
+		repoSpan.finish()
+		recordFindingsByPattern(stats, config.SearchPatterns, findings)
+		config.collect(spool, findings)
+	}
+
+	if state != nil {
+		return state.save(config.stateFilePath())
+	}
+	return nil
+}
+
+// scanRepository scans the default branch and configured refs of a single
+// repository, recording each completely scanned commit in state.
+func scanRepository(ctx context.Context, client *http.Client, config *Config, repo string, state *scanState, matcher *contentMatcher, stats *RequestStats) []Finding {
+	targets, err := repoTargets(ctx, client, config, repo, stats)
+	if err != nil {
+		config.reportError(err)
+	}
+
+	var findings []Finding
+	for _, target := range targets {
+		if target.ref != "" {
+			config.logf("Scanning branch: %s\n", target.ref)
+		}
+		targetFindings, complete, err := scanTargetFiles(ctx, client, config, repo, target, state, matcher, stats)
+		findings = append(findings, targetFindings...)
+		if err != nil {
+			config.reportError(err)
+			continue
+		}
+		// A commit whose file list was truncated, or a repository cut
+		// short by first_hit_per_repo, was not fully scanned, so its
+		// commit is not recorded and the next run covers it again.
+		if state != nil && complete && !config.repoHit(repo) {
+			state.Repos[target.stateKey(repo)] = target.sha
+		}
+	}
+	return findings
+}
+
+// scanTargetFiles scans a single target, limited to the files changed since
+// the last recorded commit when incremental state is available. When the
+// comparison fails, for example because the recorded commit was force-pushed
+// away, or lists too many files to be complete, the whole tree is scanned
+// instead. complete reports whether every file of the target was covered.
+func scanTargetFiles(ctx context.Context, client *http.Client, config *Config, repo string, target scanTarget, state *scanState, matcher *contentMatcher, stats *RequestStats) (findings []Finding, complete bool, err error) {
+	last := ""
+	if state != nil {
+		last = state.Repos[target.stateKey(repo)]
+	}
+
+	if last == target.sha {
+		config.logf("No changes since %s, skipping\n", shortSHA(target.sha))
+		return nil, true, nil
+	}
+
+	var paths []string
+	if last != "" {
+		paths, complete, err = compareChangedFiles(ctx, client, config, repo, last, target.sha, stats)
+		switch {
+		case err != nil:
+			config.logf("Warning: %v; scanning the whole tree instead\n", err)
+		case !complete:
+			config.logf("Comparison with %s lists %d or more files and may be cut short; scanning the whole tree instead\n", shortSHA(last), compareFilesLimit)
+		default:
+			config.logf("Scanning %d files changed since %s\n", len(paths), shortSHA(last))
+		}
+	}
+	if last == "" || err != nil || !complete {
+		paths, complete, err = listRepoTree(ctx, client, config, repo, target.sha, stats)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	findings, err = scanRepoFiles(ctx, client, config, repo, target.ref, target.sha, paths, matcher, stats)
+	return findings, complete, err
+}
@@ -0,0 +1,393 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultRetryBaseDelay = 1 * time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+
+	// GitHub asks clients to wait at least a minute after a secondary rate
+	// limit when no Retry-After header is given.
+	secondaryRateLimitWait = 60 * time.Second
+
+	// Some endpoints answer 202 Accepted while they compute a result and
+	// expect the client to ask again shortly.
+	computingRetryDelay = 2 * time.Second
+	maxComputingRetries = 5
+)
+
+// retryPolicy controls how failed requests are retried with exponential backoff.
+// A non-zero budget caps the total wall-clock time spent on a single request,
+// including all of its retries, regardless of how many attempts remain.
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	budget     time.Duration
+	// jitter, up to maxJitter, is waited before every request.
+	jitter    *jitter
+	maxJitter time.Duration
+	// extraCodes are the retryable_status_codes retried on top of the
+	// built-in ones.
+	extraCodes map[int]bool
+	// attemptTimeout bounds each attempt, growing by timeoutEscalation per
+	// retry up to maxAttemptTimeout; zero means no per-attempt timeout.
+	attemptTimeout    time.Duration
+	timeoutEscalation float64
+	maxAttemptTimeout time.Duration
+	// log receives retry messages; nil means stdout.
+	log io.Writer
+}
+
+// newRetryPolicy builds the retry policy from max_retries,
+// retry_base_delay, retry_max_delay and request_jitter (seconds), using the
+// defaults for unset values. A negative max_retries disables retries.
+// retryable_status_codes adds status codes to retry. request_timeout bounds
+// each attempt; request_timeout_escalation multiplies it on every retry, up
+// to max_request_timeout.
+func newRetryPolicy(config *Config) retryPolicy {
+	policy := retryPolicy{
+		maxRetries: defaultMaxRetries,
+		baseDelay:  defaultRetryBaseDelay,
+		maxDelay:   defaultRetryMaxDelay,
+		log:        config.Log,
+	}
+	if config.MaxRetries > 0 {
+		policy.maxRetries = config.MaxRetries
+	} else if config.MaxRetries < 0 {
+		policy.maxRetries = 0
+	}
+	if config.RetryBaseDelay > 0 {
+		policy.baseDelay = secondsToDuration(config.RetryBaseDelay)
+	}
+	if config.RetryMaxDelay > 0 {
+		policy.maxDelay = secondsToDuration(config.RetryMaxDelay)
+	}
+	if config.RequestJitter > 0 {
+		policy.jitter = config.jitter
+		policy.maxJitter = secondsToDuration(config.RequestJitter)
+	}
+	if config.RetryBudget > 0 {
+		policy.budget = time.Duration(config.RetryBudget) * time.Second
+	}
+	if config.RequestTimeout > 0 {
+		policy.attemptTimeout = secondsToDuration(config.RequestTimeout)
+		policy.timeoutEscalation = config.TimeoutEscalation
+		policy.maxAttemptTimeout = secondsToDuration(config.MaxRequestTimeout)
+	}
+	if len(config.RetryableStatusCodes) > 0 {
+		policy.extraCodes = make(map[int]bool, len(config.RetryableStatusCodes))
+		for _, code := range config.RetryableStatusCodes {
+			policy.extraCodes[code] = true
+		}
+	}
+	return policy
+}
+
+// backoff returns the delay before the given retry attempt (0-based).
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.baseDelay
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.maxDelay {
+			return p.maxDelay
+		}
+	}
+	if delay > p.maxDelay {
+		return p.maxDelay
+	}
+	return delay
+}
+
+// timeout returns the timeout for the given attempt (0-based), or 0 when
+// attempts are not individually bounded. Slow but working endpoints that
+// time out once then get longer on each retry instead of failing the same
+// way again.
+func (p retryPolicy) timeout(attempt int) time.Duration {
+	if p.attemptTimeout <= 0 {
+		return 0
+	}
+	timeout := p.attemptTimeout
+	if p.timeoutEscalation > 1 {
+		for i := 0; i < attempt; i++ {
+			timeout = time.Duration(float64(timeout) * p.timeoutEscalation)
+			if p.maxAttemptTimeout > 0 && timeout >= p.maxAttemptTimeout {
+				return p.maxAttemptTimeout
+			}
+		}
+	}
+	return timeout
+}
+
+// cancelingBody cancels an attempt's context once its body is closed.
+type cancelingBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// exceedsBudget reports whether waiting delay more would push the request
+// past its retry budget.
+func (p retryPolicy) exceedsBudget(start time.Time, delay time.Duration) bool {
+	return p.budget > 0 && time.Since(start)+delay > p.budget
+}
+
+// isSecondaryRateLimit reports whether a 403 response is GitHub's secondary
+// rate limit, which is only signalled in the body. The body is buffered and
+// put back so callers can still read it.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(bytes.ToLower(data), []byte("secondary rate limit"))
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// retryable reports whether the policy retries status code: the built-in
+// 429 and 5xx, or one of retryable_status_codes.
+func (p retryPolicy) retryable(code int) bool {
+	return isRetryableStatus(code) || p.extraCodes[code]
+}
+
+// isRetryableNetError reports whether a transport error is likely to be
+// transient: timeouts (including TLS handshake timeouts), temporary DNS
+// failures and dropped connections. A host that does not resolve at all is
+// treated as permanent.
+func isRetryableNetError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+	return false
+}
+
+// doWithRetry issues the request built by newRequest, retrying retryable
+// status codes with backoff. When no retries remain (or the budget is spent)
+// the last response is returned unchanged so the caller can handle it.
+func doWithRetry(ctx context.Context, client *http.Client, newRequest func() (*http.Request, error), policy retryPolicy, stats *RequestStats) (*http.Response, error) {
+	start := time.Now()
+	computing := 0
+	for attempt := 0; ; attempt++ {
+		if wait := policy.jitter.upTo(policy.maxJitter); wait > 0 {
+			stats.AddWaitTime(wait)
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %v", err)
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout := policy.timeout(attempt); timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+			req = req.WithContext(attemptCtx)
+		}
+
+		stats.IncrementTotal()
+		_, reqSpan := startSpan(ctx, "http.request")
+		reqSpan.setAttribute("http.method", req.Method)
+		reqSpan.setAttribute("http.url", req.URL.String())
+		reqSpan.setAttribute("retry.attempt", attempt)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body = &cancelingBody{ReadCloser: resp.Body, cancel: cancel}
+		} else {
+			cancel()
+		}
+		if err != nil {
+			reqSpan.recordError(err)
+		} else {
+			reqSpan.setAttribute("http.status_code", resp.StatusCode)
+			if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+				reqSpan.setAttribute("github.ratelimit.remaining", remaining)
+			}
+		}
+		reqSpan.finish()
+
+		if err != nil {
+			stats.IncrementFailed()
+			// An attempt that ran out its own timeout is retried, unlike
+			// one cut short by the caller's context.
+			attemptTimedOut := attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+			if attemptTimedOut {
+				fmt.Fprintf(logWriter(policy.log), "Request timed out after %v\n", policy.timeout(attempt))
+			}
+			if !(isRetryableNetError(err) || attemptTimedOut) || attempt >= policy.maxRetries {
+				return nil, fmt.Errorf("error making request: %v", err)
+			}
+			delay := policy.backoff(attempt)
+			if policy.exceedsBudget(start, delay) {
+				fmt.Fprintf(logWriter(policy.log), "Retry budget of %v exhausted after %d attempts\n", policy.budget, attempt+1)
+				return nil, fmt.Errorf("error making request: %v", err)
+			}
+			fmt.Fprintf(logWriter(policy.log), "Network error (%v), retrying in %v (attempt %d/%d)\n",
+				err, delay, attempt+1, policy.maxRetries)
+			stats.IncrementRetried()
+			stats.AddWaitTime(delay)
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode == http.StatusAccepted {
+			if computing >= maxComputingRetries {
+				fmt.Fprintf(logWriter(policy.log), "Result still being computed after %d attempts, giving up\n", computing+1)
+				return resp, nil
+			}
+			if policy.exceedsBudget(start, computingRetryDelay) {
+				fmt.Fprintf(logWriter(policy.log), "Retry budget of %v exhausted after %d attempts\n", policy.budget, attempt+computing+1)
+				return resp, nil
+			}
+			resp.Body.Close()
+			computing++
+			fmt.Fprintf(logWriter(policy.log), "Result still being computed (202), retrying in %v (%d/%d)\n",
+				computingRetryDelay, computing, maxComputingRetries)
+			stats.IncrementRetried()
+			stats.AddWaitTime(computingRetryDelay)
+			if err := sleepContext(ctx, computingRetryDelay); err != nil {
+				return nil, err
+			}
+			// 202s have their own limit and do not use up error retries.
+			attempt--
+			continue
+		}
+
+		retryable := policy.retryable(resp.StatusCode)
+		secondary := resp.StatusCode == http.StatusForbidden && isSecondaryRateLimit(resp)
+		if secondary {
+			stats.IncrementRateLimit()
+			retryable = true
+		}
+		if !retryable || attempt >= policy.maxRetries {
+			return resp, nil
+		}
+
+		delay := policy.backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			delay = retryAfter
+		} else if secondary && delay < secondaryRateLimitWait {
+			delay = secondaryRateLimitWait
+		}
+		if policy.exceedsBudget(start, delay) {
+			fmt.Fprintf(logWriter(policy.log), "Retry budget of %v exhausted after %d attempts\n", policy.budget, attempt+1)
+			return resp, nil
+		}
+
+		resp.Body.Close()
+		stats.IncrementFailed()
+		if secondary {
+			fmt.Fprintf(logWriter(policy.log), "Secondary rate limit hit, retrying in %v (attempt %d/%d)\n",
+				delay, attempt+1, policy.maxRetries)
+		} else {
+			fmt.Fprintf(logWriter(policy.log), "Request failed with status %d, retrying in %v (attempt %d/%d)\n",
+				resp.StatusCode, delay, attempt+1, policy.maxRetries)
+		}
+		stats.IncrementRetried()
+		stats.AddWaitTime(delay)
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepContext waits for d, returning early with the context's error if it
+// is cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// validateRetryPolicy rejects retry settings where the base delay exceeds
+// the cap, which would make every backoff the cap.
+func validateRetryPolicy(config *Config) error {
+	policy := newRetryPolicy(config)
+	if policy.baseDelay > policy.maxDelay {
+		return fmt.Errorf("retry_base_delay (%v) must not exceed retry_max_delay (%v)", policy.baseDelay, policy.maxDelay)
+	}
+	if config.TimeoutEscalation != 0 && config.TimeoutEscalation < 1 {
+		return fmt.Errorf("request_timeout_escalation (%v) must be at least 1", config.TimeoutEscalation)
+	}
+	if policy.maxAttemptTimeout > 0 && policy.maxAttemptTimeout < policy.attemptTimeout {
+		return fmt.Errorf("max_request_timeout (%v) must not be below request_timeout (%v)", policy.maxAttemptTimeout, policy.attemptTimeout)
+	}
+	for _, code := range config.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("retryable_status_codes: invalid status code %d", code)
+		}
+		if code >= 200 && code < 300 {
+			return fmt.Errorf("retryable_status_codes: %d is a success status", code)
+		}
+	}
+	return nil
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
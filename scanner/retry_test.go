@@ -0,0 +1,361 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// flakyServer answers status to the first failures requests and 200 after.
+func flakyServer(status int, failures int32) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	return srv, &requests
+}
+
+func getWithRetry(t *testing.T, config *Config, url string) *http.Response {
+	t.Helper()
+	resp, err := doWithRetry(context.Background(), http.DefaultClient, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, url, nil)
+	}, newRetryPolicy(config), &RequestStats{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestRetryableStatusCodesRetried(t *testing.T) {
+	srv, requests := flakyServer(http.StatusConflict, 2)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"retryable_status_codes": [409], "retry_base_delay": 0.01, "retry_max_delay": 0.05}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := getWithRetry(t, config, srv.URL)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200 after retrying 409", resp.StatusCode)
+	}
+	if n := atomic.LoadInt32(requests); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+	if !newRetryPolicy(config).retryable(http.StatusBadGateway) {
+		t.Error("built-in 5xx codes should stay retryable alongside configured ones")
+	}
+}
+
+func TestUnlistedStatusNotRetried(t *testing.T) {
+	srv, requests := flakyServer(http.StatusConflict, 2)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"retry_base_delay": 0.01, "retry_max_delay": 0.05}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := getWithRetry(t, config, srv.URL)
+	if resp.StatusCode != http.StatusConflict || atomic.LoadInt32(requests) != 1 {
+		t.Errorf("status %d after %d requests, want 409 after 1", resp.StatusCode, atomic.LoadInt32(requests))
+	}
+}
+
+func TestRetryableStatusCodesValidated(t *testing.T) {
+	for _, cfg := range []string{`{"retryable_status_codes": [42]}`, `{"retryable_status_codes": [200]}`} {
+		if _, err := parseConfig([]byte(cfg)); err == nil {
+			t.Errorf("%s: expected a validation error", cfg)
+		}
+	}
+}
+
+func TestRequestTimeoutEscalatesToCap(t *testing.T) {
+	config, err := parseConfig([]byte(`{"request_timeout": 1, "request_timeout_escalation": 1.5, "max_request_timeout": 3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := newRetryPolicy(config)
+	want := []time.Duration{time.Second, 1500 * time.Millisecond, 2250 * time.Millisecond, 3 * time.Second, 3 * time.Second}
+	for attempt, w := range want {
+		if got := policy.timeout(attempt); got != w {
+			t.Errorf("attempt %d timeout = %v, want %v", attempt, got, w)
+		}
+	}
+}
+
+func TestRequestTimeoutEscalationReachesSlowServer(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		select {
+		case <-time.After(100 * time.Millisecond):
+			w.Write([]byte("{}"))
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	// 40ms, 80ms, then 160ms capped to 150ms: only the third attempt
+	// outlasts the server.
+	config, err := parseConfig([]byte(`{"request_timeout": 0.04, "request_timeout_escalation": 2, "max_request_timeout": 0.15,
+		"retry_base_delay": 0.01, "retry_max_delay": 0.01}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := getWithRetry(t, config, srv.URL)
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&requests) != 3 {
+		t.Errorf("status %d after %d requests, want 200 after 3", resp.StatusCode, atomic.LoadInt32(&requests))
+	}
+
+	// Without escalation every attempt times out.
+	atomic.StoreInt32(&requests, 0)
+	config.TimeoutEscalation = 0
+	_, err = doWithRetry(context.Background(), http.DefaultClient, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, srv.URL, nil)
+	}, newRetryPolicy(config), &RequestStats{})
+	if err == nil {
+		t.Error("expected every attempt to time out without escalation")
+	}
+}
+
+// secondaryLimitServer answers a secondary rate limit 403 to the first
+// limited requests and an empty search result after.
+func secondaryLimitServer(limited int32) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		if atomic.AddInt32(&requests, 1) <= limited {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`))
+			return
+		}
+		w.Write([]byte(`{"total_count": 0, "items": []}`))
+	}))
+	return srv, &requests
+}
+
+func TestSecondaryRateLimitRetried(t *testing.T) {
+	srv, requests := secondaryLimitServer(2)
+	defer srv.Close()
+	config := newPagedSearchConfig(t, srv.URL)
+
+	stats := &RequestStats{}
+	if _, err := searchGitHubQuery(context.Background(), config, "password", "", stats, newTopicCache()); err != nil {
+		t.Fatalf("searchGitHubQuery: %v", err)
+	}
+	if n := atomic.LoadInt32(requests); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+	if stats.RateLimitHits != 2 {
+		t.Errorf("%d rate limit hits, want 2", stats.RateLimitHits)
+	}
+}
+
+func TestSecondaryRateLimitCountedOnce(t *testing.T) {
+	srv, requests := secondaryLimitServer(100)
+	defer srv.Close()
+	config := newPagedSearchConfig(t, srv.URL)
+	config.MaxRetries = 2
+
+	stats := &RequestStats{}
+	if _, err := searchGitHubQuery(context.Background(), config, "password", "", stats, newTopicCache()); err == nil {
+		t.Fatal("expected an error once retries ran out")
+	}
+	n := atomic.LoadInt32(requests)
+	if int32(stats.RateLimitHits) != n {
+		t.Errorf("%d rate limit hits for %d limited responses", stats.RateLimitHits, n)
+	}
+}
+
+func TestComputingResponseRetried(t *testing.T) {
+	srv, requests := flakyServer(http.StatusAccepted, 1)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := getWithRetry(t, config, srv.URL)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200 once the result is computed", resp.StatusCode)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+}
+
+func TestComputingRetriesRespectBudget(t *testing.T) {
+	srv, requests := flakyServer(http.StatusAccepted, 100)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"retry_budget": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp := getWithRetry(t, config, srv.URL)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("status = %d, want the 202 returned", resp.StatusCode)
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("%d requests, want 1: a %v wait exceeds the 1s budget", n, computingRetryDelay)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, past the retry budget", elapsed)
+	}
+}
+
+func TestRetryBudgetStopsRetries(t *testing.T) {
+	srv, requests := flakyServer(http.StatusServiceUnavailable, 100)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"retry_budget": 1, "max_retries": 10, "retry_base_delay": 0.4, "retry_max_delay": 5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp := getWithRetry(t, config, srv.URL)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want the last 503", resp.StatusCode)
+	}
+	// 0.4s after the first attempt fits the budget; the 0.8s after the
+	// second would not, though attempts remain.
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, past the 1s retry budget", elapsed)
+	}
+}
+
+// failOnceTransport fails its first round trip with err and then passes
+// requests to http.DefaultTransport.
+type failOnceTransport struct {
+	err   error
+	calls int32
+}
+
+func (f *failOnceTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if atomic.AddInt32(&f.calls, 1) == 1 {
+		return nil, f.err
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestNetworkErrorsRetried(t *testing.T) {
+	srv, _ := flakyServer(http.StatusOK, 0)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"retry_base_delay": 0.01, "retry_max_delay": 0.05}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		err   error
+		retry bool
+	}{
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"temporary DNS failure", &net.DNSError{Err: "server misbehaving", Name: "api.github.com", IsTemporary: true}, true},
+		{"unknown host", &net.DNSError{Err: "no such host", Name: "api.github.invalid", IsNotFound: true}, false},
+	}
+	for _, c := range cases {
+		transport := &failOnceTransport{err: c.err}
+		resp, err := doWithRetry(context.Background(), &http.Client{Transport: transport}, func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, srv.URL, nil)
+		}, newRetryPolicy(config), &RequestStats{})
+		if c.retry {
+			if err != nil {
+				t.Errorf("%s: %v, want a retry that succeeds", c.name, err)
+				continue
+			}
+			resp.Body.Close()
+			if n := atomic.LoadInt32(&transport.calls); n != 2 {
+				t.Errorf("%s: %d attempts, want 2", c.name, n)
+			}
+		} else if err == nil {
+			resp.Body.Close()
+			t.Errorf("%s: succeeded after a retry, want it to fail at once", c.name)
+		}
+	}
+}
+
+func TestRetriesAndWaitTimeCounted(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"retry_base_delay": 0.02, "retry_max_delay": 0.05}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := &RequestStats{}
+	resp, err := doWithRetry(context.Background(), http.DefaultClient, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, srv.URL, nil)
+	}, newRetryPolicy(config), stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if stats.RetriedRequests != 2 {
+		t.Errorf("RetriedRequests = %d, want 2", stats.RetriedRequests)
+	}
+	if stats.TotalWaitTime <= 0 {
+		t.Errorf("TotalWaitTime = %v, want the backoff waits counted", stats.TotalWaitTime)
+	}
+	meta := newScanMetadata(0, stats)
+	if meta.RetriedRequests != 2 || meta.TotalWaitSeconds != stats.TotalWaitTime.Seconds() {
+		t.Errorf("metadata reports %d retries and %vs waiting", meta.RetriedRequests, meta.TotalWaitSeconds)
+	}
+}
+
+func TestBackoffRespectsBaseAndCap(t *testing.T) {
+	config, err := parseConfig([]byte(`{"retry_base_delay": 0.5, "retry_max_delay": 3, "max_retries": 7}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := newRetryPolicy(config)
+	if policy.maxRetries != 7 {
+		t.Errorf("maxRetries = %d, want 7", policy.maxRetries)
+	}
+	want := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second, 3 * time.Second}
+	for attempt, w := range want {
+		if got := policy.backoff(attempt); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, w)
+		}
+	}
+	if got := policy.backoff(100); got != 3*time.Second {
+		t.Errorf("backoff(100) = %v, want the 3s cap", got)
+	}
+
+	defaults := newRetryPolicy(&Config{})
+	if defaults.backoff(0) != defaultRetryBaseDelay || defaults.backoff(20) != defaultRetryMaxDelay {
+		t.Errorf("default backoff runs from %v to %v", defaults.backoff(0), defaults.backoff(20))
+	}
+}
+
+func TestRetryBaseAboveMaxRejected(t *testing.T) {
+	if _, err := parseConfig([]byte(`{"retry_base_delay": 10, "retry_max_delay": 2}`)); err == nil {
+		t.Error("parseConfig accepted retry_base_delay above retry_max_delay")
+	}
+}
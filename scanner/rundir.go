@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	manifestFileName = "manifest.json"
+	// runDirLayout is time.RFC3339 in UTC with colons, which some file
+	// systems reject, replaced by dashes.
+	runDirLayout = "2006-01-02T15-04-05Z"
+)
+
+// createRunDir creates the --output-dir subfolder for a run started at
+// start, such as 2024-06-01T12-00-00Z. A run started in the same second as
+// an earlier one gets a numeric suffix instead of sharing its folder.
+func createRunDir(base string, start time.Time) (string, error) {
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return "", fmt.Errorf("error creating output directory: %v", err)
+	}
+	name := start.UTC().Format(runDirLayout)
+	dir := filepath.Join(base, name)
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("error creating run directory: %v", err)
+		}
+		dir = filepath.Join(base, fmt.Sprintf("%s-%d", name, i))
+	}
+}
+
+// runManifest describes the contents of a run directory.
+type runManifest struct {
+	ScanID       string         `json:"scan_id,omitempty"`
+	StartedAt    string         `json:"started_at"`
+	FinishedAt   string         `json:"finished_at"`
+	Args         []string       `json:"args"`
+	FindingCount int            `json:"finding_count"`
+	Truncated    bool           `json:"truncated,omitempty"`
+	Files        []manifestFile `json:"files"`
+}
+
+type manifestFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// writeRunManifest writes manifest.json into dir, listing files (paths
+// inside dir) with their sizes and checksums.
+func writeRunManifest(dir string, manifest runManifest, files []string) error {
+	manifest.Files = []manifestFile{}
+	for _, path := range files {
+		entry, err := describeManifestFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s for the manifest: %v", path, err)
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			name = path
+		}
+		entry.Name = filepath.ToSlash(name)
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling run manifest: %v", err)
+	}
+	return writeBytesAtomic(filepath.Join(dir, manifestFileName), data)
+}
+
+// describeManifestFile returns the size and checksum of the file at path,
+// streaming it through the hash so a large output is never held in memory.
+func describeManifestFile(path string) (manifestFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return manifestFile{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return manifestFile{}, err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return manifestFile{}, err
+	}
+	return manifestFile{Size: info.Size(), SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
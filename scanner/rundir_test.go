@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRunDirsDoNotClobber(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "archive")
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	first, err := createRunDir(base, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := createRunDir(base, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(first) != "2024-06-01T10-00-00Z" || filepath.Base(second) != "2024-06-01T10-00-00Z-2" {
+		t.Errorf("run directories %s and %s, want 2024-06-01T10-00-00Z and a -2 suffix", first, second)
+	}
+}
+
+func TestOutputDirWritesSubfolderAndManifest(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "app.env"), []byte("password=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(config, []byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if stdout, stderr, err := runMain(t, dir, "-config", config, "-local-path", src, "-output-dir", "archive"); err != nil {
+			t.Fatalf("run %d failed: %v\n%s%s", i, err, stdout, stderr)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "findings.json")); !os.IsNotExist(err) {
+		t.Error("findings.json written outside the output directory")
+	}
+
+	runDirs, _ := filepath.Glob(filepath.Join(dir, "archive", "*"))
+	if len(runDirs) != 2 {
+		t.Fatalf("got run directories %v, want one per run", runDirs)
+	}
+	nameRe := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}Z(-\d+)?$`)
+	for _, runDir := range runDirs {
+		if !nameRe.MatchString(filepath.Base(runDir)) {
+			t.Errorf("run directory %s is not timestamped", runDir)
+		}
+		data, err := os.ReadFile(filepath.Join(runDir, manifestFileName))
+		if err != nil {
+			t.Fatalf("no manifest: %v", err)
+		}
+		var manifest runManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatal(err)
+		}
+		if manifest.FindingCount != 1 || manifest.StartedAt == "" || manifest.FinishedAt == "" {
+			t.Errorf("manifest %+v, want one finding and the run times", manifest)
+		}
+		listed := make(map[string]bool)
+		for _, f := range manifest.Files {
+			content, err := os.ReadFile(filepath.Join(runDir, filepath.FromSlash(f.Name)))
+			if err != nil {
+				t.Fatalf("manifest lists %s: %v", f.Name, err)
+			}
+			sum := sha256.Sum256(content)
+			if f.Size != int64(len(content)) || f.SHA256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("manifest entry %+v does not match the file", f)
+			}
+			listed[f.Name] = true
+		}
+		if !listed["findings.json"] || !listed[metadataFileName] {
+			t.Errorf("manifest lists %v, want findings.json and %s", listed, metadataFileName)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxRuntimeSavesPartialResults(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		if strings.HasPrefix(r.URL.Query().Get("q"), "slow") {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(30 * time.Second):
+			}
+			return
+		}
+		io.WriteString(w, `{"total_count": 1, "items": [
+			{"path": "app.env", "html_url": "https://github.com/o/r/blob/main/app.env", "repository": {"full_name": "o/r"},
+			 "text_matches": [{"property": "content", "fragment": "password=hunter2", "matches": [{"text": "password", "indices": [0, 8]}]}]}
+		]}`)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(config, []byte(`{"search_patterns": ["password", "slow"], "file_patterns": ["\\.env$"],
+		"max_retries": -1, "hosts": [{"name": "mock", "api_url": "`+srv.URL+`", "token": "t"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	stdout, stderr, err := runMain(t, dir, "-config", config, "-max-runtime", "2s")
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != exitCodeTruncated {
+		t.Fatalf("got %v, want exit status %d\n%s%s", err, exitCodeTruncated, stdout, stderr)
+	}
+	if elapsed := time.Since(start); elapsed > 15*time.Second {
+		t.Errorf("run took %v, want it stopped near the 2s cap", elapsed)
+	}
+	if !strings.Contains(stdout, "saving partial results") {
+		t.Errorf("truncation not reported:\n%s", stdout)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "findings.json"))
+	if err != nil {
+		t.Fatalf("partial results not saved: %v", err)
+	}
+	var findings []Finding
+	if err := json.Unmarshal(data, &findings); err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Pattern != "password" {
+		t.Errorf("saved %+v, want the finding collected before the cap", findings)
+	}
+}
@@ -0,0 +1,213 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service       = "s3"
+	s3DefaultRegion = "us-east-1"
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+)
+
+// s3Target is where --output-s3 uploads the output files: a bucket and
+// key prefix, the endpoint (AWS or an S3-compatible server such as MinIO)
+// and the credentials, read from the standard AWS environment variables.
+type s3Target struct {
+	Bucket string
+	Prefix string
+	// Endpoint is the base URL of an S3-compatible server, addressed
+	// path-style; empty means AWS, addressed virtual-host style.
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// newS3Target parses an s3://bucket/prefix destination and reads the
+// credentials from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN, the region from AWS_REGION or AWS_DEFAULT_REGION and,
+// when endpoint is empty, the endpoint from AWS_ENDPOINT_URL_S3 or
+// AWS_ENDPOINT_URL.
+func newS3Target(dest, endpoint string) (*s3Target, error) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 destination %q, want s3://bucket/prefix", dest)
+	}
+	t := &s3Target{
+		Bucket:       u.Host,
+		Prefix:       strings.Trim(u.Path, "/"),
+		Endpoint:     strings.TrimSuffix(firstNonEmpty(endpoint, os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")), "/"),
+		Region:       firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), s3DefaultRegion),
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if t.AccessKey == "" || t.SecretKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to upload to S3")
+	}
+	if t.Endpoint != "" {
+		if e, err := url.Parse(t.Endpoint); err != nil || (e.Scheme != "http" && e.Scheme != "https") || e.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", t.Endpoint)
+		}
+	}
+	return t, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// objectURL returns the URL of key in the bucket.
+func (t *s3Target) objectURL(key string) string {
+	if t.Endpoint != "" {
+		return t.Endpoint + "/" + t.Bucket + "/" + awsURIEscape(key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", t.Bucket, t.Region, awsURIEscape(key))
+}
+
+// objectKey is the key a local output file is uploaded under: the prefix,
+// the run directory when --output-dir made one, and the file name.
+func (t *s3Target) objectKey(file, runDir string) string {
+	parts := []string{t.Prefix}
+	if runDir != "" {
+		parts = append(parts, filepath.Base(runDir))
+	}
+	return strings.TrimPrefix(path.Join(append(parts, filepath.Base(file))...), "/")
+}
+
+// uploadToS3 uploads each file, stopping at the first failure. The local
+// files are left in place either way. It returns the URLs uploaded to.
+func uploadToS3(ctx context.Context, config *Config, t *s3Target, files []string, runDir string) ([]string, error) {
+	client := &http.Client{Timeout: config.clientTimeout()}
+	var uploaded []string
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return uploaded, fmt.Errorf("error reading %s: %v", file, err)
+		}
+		objectURL := t.objectURL(t.objectKey(file, runDir))
+		resp, err := doWithRetry(ctx, client, func() (*http.Request, error) {
+			return t.newPutRequest(ctx, objectURL, data, time.Now())
+		}, newRetryPolicy(config), &RequestStats{})
+		if err != nil {
+			return uploaded, fmt.Errorf("error uploading %s: %v", file, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return uploaded, fmt.Errorf("error uploading %s: unexpected status code: %d: %s", file, resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		uploaded = append(uploaded, objectURL)
+	}
+	return uploaded, nil
+}
+
+// newPutRequest builds a PUT of data to objectURL signed with AWS
+// Signature Version 4 as of now.
+func (t *s3Target) newPutRequest(ctx context.Context, objectURL string, data []byte, now time.Time) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "PUT", objectURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	contentType := mime.TypeByExtension(path.Ext(req.URL.Path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	payloadHash := sha256.Sum256(data)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	req.Header.Set("X-Amz-Date", now.UTC().Format(amzDateFormat))
+	if t.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", t.SessionToken)
+	}
+	req.Header.Set("Authorization", t.signV4(req, now))
+	return req, nil
+}
+
+// signV4 returns the Authorization header for req, which must already
+// carry its X-Amz-* headers. Only host and the X-Amz-* headers are signed.
+func (t *s3Target) signV4(req *http.Request, now time.Time) string {
+	amzDate := now.UTC().Format(amzDateFormat)
+	date := amzDate[:8]
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		headers["x-amz-content-sha256"],
+	}, "\n")
+	scope := strings.Join([]string{date, t.Region, s3Service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+t.SecretKey), date)
+	for _, part := range []string{t.Region, s3Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", sigV4Algorithm, t.AccessKey, scope, signedHeaders, signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEscape escapes an object key as Signature Version 4 expects:
+// everything but unreserved characters and the / separators.
+func awsURIEscape(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setS3Env(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+}
+
+func TestUploadToS3(t *testing.T) {
+	setS3Env(t)
+	received := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			t.Errorf("got %s, want PUT", r.Method)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			t.Errorf("payload hash header does not match the body")
+		}
+		auth := r.Header.Get("Authorization")
+		date := time.Now().UTC().Format("20060102")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"+date+"/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+			t.Errorf("Authorization = %q", auth)
+		}
+		received[r.URL.Path] = body
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "findings.json")
+	if err := os.WriteFile(file, []byte(`[{"repository": "o/r"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	target, err := newS3Target("s3://scans/nightly/", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded, err := uploadToS3(context.Background(), &Config{}, target, []string{file}, "")
+	if err != nil {
+		t.Fatalf("uploadToS3: %v", err)
+	}
+	if len(uploaded) != 1 || uploaded[0] != srv.URL+"/scans/nightly/findings.json" {
+		t.Errorf("uploaded %v", uploaded)
+	}
+	if got := string(received["/scans/nightly/findings.json"]); got != `[{"repository": "o/r"}]` {
+		t.Errorf("object body %q", got)
+	}
+}
+
+func TestUploadToS3FailureKeepsLocalFile(t *testing.T) {
+	setS3Env(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "findings.csv")
+	if err := os.WriteFile(file, []byte("Repository\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	target, err := newS3Target("s3://scans", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uploadToS3(context.Background(), &Config{}, target, []string{file}, ""); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("got %v, want the AccessDenied error", err)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("local file gone after a failed upload: %v", err)
+	}
+}
+
+func TestNewS3Target(t *testing.T) {
+	setS3Env(t)
+	target, err := newS3Target("s3://bucket/a/b", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := target.objectURL(target.objectKey("out/findings.json", "/tmp/runs/20261014-120000")); got != "https://bucket.s3.eu-west-1.amazonaws.com/a/b/20261014-120000/findings.json" {
+		t.Errorf("objectURL = %s", got)
+	}
+	if _, err := newS3Target("https://bucket/x", ""); err == nil {
+		t.Error("expected an error for a non-s3 destination")
+	}
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := newS3Target("s3://bucket", ""); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
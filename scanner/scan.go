@@ -0,0 +1,198 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// runScan runs the scan mode selected by the config and returns everything
+// found. Per-pattern and per-repository errors are logged and skipped; only
+// errors that would make every later request fail, such as bad credentials,
+// are returned.
+func runScan(ctx context.Context, config *Config, stats *RequestStats) ([]Finding, error) {
+	spool, err := spoolScan(ctx, config, stats)
+	// Spilled findings that cannot be read back are lost, which is
+	// reported like any other non-fatal error.
+	findings, drainErr := spool.drain()
+	if drainErr != nil {
+		config.reportError(drainErr)
+	}
+	return findings, err
+}
+
+// spoolScan is runScan that returns the scan's findings in a spool, which
+// spills them to disk past spill_threshold, rather than in memory.
+func spoolScan(ctx context.Context, config *Config, stats *RequestStats) (*findingSpool, error) {
+	defer config.notifier.flush()
+	ctx, stop := config.limitFindings(ctx)
+	defer stop()
+	spool := newFindingSpool(config.SpillThreshold, config.Log)
+	if config.FetchQueueFile == "" || config.fetchQueue != nil {
+		return spool, scanSources(ctx, config, stats, spool)
+	}
+	q, err := loadFetchQueue(config.FetchQueueFile)
+	if err != nil {
+		return spool, err
+	}
+	config.fetchQueue = q
+	defer q.close()
+	err = scanSources(ctx, config, stats, spool)
+	if err == nil && ctx.Err() == nil {
+		if err := q.remove(); err != nil {
+			config.reportError(err)
+		}
+	}
+	return spool, err
+}
+
+// scanSources is runScan once the fetch queue, if any, is loaded; each host
+// of a multi-host scan runs through it. Findings are collected in spool,
+// the one spool of the whole scan.
+func scanSources(ctx context.Context, config *Config, stats *RequestStats, spool *findingSpool) error {
+	if len(config.Hosts) > 0 {
+		return runHostScans(ctx, config, stats, spool)
+	}
+	defer startRateLimitPoll(ctx, config)()
+
+	if config.scansRepositories() {
+		if err := scanRepositories(ctx, config, stats, spool); err != nil {
+			config.reportError(err)
+		}
+	}
+	if len(config.ScanGists) > 0 {
+		findings, err := scanGists(ctx, config, stats)
+		if err != nil {
+			config.reportError(err)
+		}
+		recordFindingsByPattern(stats, config.SearchPatterns, findings)
+		config.collect(spool, findings)
+	}
+	if config.scansRepositories() || len(config.ScanGists) > 0 {
+		return nil
+	}
+
+	topics := newTopicCache()
+	deadline, _ := ctx.Deadline()
+	progress := newScanProgress(len(config.SearchPatterns), deadline, config.Log)
+	for _, pattern := range prioritizePatterns(config) {
+		if config.findingCapReached() {
+			break
+		}
+		if ctx.Err() != nil {
+			config.logf("\nScan timeout reached, remaining patterns skipped!\n")
+			break
+		}
+
+		config.logf("\nSearching for: %s\n", pattern)
+		findings, err := searchPattern(ctx, config, pattern, stats, topics)
+		if errors.Is(err, errUnauthorized) {
+			return err
+		}
+		progress.complete(time.Now())
+		if err != nil {
+			config.reportError(err)
+			continue
+		}
+		stats.RecordPatternFindings(pattern, len(findings))
+		config.collect(spool, findings)
+	}
+	return nil
+}
+
+// prioritizePatterns returns the search patterns ordered by severity, most
+// severe first, so a scan cut short by its timeout has already covered the
+// patterns that matter most. Patterns of equal severity keep their
+// configured (or, in deterministic mode, sorted) order.
+func prioritizePatterns(config *Config) []string {
+	patterns := append([]string(nil), config.SearchPatterns...)
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return severityRank(config.severityFor(patterns[i])) > severityRank(config.severityFor(patterns[j]))
+	})
+	return patterns
+}
+
+// searchPattern runs code search for a single pattern, bounded by
+// per_pattern_timeout so one slow pattern cannot starve the rest.
+func searchPattern(ctx context.Context, config *Config, pattern string, stats *RequestStats, topics *topicCache) ([]Finding, error) {
+	patternCtx, patternSpan := startSpan(ctx, "pattern")
+	patternSpan.setAttribute("pattern", pattern)
+	defer patternSpan.finish()
+
+	if config.PerPatternTimeout > 0 {
+		var cancel context.CancelFunc
+		patternCtx, cancel = context.WithTimeout(patternCtx, time.Duration(config.PerPatternTimeout)*time.Second)
+		defer cancel()
+	}
+
+	var key string
+	if config.fetchQueue != nil {
+		key = fetchQueueKey(config, pattern)
+		if n, ok := config.fetchQueue.pending(key); ok {
+			config.logf("Resuming %s from the fetch queue (%d fetch(es) pending)\n", pattern, n)
+			findings, err := fetchPending(patternCtx, config, key, stats)
+			patternSpan.setAttribute("findings", len(findings))
+			patternSpan.recordError(err)
+			return findings, err
+		}
+	}
+
+	findings, err := searchGitHub(patternCtx, config, pattern, stats, topics)
+	if ctx.Err() == nil && patternCtx.Err() == context.DeadlineExceeded {
+		config.logf("Pattern %s exceeded per_pattern_timeout of %ds, results truncated\n", pattern, config.PerPatternTimeout)
+		patternSpan.setAttribute("truncated", true)
+	}
+	// Only a search that ran to the end is queued; a truncated one is
+	// searched again on resume.
+	if config.fetchQueue != nil && err == nil && patternCtx.Err() == nil {
+		if err = config.fetchQueue.enqueue(key, findings); err == nil {
+			findings, err = fetchPending(patternCtx, config, key, stats)
+		}
+	}
+	patternSpan.setAttribute("findings", len(findings))
+	patternSpan.recordError(err)
+	return findings, err
+}
+
+// recordFindingsByPattern attributes findings from direct scans, which match
+// every pattern at once, to the pattern each one came from. Every pattern
+// counts as scanned, including those that found nothing.
+func recordFindingsByPattern(stats *RequestStats, patterns []string, findings []Finding) {
+	for _, p := range patterns {
+		stats.RecordPatternFindings(p, 0)
+	}
+	for _, f := range findings {
+		stats.RecordPatternFindings(f.Pattern, 1)
+	}
+}
+
+// collect tags findings with the config's host, if any, emits them and adds
+// them to the scan's spool.
+func (c *Config) collect(spool *findingSpool, findings []Finding) {
+	if c.host != "" {
+		for i := range findings {
+			findings[i].Host = c.host
+		}
+	}
+	c.emitFindings(findings)
+	spool.add(findings)
+}
+
+// emitFindings passes findings to the notifier and the onFindings
+// callback, if any.
+func (c *Config) emitFindings(findings []Finding) {
+	c.notifier.add(findings)
+	if c.onFindings != nil && len(findings) > 0 {
+		c.onFindings(findings)
+	}
+}
+
+// reportError logs a non-fatal error and passes it to the onError
+// callback, if any.
+func (c *Config) reportError(err error) {
+	c.logf("Error: %v\n", err)
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
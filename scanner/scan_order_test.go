@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHigherSeverityPatternsScannedFirst(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, strings.Fields(r.URL.Query().Get("q"))[0])
+		mu.Unlock()
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		w.Write([]byte(`{"total_count": 0, "items": []}`))
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["hostname", "password", "zone", "db_url", "api_token"],
+		"file_patterns": ["\\.env$"], "severity_overrides": {"db_url": "CRITICAL", "zone": "LOW"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	if _, err := runScan(context.Background(), config, &RequestStats{}); err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	// CRITICAL, then both HIGH and the MEDIUM pattern in configured order,
+	// then LOW.
+	want := []string{"db_url", "password", "api_token", "hostname", "zone"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("patterns searched in order %v, want %v", order, want)
+	}
+}
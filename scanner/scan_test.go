@@ -0,0 +1,72 @@
+package scanner_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brettsky/github-security-scanner/scanner"
+)
+
+// TestScanStreamsFindingsAndErrors uses the package the way an embedding
+// service would: from outside, with a config pointing at a mocked host.
+func TestScanStreamsFindingsAndErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		if strings.HasPrefix(r.URL.Query().Get("q"), "broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, `{"total_count": 2, "items": [
+			{"path": "a.env", "html_url": "https://github.com/o/a/blob/main/a.env", "repository": {"full_name": "o/a"},
+			 "text_matches": [{"property": "content", "fragment": "password=hunter2", "matches": [{"text": "password", "indices": [0, 8]}]}]},
+			{"path": "b.env", "html_url": "https://github.com/o/b/blob/main/b.env", "repository": {"full_name": "o/b"},
+			 "text_matches": [{"property": "content", "fragment": "password=s3cr3t", "matches": [{"text": "password", "indices": [0, 8]}]}]}
+		]}`)
+	}))
+	defer srv.Close()
+
+	config, err := scanner.ParseConfig([]byte(`{"search_patterns": ["password", "broken"], "file_patterns": ["\\.env$"],
+		"max_retries": -1, "hosts": [{"name": "mock", "api_url": "` + srv.URL + `", "token": "t"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Log = io.Discard
+
+	findingsCh, errsCh := scanner.Scan(context.Background(), config)
+	var findings []scanner.Finding
+	var errs []error
+	for findingsCh != nil || errsCh != nil {
+		select {
+		case f, ok := <-findingsCh:
+			if !ok {
+				findingsCh = nil
+				continue
+			}
+			findings = append(findings, f)
+		case err, ok := <-errsCh:
+			if !ok {
+				errsCh = nil
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	if len(findings) != 2 {
+		t.Fatalf("received %d findings, want 2", len(findings))
+	}
+	for _, f := range findings {
+		if f.Severity != "HIGH" || f.Fingerprint == "" || f.Host != "mock" {
+			t.Errorf("finding %+v was not classified, fingerprinted and tagged with its host", f)
+		}
+	}
+	if len(errs) != 1 {
+		t.Errorf("received errors %v, want one for the broken pattern", errs)
+	}
+}
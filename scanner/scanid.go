@@ -0,0 +1,26 @@
+package scanner
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// newScanID returns a random UUID (version 4) identifying one run, falling
+// back to a timestamp if the system random source fails.
+func newScanID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("scan-%d", time.Now().UnixNano())
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// tagScanID stamps every finding with the run's scan ID.
+func tagScanID(findings []Finding, scanID string) {
+	for i := range findings {
+		findings[i].ScanID = scanID
+	}
+}
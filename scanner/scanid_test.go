@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanIDAcrossOutputs(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "app.env"), []byte("password=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(config, []byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runMain(t, dir, "-config", config, "-local-path", src, "-scan-id", "run-42",
+		"-output-dir", "out", "-post-hook", `cat > hook.json && echo "$SCANNER_SCAN_ID" > hook.id`)
+	if err != nil {
+		t.Fatalf("scan failed: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "Scan ID: run-42") {
+		t.Errorf("log does not print the scan ID:\n%s", stdout)
+	}
+
+	runDirs, _ := filepath.Glob(filepath.Join(dir, "out", "*"))
+	if len(runDirs) != 1 {
+		t.Fatalf("got run directories %v, want one", runDirs)
+	}
+	var findings, hookFindings []Finding
+	var meta scanMetadata
+	var manifest runManifest
+	for path, v := range map[string]interface{}{
+		filepath.Join(runDirs[0], "findings.json"):  &findings,
+		filepath.Join(runDirs[0], metadataFileName): &meta,
+		filepath.Join(runDirs[0], manifestFileName): &manifest,
+		filepath.Join(dir, "hook.json"):             &hookFindings,
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+	if len(findings) != 1 || findings[0].ScanID != "run-42" {
+		t.Errorf("findings.json %+v, want one finding with the scan ID", findings)
+	}
+	if len(hookFindings) != 1 || hookFindings[0].ScanID != "run-42" {
+		t.Errorf("post-hook received %+v, want the finding with the scan ID", hookFindings)
+	}
+	if meta.ScanID != "run-42" || manifest.ScanID != "run-42" {
+		t.Errorf("metadata scan ID %q, manifest scan ID %q, want run-42", meta.ScanID, manifest.ScanID)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "hook.id")); strings.TrimSpace(string(data)) != "run-42" {
+		t.Errorf("SCANNER_SCAN_ID was %q", data)
+	}
+}
@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// repoScope is the classic token scope code search needs to see private
+// repositories.
+const repoScope = "repo"
+
+// wantsPrivateRepos reports whether the config intends to reach private
+// repositories: private_repos is set or scan_org selects private or
+// internal repositories.
+func (c *Config) wantsPrivateRepos() bool {
+	if c.PrivateRepos {
+		return true
+	}
+	if org := c.ScanOrg; org != nil {
+		return org.Type == "private" || org.Visibility == "private" || org.Visibility == "internal"
+	}
+	return false
+}
+
+// scopeWarning returns the warning for a token whose X-OAuth-Scopes header
+// is scopes, or "" when the repo scope is granted. Fine-grained and GitHub
+// App tokens send no scopes header, so their access cannot be checked here.
+func scopeWarning(scopes string, present bool) string {
+	if !present {
+		return "Token scopes unknown (fine-grained or app token); make sure it can read the private repositories to scan"
+	}
+	for _, s := range strings.Split(scopes, ",") {
+		if strings.TrimSpace(s) == repoScope {
+			return ""
+		}
+	}
+	if strings.TrimSpace(scopes) == "" {
+		scopes = "none"
+	}
+	return fmt.Sprintf("Warning: token lacks the %q scope (has: %s); private repositories will be missing from results", repoScope, scopes)
+}
+
+// checkTokenScopes reads the token's scopes from /rate_limit, which does
+// not count against the rate limit, and prints a warning when private
+// repositories are wanted but the token cannot search them.
+func checkTokenScopes(ctx context.Context, config *Config, stats *RequestStats) error {
+	url := config.apiURL() + "/rate_limit"
+	resp, err := doWithRetry(ctx, newHTTPClient(config), func() (*http.Request, error) {
+		return newGitHubRequest(ctx, config, url)
+	}, newRetryPolicy(config), stats)
+	if err != nil {
+		return fmt.Errorf("error checking token scopes: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		stats.IncrementFailed()
+		return errUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		stats.IncrementFailed()
+		return fmt.Errorf("error checking token scopes: unexpected status code: %d", resp.StatusCode)
+	}
+	stats.IncrementSuccess()
+
+	scopes, present := resp.Header["X-Oauth-Scopes"]
+	if warning := scopeWarning(strings.Join(scopes, ","), present); warning != "" {
+		config.logf("%s\n", warning)
+	}
+	return nil
+}
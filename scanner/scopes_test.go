@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// scopesServer answers /rate_limit with the given X-OAuth-Scopes header,
+// or none when scopes is nil.
+func scopesServer(t *testing.T, scopes *string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rate_limit" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		if scopes != nil {
+			w.Header().Set("X-OAuth-Scopes", *scopes)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestScopeWarningForTokenWithoutRepo(t *testing.T) {
+	cases := []struct {
+		name    string
+		scopes  *string
+		warning string
+	}{
+		{"missing repo", strPtr("read:org, gist"), `lacks the "repo" scope (has: read:org, gist)`},
+		{"no scopes", strPtr(""), "(has: none)"},
+		{"fine-grained", nil, "Token scopes unknown"},
+		{"repo granted", strPtr("read:org, repo"), ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := scopesServer(t, c.scopes)
+			config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "private_repos": true}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			config.apiBase = srv.URL
+			var log strings.Builder
+			config.Log = &log
+
+			if err := checkTokenScopes(context.Background(), config, &RequestStats{}); err != nil {
+				t.Fatalf("checkTokenScopes: %v", err)
+			}
+			if c.warning == "" {
+				if log.Len() > 0 {
+					t.Errorf("warned for a token with repo scope: %s", log.String())
+				}
+			} else if !strings.Contains(log.String(), c.warning) {
+				t.Errorf("log %q does not contain %q", log.String(), c.warning)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string { return &s }
@@ -0,0 +1,47 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSelfTestProducesFixtureFindings(t *testing.T) {
+	config, err := loadSelfTestConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := runScan(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	keys, _ := parseSortKeys(defaultSortBy)
+	sortFindings(findings, keys)
+	if err := verifySelfTest(findings); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := saveFindings(findings, outputOptions{format: "json", dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "findings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written []Finding
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifySelfTest(written); err != nil {
+		t.Errorf("findings.json: %v", err)
+	}
+}
+
+func TestSelfTestDetectsMissingFinding(t *testing.T) {
+	if err := verifySelfTest(selfTestExpected[1:]); err == nil {
+		t.Error("verifySelfTest accepted a missing finding")
+	}
+}
@@ -0,0 +1,202 @@
+package scanner
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxConcurrentScans = 1
+	serveRetryAfterSeconds    = 30
+	defaultServeScanTimeout   = 60 * time.Second
+)
+
+// scanServer exposes scans over HTTP to clients presenting the serve_token
+// as a bearer token. At most maxScans run at once; further requests are
+// either rejected immediately with 429 or, in queue mode, wait up to
+// queueTimeout for a slot before being rejected.
+type scanServer struct {
+	config       *Config
+	token        string
+	slots        chan struct{}
+	queue        bool
+	queueTimeout time.Duration
+	scanTimeout  time.Duration
+	// stateMu serializes scans that keep state in files, the incremental
+	// state file and the fetch queue, which concurrent scans would
+	// overwrite.
+	stateMu sync.Mutex
+	scan    func(ctx context.Context, config *Config, stats *RequestStats) ([]Finding, error)
+}
+
+func newScanServer(config *Config) *scanServer {
+	maxScans := config.MaxConcurrentScans
+	if maxScans <= 0 {
+		maxScans = defaultMaxConcurrentScans
+	}
+	scanTimeout := time.Duration(config.ServeScanTimeout) * time.Second
+	if scanTimeout <= 0 {
+		scanTimeout = defaultServeScanTimeout
+	}
+	return &scanServer{
+		config:       config,
+		token:        config.ServeToken,
+		slots:        make(chan struct{}, maxScans),
+		queue:        config.ScanQueueMode == "queue",
+		queueTimeout: time.Duration(config.ScanQueueTimeout) * time.Second,
+		scanTimeout:  scanTimeout,
+		scan:         runScan,
+	}
+}
+
+// authorized reports whether r carries the server's bearer token.
+func (s *scanServer) authorized(r *http.Request) bool {
+	got := []byte(r.Header.Get("Authorization"))
+	want := []byte("Bearer " + s.token)
+	return s.token != "" && subtle.ConstantTimeCompare(got, want) == 1
+}
+
+func (s *scanServer) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scan", s.handleScan)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// acquire takes a scan slot, waiting only in queue mode.
+func (s *scanServer) acquire(ctx context.Context) bool {
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if !s.queue {
+		return false
+	}
+
+	if s.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.queueTimeout)
+		defer cancel()
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *scanServer) release() {
+	<-s.slots
+}
+
+type scanRequest struct {
+	SearchPatterns []string `json:"search_patterns"`
+}
+
+type scanResponse struct {
+	Findings []Finding     `json:"findings"`
+	Stats    *scanMetadata `json:"stats"`
+	Error    string        `json:"error,omitempty"`
+}
+
+func (s *scanServer) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req scanRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The request's patterns replace search_patterns and are expanded,
+	// grouped and checked as the config's own were.
+	config := *s.config
+	if len(req.SearchPatterns) > 0 {
+		config.SearchPatterns = req.SearchPatterns
+		if err := config.initPatterns(); err != nil {
+			http.Error(w, fmt.Sprintf("invalid search_patterns: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !s.acquire(r.Context()) {
+		w.Header().Set("Retry-After", strconv.Itoa(serveRetryAfterSeconds))
+		http.Error(w, "too many concurrent scans", http.StatusTooManyRequests)
+		return
+	}
+	defer s.release()
+
+	// Each scan gets its own first-hit and notifier state; the config
+	// was validated at startup, so newNotifier cannot fail here.
+	config.repoHits = newRepoHits()
+	config.notifier, _ = newNotifier(&config)
+	if config.Incremental || config.FetchQueueFile != "" {
+		s.stateMu.Lock()
+		defer s.stateMu.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.scanTimeout)
+	defer cancel()
+
+	stats := &RequestStats{}
+	findings, err := s.scan(ctx, &config, stats)
+	if findings == nil {
+		findings = []Finding{}
+	}
+	if config.NormalizeURLs {
+		normalizeFindingURLs(findings)
+	}
+	scanID := newScanID()
+	tagScanID(findings, scanID)
+	tagPatternGroups(&config, findings)
+	classifyFindings(&config, findings)
+	assignFingerprints(findings)
+	resp := scanResponse{Findings: findings, Stats: newScanMetadata(len(findings), stats)}
+	resp.Stats.ScanID = scanID
+	status := http.StatusOK
+	if err != nil {
+		resp.Error = err.Error()
+		status = http.StatusBadGateway
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serve runs the scan server until it fails. It refuses to start without
+// a serve_token, since every scan spends the configured GitHub tokens.
+func serve(addr string, config *Config) error {
+	if config.ServeToken == "" {
+		return fmt.Errorf("serve_token is required to serve scans")
+	}
+	s := newScanServer(config)
+	mode := "reject"
+	if s.queue {
+		mode = "queue"
+	}
+	fmt.Printf("Serving scans on %s (max %d concurrent, %s when busy)\n", addr, cap(s.slots), mode)
+	return http.ListenAndServe(addr, s.routes())
+}
@@ -0,0 +1,187 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestScanServer(t *testing.T, extra string) *scanServer {
+	t.Helper()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "serve_token": "s3cret"` + extra + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newScanServer(config)
+}
+
+func postScan(t *testing.T, url, token string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+"/scan", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestServeRejectsScansBeyondLimit(t *testing.T) {
+	s := newTestScanServer(t, `, "max_concurrent_scans": 2`)
+	started := make(chan struct{})
+	done := make(chan struct{})
+	s.scan = func(ctx context.Context, config *Config, stats *RequestStats) ([]Finding, error) {
+		started <- struct{}{}
+		<-done
+		return nil, nil
+	}
+	srv := httptest.NewServer(s.routes())
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			postScan(t, srv.URL, "s3cret")
+		}()
+		<-started
+	}
+	resp := postScan(t, srv.URL, "s3cret")
+	close(done)
+	wg.Wait()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("third scan got %d, want 429", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("429 without Retry-After")
+	}
+}
+
+func TestServeRequiresBearerToken(t *testing.T) {
+	s := newTestScanServer(t, "")
+	s.scan = func(ctx context.Context, config *Config, stats *RequestStats) ([]Finding, error) {
+		return nil, nil
+	}
+	srv := httptest.NewServer(s.routes())
+	defer srv.Close()
+
+	for _, token := range []string{"", "wrong"} {
+		if resp := postScan(t, srv.URL, token); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("token %q got %d, want 401", token, resp.StatusCode)
+		}
+	}
+	if resp := postScan(t, srv.URL, "s3cret"); resp.StatusCode != http.StatusOK {
+		t.Errorf("valid token got %d, want 200", resp.StatusCode)
+	}
+	if err := serve("127.0.0.1:0", &Config{}); err == nil || !strings.Contains(err.Error(), "serve_token") {
+		t.Errorf("serve without serve_token returned %v", err)
+	}
+}
+
+func TestServeUsesConfiguredScanTimeout(t *testing.T) {
+	s := newTestScanServer(t, `, "serve_scan_timeout": 5`)
+	var remaining time.Duration
+	s.scan = func(ctx context.Context, config *Config, stats *RequestStats) ([]Finding, error) {
+		deadline, _ := ctx.Deadline()
+		remaining = time.Until(deadline)
+		return nil, nil
+	}
+	srv := httptest.NewServer(s.routes())
+	defer srv.Close()
+
+	postScan(t, srv.URL, "s3cret")
+	if remaining <= 0 || remaining > 5*time.Second {
+		t.Errorf("scan deadline %v away, want within serve_scan_timeout of 5s", remaining)
+	}
+}
+
+func TestServeSerializesStatefulScans(t *testing.T) {
+	s := newTestScanServer(t, `, "max_concurrent_scans": 3, "fetch_queue_file": "`+t.TempDir()+`/queue.json"`)
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	s.scan = func(ctx context.Context, config *Config, stats *RequestStats) ([]Finding, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return nil, nil
+	}
+	srv := httptest.NewServer(s.routes())
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			postScan(t, srv.URL, "s3cret")
+		}()
+	}
+	wg.Wait()
+	if maxInFlight != 1 {
+		t.Errorf("%d stateful scans ran at once, want 1", maxInFlight)
+	}
+}
+
+func TestScanQueueModeValidated(t *testing.T) {
+	if _, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "scan_queue_mode": "queued"}`)); err == nil {
+		t.Error("scan_queue_mode queued was accepted")
+	}
+}
+
+func TestServeChecksRequestPatterns(t *testing.T) {
+	s := newTestScanServer(t, `, "strict_severity": true, "case_insensitive": true, "expand_patterns": ["api_key"]`)
+	var scanned []string
+	s.scan = func(ctx context.Context, config *Config, stats *RequestStats) ([]Finding, error) {
+		scanned = config.SearchPatterns
+		return nil, nil
+	}
+	srv := httptest.NewServer(s.routes())
+	defer srv.Close()
+
+	post := func(body string) int {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/scan", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer s3cret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := post(`{"search_patterns": ["hostname"]}`); status != http.StatusBadRequest {
+		t.Errorf("unclassified pattern under strict_severity got %d, want 400", status)
+	}
+	if scanned != nil {
+		t.Errorf("rejected request scanned %v", scanned)
+	}
+	if status := post(`{"search_patterns": ["secret", "secret"]}`); status != http.StatusOK {
+		t.Fatalf("valid patterns got %d, want 200", status)
+	}
+	if got := strings.Join(scanned, ","); !strings.HasPrefix(got, "secret,api_key,") || strings.Count(got, "secret") != 1 {
+		t.Errorf("scanned %s, want secret once followed by the api_key variants", got)
+	}
+	if got := strings.Join(s.config.SearchPatterns, ","); !strings.HasPrefix(got, "password,") {
+		t.Errorf("request patterns leaked into the server config: %s", got)
+	}
+}
@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup lets concurrent callers asking for the same key share one
+// in-flight call, in the manner of golang.org/x/sync/singleflight, which
+// this tree does not vendor. Results are not cached: once a call returns,
+// the next caller for the key starts a new one.
+//
+// The shared call runs on a context detached from whichever caller started
+// it, so one caller giving up does not fail the others; it is cancelled
+// only once every caller waiting on it has gone.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	val     []byte
+	err     error
+	dups    int
+	waiters int
+}
+
+// do runs fn for key unless a call for key is already in flight, in which
+// case it waits for that call and returns its result. If ctx ends first do
+// returns ctx's error; the call goes on for the remaining waiters. shared
+// reports whether the result went to more than one caller. Callers must
+// not modify the returned slice.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) (val []byte, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	c, ok := g.calls[key]
+	if ok {
+		c.dups++
+		c.waiters++
+	} else {
+		// WithoutCancel keeps the caller's values, such as its tracer.
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &flightCall{done: make(chan struct{}), cancel: cancel, waiters: 1}
+		g.calls[key] = c
+		go func() {
+			c.val, c.err = fn(callCtx)
+			cancel()
+			g.mu.Lock()
+			g.forgetLocked(key, c)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		g.mu.Lock()
+		shared = c.dups > 0
+		g.mu.Unlock()
+		return c.val, c.err, shared
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			// A caller arriving now starts a new call instead of
+			// joining the cancelled one.
+			g.forgetLocked(key, c)
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err(), false
+	}
+}
+
+func (g *flightGroup) forgetLocked(key string, c *flightCall) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
+
+// contentFetches deduplicates concurrent downloads of the same file.
+var contentFetches flightGroup
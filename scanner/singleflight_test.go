@@ -0,0 +1,137 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForWaiters polls until n callers wait on key's call.
+func waitForWaiters(t *testing.T, g *flightGroup, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		c := g.calls[key]
+		joined := c != nil && c.waiters == n
+		g.mu.Unlock()
+		if joined {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d callers never joined the call for %s", n, key)
+}
+
+func TestConcurrentContentFetchesShareOneRequest(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		fmt.Fprint(w, "password=hunter2\n")
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	client := newHTTPClient(config)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	contents := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			content, err := fetchFileContent(context.Background(), client, config, "o/r", "shared.env", "main", &RequestStats{})
+			if err != nil {
+				t.Error(err)
+			}
+			contents[i] = string(content)
+		}(i)
+	}
+	waitForWaiters(t, &contentFetches, srv.URL+"/repos/o/r/contents/shared.env?ref=main", callers)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("%d upstream requests, want 1", n)
+	}
+	for i, c := range contents {
+		if c != "password=hunter2\n" {
+			t.Errorf("caller %d got %q", i, c)
+		}
+	}
+}
+
+func TestSharedCallOutlivesCancelledCaller(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	fn := func(ctx context.Context) ([]byte, error) {
+		select {
+		case <-release:
+			return []byte("ok"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err, _ := g.do(firstCtx, "k", fn)
+		firstErr <- err
+	}()
+	waitForWaiters(t, &g, "k", 1)
+	second := make(chan []byte, 1)
+	go func() {
+		val, _, _ := g.do(context.Background(), "k", fn)
+		second <- val
+	}()
+	waitForWaiters(t, &g, "k", 2)
+
+	cancelFirst()
+	if err := <-firstErr; err != context.Canceled {
+		t.Errorf("cancelled caller got %v, want context.Canceled", err)
+	}
+	close(release)
+	if val := <-second; string(val) != "ok" {
+		t.Errorf("remaining caller got %q, want the shared result", val)
+	}
+}
+
+func TestSharedCallCancelledWhenEveryCallerLeaves(t *testing.T) {
+	var g flightGroup
+	stopped := make(chan struct{})
+	fn := func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		close(stopped)
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.do(ctx, "k", fn)
+		}()
+	}
+	waitForWaiters(t, &g, "k", 2)
+	cancel()
+	wg.Wait()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shared call kept running after every caller left")
+	}
+}
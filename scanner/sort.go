@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const defaultSortBy = "severity,repository,path"
+
+// severityRank orders severities from most to least urgent. Unknown
+// severities sort last.
+func severityRank(severity string) int {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return 4
+	case "HIGH":
+		return 3
+	case "MEDIUM":
+		return 2
+	case "LOW":
+		return 1
+	case "INFO":
+		return 0
+	default:
+		return -1
+	}
+}
+
+// severityLevels lists the known severities, most urgent first.
+var severityLevels = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO"}
+
+// countBySeverity counts findings per upper-cased severity.
+func countBySeverity(findings []Finding) map[string]int {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[strings.ToUpper(f.Severity)]++
+	}
+	return counts
+}
+
+// parseSortKeys validates a comma-separated --sort-by value. "none" keeps
+// findings in discovery order.
+func parseSortKeys(value string) ([]string, error) {
+	if value == "none" {
+		return nil, nil
+	}
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		switch key {
+		case "severity", "repository", "path":
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("unsupported sort key: %s", key)
+		}
+	}
+	return keys, nil
+}
+
+// compareFindings orders two findings by a single key, returning a negative
+// number when a sorts first. Severity sorts most urgent first.
+func compareFindings(a, b Finding, key string) int {
+	switch key {
+	case "severity":
+		return severityRank(b.Severity) - severityRank(a.Severity)
+	case "repository":
+		return strings.Compare(a.Repository, b.Repository)
+	case "path":
+		return strings.Compare(a.FilePath, b.FilePath)
+	}
+	return 0
+}
+
+// sortFindings sorts findings in place by the given keys. Remaining ties are
+// broken on line, pattern, ref and URL so the order never depends on the
+// order findings were discovered in.
+func sortFindings(findings []Finding, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	less := findingLess(keys)
+	sort.SliceStable(findings, func(i, j int) bool {
+		return less(findings[i], findings[j])
+	})
+}
+
+// findingLess is the order sortFindings sorts by, for sorting findings
+// that are not all in one slice.
+func findingLess(keys []string) func(a, b Finding) bool {
+	return func(a, b Finding) bool {
+		for _, key := range keys {
+			if c := compareFindings(a, b, key); c != 0 {
+				return c < 0
+			}
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Pattern != b.Pattern {
+			return a.Pattern < b.Pattern
+		}
+		if a.Ref != b.Ref {
+			return a.Ref < b.Ref
+		}
+		return a.URL < b.URL
+	}
+}
@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultSortOrder(t *testing.T) {
+	findings := []Finding{
+		{Severity: "LOW", Repository: "o/a", FilePath: "a.env"},
+		{Severity: "HIGH", Repository: "o/b", FilePath: "b.env"},
+		{Severity: "HIGH", Repository: "o/a", FilePath: "z.env"},
+		{Severity: "unknown", Repository: "o/a", FilePath: "a.env"},
+		{Severity: "CRITICAL", Repository: "o/z", FilePath: "a.env"},
+		{Severity: "HIGH", Repository: "o/a", FilePath: "c.env", Line: 9},
+		{Severity: "HIGH", Repository: "o/a", FilePath: "c.env", Line: 2},
+	}
+	keys, err := parseSortKeys(defaultSortBy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortFindings(findings, keys)
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Severity+" "+f.Repository+"/"+f.FilePath)
+	}
+	want := []string{
+		"CRITICAL o/z/a.env",
+		"HIGH o/a/c.env",
+		"HIGH o/a/c.env",
+		"HIGH o/a/z.env",
+		"HIGH o/b/b.env",
+		"LOW o/a/a.env",
+		"unknown o/a/a.env",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order %q, want %q", got, want)
+	}
+	if findings[1].Line != 2 || findings[2].Line != 9 {
+		t.Errorf("ties on path are in line order %d, %d, want 2, 9", findings[1].Line, findings[2].Line)
+	}
+}
+
+func TestSortByPathIgnoresDiscoveryOrder(t *testing.T) {
+	a := []Finding{{FilePath: "b.env", Pattern: "x"}, {FilePath: "a.env"}, {FilePath: "b.env", Pattern: "w"}}
+	b := []Finding{a[2], a[0], a[1]}
+	sortFindings(a, []string{"path"})
+	sortFindings(b, []string{"path"})
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("sorted %v and %v differ", a, b)
+	}
+}
+
+func TestInvalidSortKey(t *testing.T) {
+	if _, err := parseSortKeys("severity,size"); err == nil {
+		t.Error("parseSortKeys accepted an unknown key")
+	}
+	if keys, err := parseSortKeys("none"); err != nil || keys != nil {
+		t.Errorf("none gave %v, %v, want no keys", keys, err)
+	}
+}
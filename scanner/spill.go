@@ -0,0 +1,345 @@
+package scanner
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// findingSpool collects a scan's findings, holding at most limit of them in
+// memory: when the limit is reached they are appended to a temporary JSON
+// lines file and memory is released. One spool collects every finding of a
+// scan, and post-processing and output read it back one finding or one
+// batch at a time, so memory stays bounded from the first request to the
+// last byte of the findings file. A limit of 0 never spills.
+type findingSpool struct {
+	limit int
+	log   io.Writer
+	// quiet skips the spilling notice, for the spools sort and rewrite
+	// build from one that already spilled.
+	quiet bool
+
+	mu      sync.Mutex
+	mem     []Finding
+	file    *os.File
+	enc     *json.Encoder
+	spilled int
+}
+
+func newFindingSpool(limit int, log io.Writer) *findingSpool {
+	return &findingSpool{limit: limit, log: log}
+}
+
+// add appends findings, spilling to disk when the memory limit is reached.
+// If the spill file cannot be written the findings stay in memory. It is
+// safe for concurrent use, but not while the spool is being read.
+func (s *findingSpool) add(findings []Finding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem = append(s.mem, findings...)
+	if s.limit <= 0 || len(s.mem) < s.limit {
+		return
+	}
+	if err := s.spill(); err != nil {
+		fmt.Fprintf(logWriter(s.log), "Warning: could not spill findings to disk, keeping them in memory: %v\n", err)
+		s.limit = 0
+	}
+}
+
+func (s *findingSpool) spill() error {
+	if s.file == nil {
+		file, err := ioutil.TempFile("", "findings-spill-*.jsonl")
+		if err != nil {
+			return err
+		}
+		// The file is read through its descriptor, so its name can go at
+		// once where the platform allows it and a run that exits early
+		// leaves nothing behind; close removes it otherwise.
+		os.Remove(file.Name())
+		s.file, s.enc = file, json.NewEncoder(file)
+		if !s.quiet {
+			fmt.Fprintf(logWriter(s.log), "Holding more than %d findings, spilling to %s\n", s.limit, file.Name())
+		}
+	}
+	for _, f := range s.mem {
+		if err := s.enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	s.spilled += len(s.mem)
+	s.mem = nil
+	return nil
+}
+
+// len returns the number of findings added.
+func (s *findingSpool) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.spilled + len(s.mem)
+}
+
+// spoolReader reads a spool's findings back one at a time, spilled ones
+// first, in the order they were added.
+type spoolReader struct {
+	dec     *json.Decoder
+	read    int
+	spilled int
+	mem     []Finding
+}
+
+// reader returns a reader over the findings added so far. Readers read
+// the spill file at their own offset, so several can be open at once.
+func (s *findingSpool) reader() *spoolReader {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := &spoolReader{spilled: s.spilled, mem: s.mem}
+	if s.file != nil {
+		r.dec = json.NewDecoder(io.NewSectionReader(s.file, 0, math.MaxInt64))
+	}
+	return r
+}
+
+// next returns the next finding, or false once every finding was read.
+func (r *spoolReader) next() (Finding, bool, error) {
+	if r.read < r.spilled {
+		var f Finding
+		if err := r.dec.Decode(&f); err != nil {
+			return f, false, fmt.Errorf("error reading spilled findings: read %d of %d: %v", r.read, r.spilled, err)
+		}
+		r.read++
+		return f, true, nil
+	}
+	if len(r.mem) == 0 {
+		return Finding{}, false, nil
+	}
+	f := r.mem[0]
+	r.mem = r.mem[1:]
+	return f, true, nil
+}
+
+// each calls fn with every finding, in the order they were added, and
+// stops at the first error fn or the spill file returns.
+func (s *findingSpool) each(fn func(Finding) error) error {
+	r := s.reader()
+	for {
+		f, ok, err := r.next()
+		if err != nil || !ok {
+			return err
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+}
+
+// findings returns every finding added, spilled ones first. Findings
+// that cannot be read back are left out and reported in the error. It is
+// for callers that need the findings in memory.
+func (s *findingSpool) findings() ([]Finding, error) {
+	findings := make([]Finding, 0, s.len())
+	r := s.reader()
+	for {
+		f, ok, err := r.next()
+		if err != nil {
+			// The findings still in memory are not lost with the file.
+			return append(findings, r.mem...), err
+		}
+		if !ok {
+			return findings, nil
+		}
+		findings = append(findings, f)
+	}
+}
+
+// drain is findings that also removes the spill file.
+func (s *findingSpool) drain() ([]Finding, error) {
+	findings, err := s.findings()
+	s.close()
+	return findings, err
+}
+
+// close removes the spill file and empties the spool.
+func (s *findingSpool) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}
+	s.mem, s.file, s.enc, s.spilled = nil, nil, nil, 0
+}
+
+// replace moves the findings of other into s, removing those s held.
+func (s *findingSpool) replace(other *findingSpool) {
+	s.close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem, s.file, s.enc, s.spilled = other.mem, other.file, other.enc, other.spilled
+}
+
+// unspill reads the spill file back into memory when spilling had to be
+// given up, leaving a spool that either spills or holds every finding.
+func (s *findingSpool) unspill() error {
+	s.mu.Lock()
+	spilledWithoutLimit := s.file != nil && s.limit <= 0
+	s.mu.Unlock()
+	if !spilledWithoutLimit {
+		return nil
+	}
+	findings, err := s.drain()
+	s.mu.Lock()
+	s.mem = findings
+	s.mu.Unlock()
+	return err
+}
+
+// rewrite replaces the findings with what fn returns for them, passing
+// fn at most limit findings at a time, so a spilled spool is never read
+// back whole. fn may change or drop findings; each finding is passed to
+// it once, in order.
+func (s *findingSpool) rewrite(fn func([]Finding) []Finding) error {
+	if err := s.unspill(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.file == nil {
+		s.mem = fn(s.mem)
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+
+	out := &findingSpool{limit: s.limit, log: s.log, quiet: true}
+	batch := make([]Finding, 0, s.limit)
+	err := s.each(func(f Finding) error {
+		batch = append(batch, f)
+		if len(batch) >= s.limit {
+			out.add(fn(batch))
+			batch = batch[:0]
+		}
+		return nil
+	})
+	if err != nil {
+		out.close()
+		return err
+	}
+	out.add(fn(batch))
+	s.replace(out)
+	return nil
+}
+
+// sort orders the findings by less, keeping the order of equal findings.
+// Spilled findings are sorted on disk: each batch of limit findings is
+// sorted into a run of its own and the runs are then merged, holding one
+// finding per run in memory.
+func (s *findingSpool) sort(less func(a, b Finding) bool) error {
+	if err := s.unspill(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.file == nil {
+		sort.SliceStable(s.mem, func(i, j int) bool { return less(s.mem[i], s.mem[j]) })
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+
+	var runs []*findingSpool
+	defer func() {
+		for _, run := range runs {
+			run.close()
+		}
+	}()
+	batch := make([]Finding, 0, s.limit)
+	flush := func() {
+		sort.SliceStable(batch, func(i, j int) bool { return less(batch[i], batch[j]) })
+		// A limit of 1 spills the whole sorted batch.
+		run := &findingSpool{limit: 1, log: s.log, quiet: true}
+		run.add(batch)
+		runs = append(runs, run)
+		batch = batch[:0]
+	}
+	err := s.each(func(f Finding) error {
+		batch = append(batch, f)
+		if len(batch) >= s.limit {
+			flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+
+	merge := &runMerge{less: less}
+	for i, run := range runs {
+		r := run.reader()
+		f, ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			merge.heads = append(merge.heads, runHead{f: f, run: i, r: r})
+		}
+	}
+	heap.Init(merge)
+	out := &findingSpool{limit: s.limit, log: s.log, quiet: true}
+	for merge.Len() > 0 {
+		head := &merge.heads[0]
+		out.add([]Finding{head.f})
+		f, ok, err := head.r.next()
+		if err != nil {
+			out.close()
+			return err
+		}
+		if ok {
+			head.f = f
+			heap.Fix(merge, 0)
+		} else {
+			heap.Pop(merge)
+		}
+	}
+	s.replace(out)
+	return nil
+}
+
+// runHead is the next finding of one sorted run being merged.
+type runHead struct {
+	f   Finding
+	run int
+	r   *spoolReader
+}
+
+// runMerge is a heap of run heads. Equal findings come out in run order,
+// which keeps the merge stable.
+type runMerge struct {
+	heads []runHead
+	less  func(a, b Finding) bool
+}
+
+func (m *runMerge) Len() int { return len(m.heads) }
+func (m *runMerge) Less(i, j int) bool {
+	a, b := m.heads[i], m.heads[j]
+	if m.less(a.f, b.f) {
+		return true
+	}
+	if m.less(b.f, a.f) {
+		return false
+	}
+	return a.run < b.run
+}
+func (m *runMerge) Swap(i, j int)      { m.heads[i], m.heads[j] = m.heads[j], m.heads[i] }
+func (m *runMerge) Push(x interface{}) { m.heads = append(m.heads, x.(runHead)) }
+func (m *runMerge) Pop() interface{} {
+	last := m.heads[len(m.heads)-1]
+	m.heads = m.heads[:len(m.heads)-1]
+	return last
+}
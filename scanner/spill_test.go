@@ -0,0 +1,199 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// collectRepositories runs scanRepositories into a spool of its own and
+// returns what it collected.
+func collectRepositories(ctx context.Context, config *Config, stats *RequestStats) ([]Finding, error) {
+	spool := newFindingSpool(0, nil)
+	err := scanRepositories(ctx, config, stats, spool)
+	findings, _ := spool.drain()
+	return findings, err
+}
+
+func TestFindingSpoolKeepsEveryFinding(t *testing.T) {
+	spool := newFindingSpool(4, nil)
+	var want []Finding
+	for batch := 0; batch < 5; batch++ {
+		var findings []Finding
+		for i := 0; i < 3; i++ {
+			f := Finding{Repository: "o/r", FilePath: fmt.Sprintf("f%d-%d.env", batch, i), Line: i + 1, Public: i%2 == 0}
+			findings = append(findings, f)
+		}
+		want = append(want, findings...)
+		spool.add(findings)
+		if len(spool.mem) >= 4 {
+			t.Fatalf("%d findings held in memory, limit is 4", len(spool.mem))
+		}
+	}
+	if spool.file == nil {
+		t.Fatal("spool never spilled")
+	}
+	spillFile := spool.file.Name()
+
+	got, err := spool.drain()
+	if err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d findings, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("finding %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if _, err := os.Stat(spillFile); !os.IsNotExist(err) {
+		t.Errorf("spill file %s not removed", spillFile)
+	}
+}
+
+// spilledSpool returns a spool with a memory limit of 4 holding n findings,
+// most of them spilled, numbered by Line in the order they were added.
+func spilledSpool(t *testing.T, n int) *findingSpool {
+	t.Helper()
+	spool := newFindingSpool(4, ioutil.Discard)
+	t.Cleanup(spool.close)
+	for i := 0; i < n; i++ {
+		spool.add([]Finding{{Repository: fmt.Sprintf("o/r%d", i%3), FilePath: fmt.Sprintf("f%d.env", i), Line: i}})
+	}
+	if spool.file == nil {
+		t.Fatal("spool never spilled")
+	}
+	return spool
+}
+
+func TestSpoolSortsOnDisk(t *testing.T) {
+	spool := spilledSpool(t, 23)
+	if err := spool.sort(func(a, b Finding) bool { return a.Repository < b.Repository }); err != nil {
+		t.Fatal(err)
+	}
+	if spool.file == nil || len(spool.mem) >= 4 {
+		t.Fatalf("sorted spool holds %d findings in memory, want it spilled", len(spool.mem))
+	}
+	var got []Finding
+	if err := spool.each(func(f Finding) error {
+		got = append(got, f)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 23 {
+		t.Fatalf("got %d findings, want 23", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		a, b := got[i-1], got[i]
+		if a.Repository > b.Repository || a.Repository == b.Repository && a.Line > b.Line {
+			t.Fatalf("findings %d and %d out of order: %+v, %+v", i-1, i, a, b)
+		}
+	}
+}
+
+func TestSpoolRewritesInBatches(t *testing.T) {
+	spool := spilledSpool(t, 23)
+	err := spool.rewrite(func(findings []Finding) []Finding {
+		if len(findings) > 4 {
+			t.Errorf("rewrite passed %d findings at once, limit is 4", len(findings))
+		}
+		kept := findings[:0]
+		for _, f := range findings {
+			if f.Line%2 == 0 {
+				f.Severity = "HIGH"
+				kept = append(kept, f)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := spool.findings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 12 {
+		t.Fatalf("got %d findings, want 12", len(got))
+	}
+	for i, f := range got {
+		if f.Line != 2*i || f.Severity != "HIGH" {
+			t.Errorf("finding %d = %+v, want line %d rewritten", i, f, 2*i)
+		}
+	}
+}
+
+func TestSpilledFindingsWrittenLikeSlice(t *testing.T) {
+	spool := spilledSpool(t, 11)
+	findings, err := spool.findings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{"json", "csv"} {
+		var got, want bytes.Buffer
+		if err := writeFindingsFrom(&got, spool, outputOptions{format: format}); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeFindings(&want, findings, outputOptions{format: format}); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s written from the spool:\n%s\nwant:\n%s", format, got.String(), want.String())
+		}
+	}
+}
+
+func TestScanWithSpilloverLosesNoFindings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		q := r.URL.Query().Get("q")
+		var items []map[string]interface{}
+		for i := 0; i < 7; i++ {
+			items = append(items, map[string]interface{}{
+				"path":       fmt.Sprintf("file%d.env", i),
+				"html_url":   fmt.Sprintf("https://github.com/o/r/blob/main/%s/file%d.env", url.PathEscape(q), i),
+				"repository": map[string]string{"full_name": "o/r"},
+				"text_matches": []interface{}{map[string]interface{}{
+					"property": "content",
+					"fragment": "password=secret",
+					"matches":  []interface{}{map[string]interface{}{"text": "password", "indices": []int{0, 8}}},
+				}},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": len(items), "items": items})
+	}))
+	defer srv.Close()
+
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password", "secret"], "file_patterns": ["\\.env$"], "spill_threshold": 5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := runScan(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	if len(findings) != 14 {
+		t.Fatalf("got %d findings, want 14", len(findings))
+	}
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		seen[f.URL] = true
+	}
+	if len(seen) != 14 {
+		t.Errorf("%d distinct findings, want 14", len(seen))
+	}
+}
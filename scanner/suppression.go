@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+const defaultSuppressionDays = 7
+
+// suppressionStore remembers when each finding was last alerted on, so a
+// finding reported within the suppression window is marked as already
+// known instead of re-alerting every run. Once the window passes the
+// finding is reported again and the window restarts.
+type suppressionStore struct {
+	Alerted map[string]time.Time `json:"alerted"`
+}
+
+func loadSuppressionStore(path string) (*suppressionStore, error) {
+	store := &suppressionStore{Alerted: make(map[string]time.Time)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading suppression file: %v", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("error parsing suppression file: %v", err)
+	}
+	if store.Alerted == nil {
+		store.Alerted = make(map[string]time.Time)
+	}
+	return store, nil
+}
+
+func (s *suppressionStore) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling suppression store: %v", err)
+	}
+	if err := writeBytesAtomic(path, data); err != nil {
+		return fmt.Errorf("error writing suppression file: %v", err)
+	}
+	return nil
+}
+
+// apply marks findings alerted within window as already known and records
+// the rest as alerted now. Entries older than the window are pruned. It
+// returns how many findings were suppressed.
+func (s *suppressionStore) apply(findings []Finding, window time.Duration, now time.Time) int {
+	suppressed := 0
+	for i := range findings {
+		fp := findings[i].Fingerprint
+		if alerted, ok := s.Alerted[fp]; ok && now.Sub(alerted) < window {
+			findings[i].AlreadyKnown = true
+			if findings[i].Explanation != "" {
+				findings[i].Explanation += "; already reported within the suppression window"
+			}
+			suppressed++
+			continue
+		}
+		s.Alerted[fp] = now
+	}
+
+	for fp, alerted := range s.Alerted {
+		if now.Sub(alerted) >= window {
+			delete(s.Alerted, fp)
+		}
+	}
+	return suppressed
+}
+
+func (c *Config) suppressionWindow() time.Duration {
+	days := c.SuppressionDays
+	if days <= 0 {
+		days = defaultSuppressionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// applySuppressions marks recently alerted findings using the configured
+// suppression file, if any. It returns the updated store, nil without a
+// suppression file, which the caller saves with saveSuppressions only once
+// the findings have been written, so a run whose output fails alerts on
+// them again next time.
+func applySuppressions(config *Config, findings []Finding) (*suppressionStore, error) {
+	return applySpooledSuppressions(config, &findingSpool{mem: findings})
+}
+
+// applySpooledSuppressions is applySuppressions for the findings of a
+// spool, which it marks a batch at a time.
+func applySpooledSuppressions(config *Config, spool *findingSpool) (*suppressionStore, error) {
+	if config.SuppressionFile == "" {
+		return nil, nil
+	}
+	store, err := loadSuppressionStore(config.SuppressionFile)
+	if err != nil {
+		return nil, err
+	}
+	window, now := config.suppressionWindow(), time.Now().UTC()
+	suppressed := 0
+	err = spool.rewrite(func(findings []Finding) []Finding {
+		suppressed += store.apply(findings, window, now)
+		return findings
+	})
+	if err != nil {
+		return nil, err
+	}
+	if suppressed > 0 {
+		config.logf("%d finding(s) already reported within the last %v, marked as already known\n",
+			suppressed, window)
+	}
+	return store, nil
+}
+
+// saveSuppressions saves the store applySuppressions returned.
+func saveSuppressions(config *Config, store *suppressionStore) error {
+	if store == nil {
+		return nil
+	}
+	return store.save(config.SuppressionFile)
+}
@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSuppressionWindow(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	window := 7 * 24 * time.Hour
+	store := &suppressionStore{Alerted: map[string]time.Time{
+		"recent": now.Add(-2 * 24 * time.Hour),
+		"old":    now.Add(-8 * 24 * time.Hour),
+	}}
+	findings := []Finding{{Fingerprint: "recent"}, {Fingerprint: "old"}, {Fingerprint: "new"}}
+
+	if n := store.apply(findings, window, now); n != 1 {
+		t.Errorf("%d suppressed, want 1", n)
+	}
+	if !findings[0].AlreadyKnown || findings[1].AlreadyKnown || findings[2].AlreadyKnown {
+		t.Errorf("already known = %v, %v, %v; want only the finding within the window",
+			findings[0].AlreadyKnown, findings[1].AlreadyKnown, findings[2].AlreadyKnown)
+	}
+	if !store.Alerted["old"].Equal(now) || !store.Alerted["new"].Equal(now) {
+		t.Error("reported findings should restart their window")
+	}
+}
+
+func TestSuppressionsSavedOnlyWhenAsked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suppressions.json")
+	config := &Config{SuppressionFile: path}
+	findings := []Finding{{Fingerprint: "abc"}}
+
+	store, err := applySuppressions(config, findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("suppression file written before the output: %v", err)
+	}
+	if err := saveSuppressions(config, store); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadSuppressionStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Alerted["abc"]; !ok {
+		t.Error("saved store does not record the finding")
+	}
+}
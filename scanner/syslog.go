@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	syslogAppName = "github-security-scanner"
+	// syslogSDID names the structured data element; 32473 is the private
+	// enterprise number reserved for documentation.
+	syslogSDID         = "finding@32473"
+	syslogDialTimeout  = 5 * time.Second
+	syslogWriteTimeout = 5 * time.Second
+)
+
+// syslogLocalSockets are tried, in order, for a local syslog address.
+var syslogLocalSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+var syslogSeverities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3,
+	"warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// defaultSyslogSeverities maps finding severities to syslog severities
+// when syslog.severity_map leaves them out.
+var defaultSyslogSeverities = map[string]string{
+	"CRITICAL": "crit",
+	"HIGH":     "err",
+	"MEDIUM":   "warning",
+	"LOW":      "notice",
+	"INFO":     "info",
+}
+
+// syslogConfig is the syslog setting. Address is "local" for the local
+// syslog socket, or udp://host:port or tcp://host:port for a remote
+// collector. Facility defaults to local0; SeverityMap overrides the
+// syslog severity used per finding severity.
+type syslogConfig struct {
+	Address     string            `json:"address"`
+	Facility    string            `json:"facility"`
+	SeverityMap map[string]string `json:"severity_map"`
+}
+
+// validate checks the facility and severity names.
+func (s *syslogConfig) validate() error {
+	if s.Address == "" {
+		return fmt.Errorf("syslog: address is required")
+	}
+	if _, _, err := s.network(); err != nil {
+		return err
+	}
+	if _, ok := syslogFacilities[s.facility()]; !ok {
+		return fmt.Errorf("syslog: unknown facility %q", s.Facility)
+	}
+	for severity, name := range s.SeverityMap {
+		if _, err := parseSeverity(severity); err != nil {
+			return fmt.Errorf("syslog.severity_map: %v", err)
+		}
+		if _, ok := syslogSeverities[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("syslog.severity_map: unknown syslog severity %q", name)
+		}
+	}
+	return nil
+}
+
+func (s *syslogConfig) facility() string {
+	if s.Facility == "" {
+		return "local0"
+	}
+	return strings.ToLower(s.Facility)
+}
+
+// network splits Address into a network and address for net.Dial. The
+// network is empty for "local".
+func (s *syslogConfig) network() (string, string, error) {
+	if s.Address == "local" {
+		return "", "", nil
+	}
+	for _, network := range []string{"udp", "tcp"} {
+		if addr := strings.TrimPrefix(s.Address, network+"://"); addr != s.Address {
+			return network, addr, nil
+		}
+	}
+	return "", "", fmt.Errorf("syslog: address must be local, udp://host:port or tcp://host:port, got %q", s.Address)
+}
+
+// priority returns the PRI value for a finding of the given severity.
+func (s *syslogConfig) priority(severity string) int {
+	severity = strings.ToUpper(severity)
+	name := defaultSyslogSeverities[severity]
+	for k, v := range s.SeverityMap {
+		if strings.ToUpper(k) == severity {
+			name = v
+		}
+	}
+	level, ok := syslogSeverities[strings.ToLower(name)]
+	if !ok {
+		level = syslogSeverities["warning"]
+	}
+	return syslogFacilities[s.facility()]*8 + level
+}
+
+// dialSyslog connects to the configured collector. It returns the
+// connection and whether messages need octet-counting framing (TCP).
+func dialSyslog(ctx context.Context, s *syslogConfig) (net.Conn, bool, error) {
+	network, addr, err := s.network()
+	if err != nil {
+		return nil, false, err
+	}
+	dialer := &net.Dialer{Timeout: syslogDialTimeout}
+	if network != "" {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, false, fmt.Errorf("error connecting to syslog at %s: %v", s.Address, err)
+		}
+		return conn, network == "tcp", nil
+	}
+	for _, path := range syslogLocalSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := dialer.DialContext(ctx, network, path); err == nil {
+				return conn, false, nil
+			}
+		}
+	}
+	return nil, false, fmt.Errorf("error connecting to local syslog: no socket found")
+}
+
+// syslogMessage formats f as an RFC 5424 message. The snippet is left out
+// so the secret never reaches the log pipeline; the finding's fields go in
+// structured data.
+func syslogMessage(s *syslogConfig, f Finding, hostname string, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d - ", s.priority(f.Severity), now.UTC().Format(time.RFC3339), hostname, syslogAppName, os.Getpid())
+	b.WriteString("[" + syslogSDID)
+	params := []struct{ name, value string }{
+		{"repository", f.Repository},
+		{"path", f.FilePath},
+		{"line", fmt.Sprint(f.Line)},
+		{"pattern", f.Pattern},
+		{"severity", f.Severity},
+		{"fingerprint", f.Fingerprint},
+		{"scan_id", f.ScanID},
+		{"url", f.URL},
+		{"tags", strings.Join(f.Tags, ",")},
+	}
+	for _, p := range params {
+		if p.value != "" && p.value != "0" {
+			fmt.Fprintf(&b, " %s=\"%s\"", p.name, escapeSDValue(p.value))
+		}
+	}
+	b.WriteString("] ")
+	fmt.Fprintf(&b, "Potential secret (%s) in %s/%s", f.Pattern, f.Repository, f.FilePath)
+	return b.Bytes()
+}
+
+// escapeSDValue escapes the characters RFC 5424 reserves in parameter
+// values.
+func escapeSDValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
+
+// sendSyslog sends one message per finding and returns how many were sent.
+func sendSyslog(ctx context.Context, s *syslogConfig, findings []Finding) (int, error) {
+	conn, framed, err := dialSyslog(ctx, s)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	for i, f := range findings {
+		msg := syslogMessage(s, f, hostname, time.Now())
+		if framed {
+			msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+		}
+		if err := ctx.Err(); err != nil {
+			return i, fmt.Errorf("error writing to syslog: %v", err)
+		}
+		deadline := time.Now().Add(syslogWriteTimeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		conn.SetWriteDeadline(deadline)
+		if _, err := conn.Write(msg); err != nil {
+			return i, fmt.Errorf("error writing to syslog: %v", err)
+		}
+	}
+	return len(findings), nil
+}
@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var syslogTestFindings = []Finding{
+	{Repository: "acme/api", FilePath: ".env", Line: 2, Pattern: "password", Severity: "HIGH", Snippet: "password=hunter2", Fingerprint: "abc123"},
+	{Repository: "acme/web", FilePath: "config.yml", Pattern: "token", Severity: "LOW", Snippet: "token: s3cr3t"},
+}
+
+func TestSendSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	cfg := &syslogConfig{Address: "udp://" + pc.LocalAddr().String(), Facility: "local3", SeverityMap: map[string]string{"LOW": "info"}}
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	sent, err := sendSyslog(context.Background(), cfg, syslogTestFindings)
+	if err != nil || sent != 2 {
+		t.Fatalf("sendSyslog = %d, %v; want 2, nil", sent, err)
+	}
+
+	// local3 is facility 19: HIGH maps to err (3) and LOW to info (6).
+	wantPRI := []string{"<155>1 ", "<158>1 "}
+	buf := make([]byte, 4096)
+	for i, want := range wantPRI {
+		pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		msg := string(buf[:n])
+		if !strings.HasPrefix(msg, want) {
+			t.Errorf("message %d = %q, want prefix %q", i, msg, want)
+		}
+		if !strings.Contains(msg, `repository="`+syslogTestFindings[i].Repository+`"`) {
+			t.Errorf("message %d lacks the repository: %q", i, msg)
+		}
+		if strings.Contains(msg, syslogTestFindings[i].Snippet) {
+			t.Errorf("message %d leaks the snippet: %q", i, msg)
+		}
+	}
+}
+
+func TestSendSyslogTCPFramed(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var msgs []string
+		for {
+			lenField, err := r.ReadString(' ')
+			if err != nil {
+				break
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(lenField))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				break
+			}
+			msgs = append(msgs, string(msg))
+		}
+		received <- msgs
+	}()
+
+	if _, err := sendSyslog(context.Background(), &syslogConfig{Address: "tcp://" + ln.Addr().String()}, syslogTestFindings); err != nil {
+		t.Fatal(err)
+	}
+	msgs := <-received
+	if len(msgs) != 2 || !strings.HasPrefix(msgs[0], "<131>1 ") {
+		t.Errorf("received %q, want 2 framed local0 messages", msgs)
+	}
+}
+
+func TestSyslogStopsAtRunDeadline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sent, err := sendSyslog(ctx, &syslogConfig{Address: "tcp://" + ln.Addr().String()}, syslogTestFindings)
+	if err == nil || sent != 0 {
+		t.Errorf("sent %d with err %v after the deadline, want 0 and an error", sent, err)
+	}
+}
+
+func TestSyslogConfigValidation(t *testing.T) {
+	for _, cfg := range []syslogConfig{
+		{Address: "example.com:514"},
+		{Address: "local", Facility: "nope"},
+		{Address: "local", SeverityMap: map[string]string{"HIGH": "loud"}},
+	} {
+		if err := cfg.validate(); err == nil {
+			t.Errorf("%+v: expected a validation error", cfg)
+		}
+	}
+}
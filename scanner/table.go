@@ -0,0 +1,139 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// defaultTableWidth is used on a terminal when COLUMNS is not set.
+	defaultTableWidth = 120
+	tableColumnGap    = 2
+	// tableMinColumn is the narrowest a column is truncated to.
+	tableMinColumn = 8
+	tableEllipsis  = "..."
+)
+
+// tableWidth returns the width the table output should fit: COLUMNS when it
+// is set, defaultTableWidth on a terminal, and 0 (no limit) when stdout is
+// redirected, so piped output is never truncated.
+func tableWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return 0
+	}
+	return defaultTableWidth
+}
+
+// writeTable writes findings as an aligned table of severity, repository,
+// path and pattern, and tags when any finding has them, followed by a
+// totals line. When width is positive the widest columns are shortened,
+// with values truncated, until a row fits.
+func writeTable(w io.Writer, findings []Finding, width int) error {
+	header := []string{"SEVERITY", "REPOSITORY", "PATH", "PATTERN"}
+	tagged := false
+	for _, f := range findings {
+		tagged = tagged || len(f.Tags) > 0
+	}
+	if tagged {
+		header = append(header, "TAGS")
+	}
+	rows := make([][]string, len(findings))
+	for i, f := range findings {
+		rows[i] = []string{f.Severity, f.Repository, f.FilePath, f.Pattern}
+		if tagged {
+			rows[i] = append(rows[i], strings.Join(f.Tags, ","))
+		}
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	if width > 0 {
+		fitColumns(widths, width-tableColumnGap*(len(widths)-1))
+	}
+
+	var b strings.Builder
+	writeRow := func(row []string) {
+		b.Reset()
+		for i, cell := range row {
+			cell = truncateCell(cell, widths[i])
+			b.WriteString(cell)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+tableColumnGap))
+			}
+		}
+		b.WriteString("\n")
+	}
+	writeRow(header)
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		writeRow(row)
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n", tableFooter(findings))
+	return err
+}
+
+// fitColumns narrows the widest column, one character at a time, until the
+// columns add up to at most available or every column is at tableMinColumn.
+func fitColumns(widths []int, available int) {
+	for {
+		total, widest := 0, 0
+		for i, n := range widths {
+			total += n
+			if n > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= available || widths[widest] <= tableMinColumn {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// truncateCell shortens s to n characters, ending it with tableEllipsis.
+func truncateCell(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	if n <= len(tableEllipsis) {
+		return string(runes[:n])
+	}
+	return string(runes[:n-len(tableEllipsis)]) + tableEllipsis
+}
+
+// tableFooter is the totals line: the finding count and the count per
+// severity present.
+func tableFooter(findings []Finding) string {
+	counts := countBySeverity(findings)
+	var parts []string
+	for _, severity := range severityLevels {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	footer := fmt.Sprintf("Total: %d finding(s)", len(findings))
+	if len(parts) > 0 {
+		footer += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return footer
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var tableFindings = []Finding{
+	{Severity: "HIGH", Repository: "acme/api", FilePath: ".env", Pattern: "password"},
+	{Severity: "CRITICAL", Repository: "acme/infrastructure-terraform-modules", FilePath: "modules/networking/vpc/secrets/terraform.tfvars", Pattern: "aws-access-key"},
+	{Severity: "HIGH", Repository: "acme/web", FilePath: "config/prod.env", Pattern: "api_token"},
+}
+
+// columnStarts returns where each cell of line begins; cells are
+// separated by at least tableColumnGap spaces.
+func columnStarts(line string) []int {
+	runes := []rune(line)
+	var starts []int
+	for i, r := range runes {
+		if r == ' ' {
+			continue
+		}
+		if i == 0 || i >= tableColumnGap && strings.TrimSpace(string(runes[i-tableColumnGap:i])) == "" {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+func TestTableAlignsColumnsWithFooter(t *testing.T) {
+	var b strings.Builder
+	if err := writeTable(&b, tableFindings, 0); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	if len(lines) != 1+len(tableFindings)+2 {
+		t.Fatalf("got %d lines, want header, rows, blank line and footer:\n%s", len(lines), b.String())
+	}
+	header := columnStarts(lines[0])
+	if len(header) != 4 || !strings.HasPrefix(lines[0], "SEVERITY") {
+		t.Fatalf("header %q, want four columns", lines[0])
+	}
+	for _, row := range lines[1 : 1+len(tableFindings)] {
+		if got := columnStarts(row); len(got) != 4 || got[1] != header[1] || got[2] != header[2] || got[3] != header[3] {
+			t.Errorf("row %q starts columns at %v, header at %v", row, got, header)
+		}
+	}
+	if !strings.Contains(b.String(), tableFindings[1].FilePath) {
+		t.Error("value truncated without a width limit")
+	}
+	if footer := lines[len(lines)-1]; footer != "Total: 3 finding(s) (1 CRITICAL, 2 HIGH)" {
+		t.Errorf("footer = %q", footer)
+	}
+}
+
+func TestTableTruncatesToWidth(t *testing.T) {
+	var b strings.Builder
+	if err := writeTable(&b, tableFindings, 70); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, line := range strings.Split(out, "\n") {
+		if n := utf8.RuneCountInString(line); n > 70 {
+			t.Errorf("line of %d characters exceeds the width: %q", n, line)
+		}
+	}
+	if !strings.Contains(out, tableEllipsis) || strings.Contains(out, tableFindings[1].FilePath) {
+		t.Errorf("long path not truncated:\n%s", out)
+	}
+}
+
+func TestTableOutputUsesColumns(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "a-rather-long-directory-name", "nested-configuration")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "application-settings.env"), []byte("password=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(config, []byte(`{"search_patterns": ["password"], "file_patterns": ["\\.env$"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COLUMNS", "60")
+
+	stdout, stderr, err := runMain(t, dir, "-config", config, "-local-path", filepath.Join(dir, "src"), "-output", "table")
+	if err != nil {
+		t.Fatalf("scan failed: %v\n%s%s", err, stdout, stderr)
+	}
+	i := strings.Index(stdout, "SEVERITY")
+	if i < 0 {
+		t.Fatalf("no table printed:\n%s", stdout)
+	}
+	table := stdout[i:]
+	for _, line := range strings.Split(table, "\n") {
+		if utf8.RuneCountInString(line) > 60 {
+			t.Errorf("line exceeds COLUMNS=60: %q", line)
+		}
+	}
+	if !strings.Contains(table, "Total: 1 finding(s) (1 HIGH)") {
+		t.Errorf("footer missing:\n%s", table)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "findings.table")); !os.IsNotExist(err) {
+		t.Error("table output was saved to a file")
+	}
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateTags normalizes the tags config, which maps a search pattern or
+// detector name to tags attached to its findings. Tags are compared case
+// insensitively and may not contain commas or spaces, so a list of them
+// can be given to --filter-tag and written to one CSV cell.
+func validateTags(c *Config) error {
+	for rule, tags := range c.Tags {
+		normalized := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag == "" || strings.ContainsAny(tag, ", \t") {
+				return fmt.Errorf("tags: invalid tag %q for %s", tag, rule)
+			}
+			normalized = append(normalized, tag)
+		}
+		c.Tags[rule] = normalized
+	}
+	return nil
+}
+
+// tagsFor returns the tags of pattern: the detector's own tags followed by
+// any tags config entry, without duplicates.
+func (c *Config) tagsFor(pattern string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	add := func(list []string) {
+		for _, tag := range list {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	if d, ok := findDetector(pattern); ok {
+		add(d.Tags)
+	}
+	add(c.Tags[pattern])
+	return tags
+}
+
+// parseTagFilter splits a comma-separated --filter-tag value.
+func parseTagFilter(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// filterByTags keeps the findings that carry at least one of tags.
+func filterByTags(findings []Finding, tags []string) []Finding {
+	want := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		want[tag] = true
+	}
+	kept := findings[:0]
+	for _, f := range findings {
+		for _, tag := range f.Tags {
+			if want[tag] {
+				kept = append(kept, f)
+				break
+			}
+		}
+	}
+	return kept
+}
@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type taggedDetector struct{}
+
+func (taggedDetector) Name() string                  { return "test-tagged-detector" }
+func (taggedDetector) Detect(content []byte) []Match { return nil }
+func (taggedDetector) Tags() []string                { return []string{"PCI"} }
+
+func init() {
+	RegisterDetector(taggedDetector{})
+}
+
+func TestRuleTagsAttachedAndFilterable(t *testing.T) {
+	config, err := parseConfig([]byte(`{
+		"github_token": "t",
+		"search_patterns": ["password", "api_key"],
+		"tags": {"password": ["team:payments", "PCI"], "test-tagged-detector": ["team:infra"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{
+		{Repository: "o/a", FilePath: "a.env", Pattern: "password"},
+		{Repository: "o/b", FilePath: "b.env", Pattern: "api_key"},
+		{Repository: "o/c", FilePath: "c.pem", Pattern: "test-tagged-detector"},
+	}
+	classifyFindings(config, findings)
+
+	want := [][]string{{"team:payments", "pci"}, nil, {"pci", "team:infra"}}
+	for i, f := range findings {
+		if !reflect.DeepEqual(f.Tags, want[i]) {
+			t.Errorf("%s: tags %v, want %v", f.Pattern, f.Tags, want[i])
+		}
+	}
+
+	columns, err := parseCSVColumns([]string{"Pattern", "Tags"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, findings, columns); err != nil {
+		t.Fatal(err)
+	}
+	records, _ := csv.NewReader(&buf).ReadAll()
+	if records[1][1] != "team:payments,pci" {
+		t.Errorf("CSV tags cell %q", records[1][1])
+	}
+
+	kept := filterByTags(findings, parseTagFilter("PCI, other"))
+	if len(kept) != 2 || kept[0].Pattern != "password" || kept[1].Pattern != "test-tagged-detector" {
+		t.Errorf("filter by pci kept %v", kept)
+	}
+	if kept := filterByTags(kept, parseTagFilter("team:infra")); len(kept) != 1 {
+		t.Errorf("filter by team:infra kept %d findings, want 1", len(kept))
+	}
+}
+
+func TestInvalidTagRejected(t *testing.T) {
+	_, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "tags": {"password": ["a,b"]}}`))
+	if err == nil || !strings.Contains(err.Error(), "tags") {
+		t.Fatalf("got %v, want a tags error", err)
+	}
+}
+
+func TestTableShowsTags(t *testing.T) {
+	var buf bytes.Buffer
+	writeTable(&buf, []Finding{{Severity: "HIGH", Repository: "o/r", FilePath: "x", Pattern: "p", Tags: []string{"pci"}}}, 0)
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasSuffix(lines[0], "TAGS") || !strings.HasSuffix(lines[1], "pci") {
+		t.Errorf("table missing tags column:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// textMatchMediaType asks code search to include text_matches fragments,
+// so a snippet is available without fetching file contents.
+const textMatchMediaType = "application/vnd.github.text-match+json"
+
+type textMatch struct {
+	Property string `json:"property"`
+	Fragment string `json:"fragment"`
+	Matches  []struct {
+		Text    string `json:"text"`
+		Indices []int  `json:"indices"`
+	} `json:"matches"`
+}
+
+// firstTextMatch returns the fragment line containing the first content
+// match and the match's position in that line.
+func firstTextMatch(matches []textMatch) (string, []int, bool) {
+	for _, tm := range matches {
+		if tm.Property != "content" || len(tm.Matches) == 0 {
+			continue
+		}
+		indices := tm.Matches[0].Indices
+		if len(indices) == 0 || indices[0] < 0 || indices[0] > len(tm.Fragment) {
+			continue
+		}
+		start := strings.LastIndex(tm.Fragment[:indices[0]], "\n") + 1
+		end := strings.Index(tm.Fragment[indices[0]:], "\n")
+		if end < 0 {
+			end = len(tm.Fragment)
+		} else {
+			end += indices[0]
+		}
+		matchEnd := end
+		if len(indices) > 1 && indices[1] >= indices[0] && indices[1] < end {
+			matchEnd = indices[1]
+		}
+		return tm.Fragment[start:end], []int{indices[0] - start, matchEnd - start}, true
+	}
+	return "", nil, false
+}
+
+// snippetFromTextMatches returns the trimmed fragment line containing the
+// first content match, or "" when there is none.
+func snippetFromTextMatches(matches []textMatch) string {
+	line, _, _ := firstTextMatch(matches)
+	return strings.TrimSpace(line)
+}
+
+// placeholderTextMatch reports whether the value at the item's first
+// content match is a known placeholder.
+func placeholderTextMatch(item codeSearchItem, placeholders *placeholderAllowlist) bool {
+	line, loc, ok := firstTextMatch(item.TextMatches)
+	return ok && placeholders.contains(matchedValue(line, loc))
+}
+
+// describeMatch fills in the finding's snippet from the item's text_matches.
+// Only when the search response carried none does it fetch the file and
+// locate the match itself, which also yields the line number. It reports
+// false when the fetched file only matches the pattern with placeholders.
+func describeMatch(ctx context.Context, client *http.Client, config *Config, item codeSearchItem, matcher *contentMatcher, f *Finding, stats *RequestStats) bool {
+	if describeFromTextMatches(item, f) {
+		return true
+	}
+	return describeFromContent(ctx, client, config, matcher, f, stats)
+}
+
+// describeFromTextMatches fills in the snippet from the item's text_matches
+// and reports whether there was one.
+func describeFromTextMatches(item codeSearchItem, f *Finding) bool {
+	snippet := snippetFromTextMatches(item.TextMatches)
+	if snippet == "" {
+		return false
+	}
+	f.Snippet = snippet
+	f.Confidence = confidenceMedium
+	return true
+}
+
+// describeFromContent fetches the finding's file and locates the match in
+// it, filling in the line and snippet. It reports false when every match
+// of the pattern in the file is a placeholder, so the finding should be
+// dropped; a file that cannot be fetched keeps the finding as it is.
+func describeFromContent(ctx context.Context, client *http.Client, config *Config, matcher *contentMatcher, f *Finding, stats *RequestStats) bool {
+	content, err := fetchFileContent(ctx, client, config, f.Repository, f.FilePath, "", stats)
+	if err != nil {
+		config.logf("Warning: could not fetch %s in %s for a snippet: %v\n", f.FilePath, f.Repository, err)
+		return true
+	}
+	if isBinaryContent(content) {
+		return true
+	}
+	for _, match := range matcher.scan(f.Repository, f.FilePath, f.URL, content) {
+		if match.Pattern == f.Pattern {
+			f.Line = match.Line
+			f.Snippet = match.Snippet
+			f.Confidence = confidenceHigh
+			return true
+		}
+	}
+	return !matcher.onlyPlaceholders(f.Pattern, content)
+}
@@ -0,0 +1,58 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTextMatchesAvoidContentFetch(t *testing.T) {
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/repos/o/r/contents/") {
+			atomic.AddInt32(&fetches, 1)
+			w.Write([]byte("# config\nDB_PASSWORD=from-content\n"))
+			return
+		}
+		if r.Header.Get("Accept") != textMatchMediaType {
+			t.Errorf("search sent Accept %q, want %s", r.Header.Get("Accept"), textMatchMediaType)
+		}
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		w.Write([]byte(`{"total_count": 2, "items": [
+			{"path": "matched.env", "html_url": "https://github.com/o/r/blob/main/matched.env", "repository": {"full_name": "o/r"},
+			 "text_matches": [
+			   {"property": "path", "fragment": "matched.env", "matches": []},
+			   {"property": "content", "fragment": "DB_HOST=db\n  DB_PASSWORD=hunter2  \nDB_PORT=5432", "matches": [{"text": "PASSWORD", "indices": [17, 25]}]}]},
+			{"path": "unmatched.env", "html_url": "https://github.com/o/r/blob/main/unmatched.env", "repository": {"full_name": "o/r"}}
+		]}`))
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["PASSWORD"], "file_patterns": ["\\.env$"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := searchGitHub(context.Background(), config, "PASSWORD", &RequestStats{}, newTopicCache())
+	if err != nil {
+		t.Fatalf("searchGitHub: %v", err)
+	}
+	snippets := make(map[string]Finding)
+	for _, f := range findings {
+		snippets[f.FilePath] = f
+	}
+	if f := snippets["matched.env"]; f.Snippet != "DB_PASSWORD=hunter2" || f.Confidence != confidenceMedium {
+		t.Errorf("matched.env snippet %q (%s), want the fragment line", f.Snippet, f.Confidence)
+	}
+	if f := snippets["unmatched.env"]; f.Snippet != "DB_PASSWORD=from-content" || f.Line != 2 {
+		t.Errorf("unmatched.env snippet %q line %d, want it from the fetched content", f.Snippet, f.Line)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("%d content fetches, want one for the item without text_matches", n)
+	}
+}
@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func transportTLS(t *testing.T, config *Config) *http.Transport {
+	t.Helper()
+	transport, ok := config.transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport is %T, want *http.Transport", config.transport)
+	}
+	return transport
+}
+
+func TestInsecureSkipVerifyTransport(t *testing.T) {
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "insecure_skip_verify": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := transportTLS(t, config)
+	if transport.TLSClientConfig == nil || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Errorf("TLSClientConfig = %+v, want InsecureSkipVerify", transport.TLSClientConfig)
+	}
+
+	config, err = parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.transport != http.DefaultTransport {
+		t.Error("verification settings changed without either option")
+	}
+}
+
+func TestCACertFileTrustsServer(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "ca_cert_file": ` + strconv.Quote(caFile) + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := transportTLS(t, config)
+	if transport.TLSClientConfig == nil || transport.TLSClientConfig.RootCAs == nil || transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatalf("TLSClientConfig = %+v, want custom roots with verification on", transport.TLSClientConfig)
+	}
+	resp, err := newHTTPClient(config).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request with ca_cert_file: %v", err)
+	}
+	resp.Body.Close()
+
+	plain, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := newHTTPClient(plain).Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("self-signed server trusted without ca_cert_file")
+	}
+}
+
+func TestCACertFileWithoutCertificates(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "ca_cert_file": ` + strconv.Quote(caFile) + `}`)); err == nil {
+		t.Error("expected an error for a file without certificates")
+	}
+}
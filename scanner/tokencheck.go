@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// dedupeTokens drops repeated tokens, keeping the first of each, and
+// returns the distinct tokens along with the number dropped. Listing a
+// token twice only looks like rotation: both entries share one rate limit.
+func dedupeTokens(tokens []string) ([]string, int) {
+	seen := make(map[string]bool, len(tokens))
+	distinct := tokens[:0:0]
+	for _, t := range tokens {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		distinct = append(distinct, t)
+	}
+	return distinct, len(tokens) - len(distinct)
+}
+
+// tokenLabel names the idx'th configured token in messages without
+// revealing it.
+func tokenLabel(idx int, token string) string {
+	tail := token
+	if len(tail) > 4 {
+		tail = tail[len(tail)-4:]
+	}
+	return fmt.Sprintf("token %d (...%s)", idx+1, tail)
+}
+
+// validateTokens checks each pooled token against /user, which costs no
+// search budget, and removes the ones GitHub rejects from the pool. It
+// reports every rejected token and returns errUnauthorized when none is
+// left, so a misconfigured pool fails before the scan starts.
+func validateTokens(ctx context.Context, config *Config, stats *RequestStats) error {
+	client := newHTTPClient(config)
+	url := config.apiURL() + "/user"
+	var valid, invalid []string
+	for idx, token := range config.tokenPool.tokens {
+		req, err := newGitHubRequest(ctx, config, url)
+		if err != nil {
+			return fmt.Errorf("error creating request: %v", err)
+		}
+		req.Header.Set("Authorization", "token "+token)
+		stats.IncrementTotal()
+		resp, err := client.Do(req)
+		if err != nil {
+			// Unreachable is not invalid; the token stays in the pool.
+			stats.IncrementFailed()
+			config.logf("Warning: could not validate %s: %v\n", tokenLabel(idx, token), err)
+			valid = append(valid, token)
+			continue
+		}
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusOK:
+			stats.IncrementSuccess()
+			valid = append(valid, token)
+		case http.StatusUnauthorized:
+			stats.IncrementFailed()
+			invalid = append(invalid, tokenLabel(idx, token))
+		default:
+			stats.IncrementFailed()
+			config.logf("Warning: could not validate %s: unexpected status code: %d\n", tokenLabel(idx, token), resp.StatusCode)
+			valid = append(valid, token)
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	config.logf("Warning: invalid or revoked %s removed from the pool\n", strings.Join(invalid, ", "))
+	if len(valid) == 0 {
+		return errUnauthorized
+	}
+	config.tokenPool = newTokenPool(valid, config.PerTokenConcurrency)
+	return nil
+}
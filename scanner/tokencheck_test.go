@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateTokensDetected(t *testing.T) {
+	tokensFile := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(tokensFile, []byte("ghp_aaaa\nghp_cccc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := parseConfig([]byte(`{"github_token": "ghp_aaaa", "github_tokens": ["ghp_bbbb", "ghp_aaaa"], "tokens_file": "` + tokensFile + `", "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := config.duplicateTokens(); n != 2 {
+		t.Errorf("%d duplicates detected, want 2", n)
+	}
+	if want := []string{"ghp_aaaa", "ghp_bbbb", "ghp_cccc"}; !reflect.DeepEqual(config.tokenPool.tokens, want) {
+		t.Errorf("pool tokens %v, want %v", config.tokenPool.tokens, want)
+	}
+}
+
+func TestValidateTokensReportsInvalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") == "token ghp_revoked1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"login": "scanner"}`))
+	}))
+	defer srv.Close()
+
+	config, err := parseConfig([]byte(`{"github_tokens": ["ghp_good0001", "ghp_revoked1", "ghp_good0002"], "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	if err := validateTokens(context.Background(), config, &RequestStats{}); err != nil {
+		t.Fatalf("validateTokens: %v", err)
+	}
+	if want := []string{"ghp_good0001", "ghp_good0002"}; !reflect.DeepEqual(config.tokenPool.tokens, want) {
+		t.Errorf("pool tokens %v, want %v", config.tokenPool.tokens, want)
+	}
+
+	config.tokenPool = newTokenPool([]string{"ghp_revoked1"}, 0)
+	if err := validateTokens(context.Background(), config, &RequestStats{}); !errors.Is(err, errUnauthorized) {
+		t.Errorf("got %v with only an invalid token, want errUnauthorized", err)
+	}
+}
+
+func TestTokenLabelHidesToken(t *testing.T) {
+	if got := tokenLabel(1, "ghp_secretvalue9z"); got != "token 2 (...ue9z)" {
+		t.Errorf("tokenLabel = %q", got)
+	}
+}
@@ -0,0 +1,286 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenPool spreads requests across one or more GitHub tokens, preferring
+// the token with the most budget left on the rate limit resource a request
+// draws on, as reported by the X-RateLimit-Remaining and
+// X-RateLimit-Resource headers. Tokens with no report yet, or whose window
+// has reset since, count as having the most budget; ties go to the token
+// with fewer requests in flight, then to the token listed first. When
+// limit is set, no token has more than limit requests in flight at once;
+// callers block until a token frees up.
+type TokenPool struct {
+	tokens   []string
+	inFlight []int
+	limit    int
+	released chan struct{}
+	mu       sync.Mutex
+	// resources holds each token's budget per rate limit resource, as
+	// last reported by a response or polled from /rate_limit.
+	resources []map[string]RateLimitInfo
+}
+
+func newTokenPool(tokens []string, perTokenLimit int) *TokenPool {
+	return &TokenPool{
+		tokens:    tokens,
+		inFlight:  make([]int, len(tokens)),
+		limit:     perTokenLimit,
+		released:  make(chan struct{}),
+		resources: make([]map[string]RateLimitInfo, len(tokens)),
+	}
+}
+
+// requestResource returns the rate limit resource a request to path draws
+// on. GitHub Enterprise Server serves the API under /api/v3 and GraphQL at
+// /api/graphql.
+func requestResource(path string) string {
+	path = strings.TrimPrefix(path, "/api/v3")
+	switch {
+	case strings.HasPrefix(path, "/search/code"):
+		return "code_search"
+	case strings.HasPrefix(path, "/search/"):
+		return "search"
+	case path == "/graphql" || path == "/api/graphql":
+		return "graphql"
+	}
+	return "core"
+}
+
+// budget is how many requests token idx has left on resource, or -1 for
+// unknown. Older servers report code search under search.
+func (tp *TokenPool) budget(idx int, resource string, now time.Time) int {
+	info, ok := tp.resources[idx][resource]
+	if !ok && resource == "code_search" {
+		info, ok = tp.resources[idx]["search"]
+	}
+	if !ok || now.After(time.Unix(int64(info.Reset), 0)) {
+		return -1
+	}
+	return info.Remaining
+}
+
+// pickLocked returns the index of the token with the most budget on
+// resource, skipping tokens at their in-flight limit when respectLimit is
+// set. It returns -1 if every token was skipped.
+func (tp *TokenPool) pickLocked(resource string, now time.Time, respectLimit bool) int {
+	best, bestBudget := -1, 0
+	for idx := range tp.tokens {
+		if respectLimit && tp.limit > 0 && tp.inFlight[idx] >= tp.limit {
+			continue
+		}
+		b := tp.budget(idx, resource, now)
+		if best == -1 || moreBudget(b, bestBudget) ||
+			(b == bestBudget && tp.inFlight[idx] < tp.inFlight[best]) {
+			best, bestBudget = idx, b
+		}
+	}
+	return best
+}
+
+// moreBudget reports whether budget a beats b, counting unknown (-1) as
+// more than any known budget.
+func moreBudget(a, b int) bool {
+	if b == -1 {
+		return false
+	}
+	return a == -1 || a > b
+}
+
+// GetNextToken returns the token with the most remaining budget on
+// resource, ignoring in-flight limits, or "" when the pool is empty.
+func (tp *TokenPool) GetNextToken(resource string) string {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if len(tp.tokens) == 0 {
+		return ""
+	}
+	return tp.tokens[tp.pickLocked(resource, time.Now(), false)]
+}
+
+// Acquire returns the token with the most remaining budget on resource
+// that is below its in-flight limit, along with a func that must be called
+// once the request using it has completed.
+func (tp *TokenPool) Acquire(ctx context.Context, resource string) (string, func(), error) {
+	for {
+		tp.mu.Lock()
+		if idx := tp.pickLocked(resource, time.Now(), true); idx >= 0 {
+			tp.inFlight[idx]++
+			tp.mu.Unlock()
+
+			var once sync.Once
+			return tp.tokens[idx], func() { once.Do(func() { tp.release(idx) }) }, nil
+		}
+		wait := tp.released
+		tp.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return "", nil, ctx.Err()
+		case <-wait:
+		}
+	}
+}
+
+// observe records the rate limit budget reported for token by resp, under
+// the response's X-RateLimit-Resource or, without one, under resource.
+func (tp *TokenPool) observe(token, resource string, resp *http.Response) {
+	info, err := getRateLimitInfo(resp)
+	if err != nil {
+		return
+	}
+	if r := resp.Header.Get("X-RateLimit-Resource"); r != "" {
+		resource = r
+	}
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	for idx, t := range tp.tokens {
+		if t == token {
+			tp.setResourceLocked(idx, resource, *info)
+		}
+	}
+}
+
+// setResourceLocked records token idx's budget on resource.
+func (tp *TokenPool) setResourceLocked(idx int, resource string, info RateLimitInfo) {
+	if tp.resources[idx] == nil {
+		tp.resources[idx] = make(map[string]RateLimitInfo)
+	}
+	tp.resources[idx][resource] = info
+}
+
+func (tp *TokenPool) release(idx int) {
+	tp.mu.Lock()
+	tp.inFlight[idx]--
+	close(tp.released)
+	tp.released = make(chan struct{})
+	tp.mu.Unlock()
+}
+
+// Len returns the number of tokens in the pool.
+func (tp *TokenPool) Len() int {
+	if tp == nil {
+		return 0
+	}
+	return len(tp.tokens)
+}
+
+// authTransport attaches a token from the pool to each outgoing request to
+// host and holds it until the response body is closed. Requests to other
+// hosts, such as redirects to raw content or S3, go out unauthenticated.
+type authTransport struct {
+	base http.RoundTripper
+	pool *TokenPool
+	host string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.pool.Len() == 0 || req.Header.Get("Authorization") != "" || req.URL.Host != t.host {
+		return t.base.RoundTrip(req)
+	}
+
+	resource := requestResource(req.URL.Path)
+	token, release, err := t.pool.Acquire(req.Context(), resource)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	t.pool.observe(token, resource, resp)
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// configTokens returns github_token followed by github_tokens and any
+// tokens read from tokens_file, each token once.
+func (c *Config) configTokens() []string {
+	var tokens []string
+	if c.GitHubToken != "" {
+		tokens = append(tokens, c.GitHubToken)
+	}
+	for _, t := range c.GitHubTokens {
+		if t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	tokens, _ = dedupeTokens(append(tokens, c.fileTokens...))
+	return tokens
+}
+
+// duplicateTokens counts the configured tokens that repeat an earlier one.
+func (c *Config) duplicateTokens() int {
+	tokens := append([]string{c.GitHubToken}, c.GitHubTokens...)
+	var listed []string
+	for _, t := range append(tokens, c.fileTokens...) {
+		if t != "" {
+			listed = append(listed, t)
+		}
+	}
+	_, dropped := dedupeTokens(listed)
+	return dropped
+}
+
+// readTokensFile reads one token per line, skipping blank lines and lines
+// starting with #.
+func readTokensFile(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading tokens file: %v", err)
+	}
+
+	var tokens []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("tokens file %s contains no tokens", path)
+	}
+	return tokens, nil
+}
+
+// newHTTPClient returns the client used for all GitHub API requests.
+func newHTTPClient(config *Config) *http.Client {
+	pool := config.tokenPool
+	if pool == nil {
+		pool = newTokenPool(config.configTokens(), config.PerTokenConcurrency)
+	}
+	base := config.transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport:     &authTransport{base: base, pool: pool, host: config.apiHost()},
+		CheckRedirect: checkRedirect(config),
+		Timeout:       config.clientTimeout(),
+	}
+}
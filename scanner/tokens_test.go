@@ -0,0 +1,206 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPerTokenConcurrencyLimit(t *testing.T) {
+	var mu sync.Mutex
+	inFlight := make(map[string]int)
+	maxInFlight := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("Authorization")
+		mu.Lock()
+		inFlight[token]++
+		if inFlight[token] > maxInFlight[token] {
+			maxInFlight[token] = inFlight[token]
+		}
+		mu.Unlock()
+		time.Sleep(30 * time.Millisecond)
+		mu.Lock()
+		inFlight[token]--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_tokens": ["ghp_a", "ghp_b"], "per_token_concurrency": 2, "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	client := newHTTPClient(config)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequestWithContext(context.Background(), "GET", srv.URL+"/rate_limit", nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if len(maxInFlight) != 2 {
+		t.Fatalf("requests used tokens %v, want both", maxInFlight)
+	}
+	for token, n := range maxInFlight {
+		if n > 2 {
+			t.Errorf("%s had %d requests in flight, want at most 2", token, n)
+		}
+	}
+}
+
+func TestTokensFileFillsPool(t *testing.T) {
+	tokensFile := filepath.Join(t.TempDir(), "tokens")
+	data := "# scanner tokens\nghp_first\n\n   \n  ghp_second  \n# retired: ghp_old\nghp_third\n"
+	if err := os.WriteFile(tokensFile, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := parseConfig([]byte(`{"tokens_file": "` + tokensFile + `", "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ghp_first", "ghp_second", "ghp_third"}; !reflect.DeepEqual(config.tokenPool.tokens, want) {
+		t.Errorf("pool tokens %v, want %v", config.tokenPool.tokens, want)
+	}
+}
+
+func TestMissingTokensFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := parseConfig([]byte(`{"tokens_file": "` + missing + `", "search_patterns": ["password"]}`)); err == nil {
+		t.Error("parseConfig accepted a missing tokens_file")
+	}
+}
+
+func TestPoolPrefersTokenWithMostBudget(t *testing.T) {
+	remaining := map[string]int{"token ghp_low": 5, "token ghp_high": 4000, "token ghp_mid": 300}
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	var mu sync.Mutex
+	var used []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("Authorization")
+		mu.Lock()
+		used = append(used, token)
+		mu.Unlock()
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining[token]))
+		w.Header().Set("X-RateLimit-Reset", reset)
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_tokens": ["ghp_low", "ghp_mid", "ghp_high"], "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	client := newHTTPClient(config)
+
+	for i := 0; i < 6; i++ {
+		resp, err := client.Get(srv.URL + "/rate_limit")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// Tokens without a report yet are tried first, in listed order; then
+	// the one with the most budget left wins.
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"token ghp_low", "token ghp_mid", "token ghp_high", "token ghp_high", "token ghp_high", "token ghp_high"}
+	if !reflect.DeepEqual(used, want) {
+		t.Errorf("tokens used %v, want %v", used, want)
+	}
+}
+
+func TestPoolTiesGoToFirstToken(t *testing.T) {
+	pool := newTokenPool([]string{"a", "b", "c"}, 0)
+	reset := int(time.Now().Add(time.Hour).Unix())
+	for i := range pool.tokens {
+		pool.setResourceLocked(i, "core", RateLimitInfo{Remaining: 100, Reset: reset})
+	}
+	for i := 0; i < 3; i++ {
+		if got := pool.GetNextToken("core"); got != "a" {
+			t.Fatalf("GetNextToken() = %s with equal budgets, want a", got)
+		}
+	}
+}
+
+func TestPoolPicksByRequestResource(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	// Token a has spent its code search budget, b most of its core budget.
+	budgets := map[string]map[string]string{
+		"token a": {"core": "4000", "code_search": "1"},
+		"token b": {"core": "10", "code_search": "9"},
+	}
+	var mu sync.Mutex
+	var used []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource := requestResource(r.URL.Path)
+		mu.Lock()
+		used = append(used, resource+" "+r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Header().Set("X-RateLimit-Resource", resource)
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", budgets[r.Header.Get("Authorization")][resource])
+		w.Header().Set("X-RateLimit-Reset", reset)
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_tokens": ["a", "b"], "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	client := newHTTPClient(config)
+
+	// Two requests per resource report every token's budget, then each
+	// resource goes to the token with the most budget left on it.
+	for _, path := range []string{"/repos/o/r", "/repos/o/r", "/search/code", "/search/code", "/repos/o/r", "/search/code"} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := used[4:]; !reflect.DeepEqual(got, []string{"core token a", "code_search token b"}) {
+		t.Errorf("requests went to %v, want core to a and code search to b", got)
+	}
+}
+
+func TestEmptyPoolHasNoNextToken(t *testing.T) {
+	if got := newTokenPool(nil, 0).GetNextToken("core"); got != "" {
+		t.Errorf("GetNextToken() = %q on an empty pool, want none", got)
+	}
+}
+
+func TestRequestResource(t *testing.T) {
+	for path, want := range map[string]string{
+		"/search/code":                           "code_search",
+		"/api/v3/search/code":                    "code_search",
+		"/search/repositories":                   "search",
+		"/graphql":                               "graphql",
+		"/api/graphql":                           "graphql",
+		"/repos/o/r/contents/src/search/code.go": "core",
+		"/api/v3/repos/o/r":                      "core",
+	} {
+		if got := requestResource(path); got != want {
+			t.Errorf("requestResource(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
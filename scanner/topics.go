@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// topicCache memoizes repository topic lookups so each repository is only
+// fetched once per scan, no matter how many findings it produces.
+type topicCache struct {
+	topics map[string][]string
+	mu     sync.Mutex
+}
+
+func newTopicCache() *topicCache {
+	return &topicCache{topics: make(map[string][]string)}
+}
+
+func (tc *topicCache) get(ctx context.Context, client *http.Client, config *Config, repo string, stats *RequestStats) ([]string, error) {
+	tc.mu.Lock()
+	topics, ok := tc.topics[repo]
+	tc.mu.Unlock()
+	if ok {
+		return topics, nil
+	}
+
+	topics, err := fetchRepoTopics(ctx, client, config, repo, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	tc.mu.Lock()
+	tc.topics[repo] = topics
+	tc.mu.Unlock()
+	return topics, nil
+}
+
+func fetchRepoTopics(ctx context.Context, client *http.Client, config *Config, repo string, stats *RequestStats) ([]string, error) {
+	var result struct {
+		Names []string `json:"names"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/topics", config.apiURL(), repo)
+	if err := getJSON(ctx, client, config, url, stats, &result); err != nil {
+		return nil, fmt.Errorf("error fetching topics for %s: %v", repo, err)
+	}
+	return result.Names, nil
+}
+
+// matchesTopics reports whether the repository carries at least one of the
+// wanted topics. Topics are compared case-insensitively.
+func matchesTopics(repoTopics, wanted []string) bool {
+	for _, w := range wanted {
+		for _, t := range repoTopics {
+			if strings.EqualFold(t, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// topicsServer serves a code search whose results span three repositories
+// and the topics of each, counting topic lookups per repository.
+type topicsServer struct {
+	mu      sync.Mutex
+	lookups map[string]int
+}
+
+func (s *topicsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-RateLimit-Limit", "30")
+	w.Header().Set("X-RateLimit-Remaining", "29")
+	w.Header().Set("X-RateLimit-Reset", "0")
+	if strings.HasSuffix(r.URL.Path, "/topics") {
+		repo := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/repos/"), "/topics")
+		s.mu.Lock()
+		s.lookups[repo]++
+		s.mu.Unlock()
+		topics := map[string][]string{
+			"acme/api":  {"go", "Production"},
+			"acme/docs": {"docs"},
+			"acme/web":  {},
+		}[repo]
+		json.NewEncoder(w).Encode(map[string][]string{"names": topics})
+		return
+	}
+	var items []map[string]interface{}
+	for _, hit := range []string{"acme/api:.env", "acme/docs:.env", "acme/api:prod.env", "acme/web:.env"} {
+		repo, path, _ := strings.Cut(hit, ":")
+		items = append(items, map[string]interface{}{
+			"path":       path,
+			"html_url":   "https://github.com/" + repo + "/blob/main/" + path,
+			"repository": map[string]string{"full_name": repo},
+			"text_matches": []interface{}{map[string]interface{}{
+				"property": "content",
+				"fragment": "password=hunter2",
+				"matches":  []interface{}{map[string]interface{}{"text": "password", "indices": []int{0, 8}}},
+			}},
+		})
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"total_count": len(items), "items": items})
+}
+
+func TestTopicsFilterDropsOtherRepositories(t *testing.T) {
+	mock := &topicsServer{lookups: make(map[string]int)}
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"], "topics": ["production"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := searchGitHubQuery(context.Background(), config, "password", "", &RequestStats{}, newTopicCache())
+	if err != nil {
+		t.Fatalf("searchGitHubQuery: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want the 2 in acme/api", len(findings))
+	}
+	for _, f := range findings {
+		if f.Repository != "acme/api" {
+			t.Errorf("finding in %s, which lacks the production topic", f.Repository)
+		}
+	}
+	for repo, n := range mock.lookups {
+		if n != 1 {
+			t.Errorf("topics of %s fetched %d times, want once", repo, n)
+		}
+	}
+	if len(mock.lookups) != 3 {
+		t.Errorf("topics fetched for %d repositories, want 3", len(mock.lookups))
+	}
+}
@@ -0,0 +1,338 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracing is configured through the standard OpenTelemetry environment
+// variables and exported as OTLP/HTTP JSON (or to stderr with the console
+// exporter). When none of them are set, no tracer is installed and every
+// span operation is a nil-receiver no-op.
+
+const (
+	defaultOTLPEndpoint = "http://localhost:4318"
+	tracerScopeName     = "github-security-scanner"
+	spanBatchSize       = 512
+)
+
+type spanExporter interface {
+	export(spans []*span) error
+}
+
+type tracer struct {
+	serviceName string
+	exporter    spanExporter
+	pending     []*span
+	mu          sync.Mutex
+}
+
+type span struct {
+	tracer   *tracer
+	name     string
+	traceID  string
+	spanID   string
+	parentID string
+	start    time.Time
+	end      time.Time
+	attrs    map[string]interface{}
+	err      error
+	mu       sync.Mutex
+}
+
+type spanContextKey struct{}
+type tracerContextKey struct{}
+
+// newTracerFromEnv builds a tracer from OTEL_* environment variables, or
+// returns nil when tracing is not configured.
+func newTracerFromEnv() *tracer {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil
+	}
+
+	exporterName := strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER"))
+	endpointSet := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+	if exporterName == "" && endpointSet {
+		exporterName = "otlp"
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = tracerScopeName
+	}
+
+	switch exporterName {
+	case "otlp":
+		return &tracer{serviceName: serviceName, exporter: newOTLPExporterFromEnv(serviceName)}
+	case "console":
+		return &tracer{serviceName: serviceName, exporter: &consoleExporter{serviceName: serviceName, w: os.Stderr}}
+	default:
+		return nil
+	}
+}
+
+func withTracer(ctx context.Context, t *tracer) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tracerContextKey{}, t)
+}
+
+// startSpan starts a child of the span in ctx, or a new trace if there is
+// none. It returns a nil span when no tracer is installed.
+func startSpan(ctx context.Context, name string) (context.Context, *span) {
+	t, _ := ctx.Value(tracerContextKey{}).(*tracer)
+	if t == nil {
+		return ctx, nil
+	}
+
+	s := &span{
+		tracer: t,
+		name:   name,
+		spanID: randomHex(8),
+		start:  time.Now(),
+		attrs:  make(map[string]interface{}),
+	}
+	if parent, ok := ctx.Value(spanContextKey{}).(*span); ok {
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+	} else {
+		s.traceID = randomHex(16)
+	}
+	return context.WithValue(ctx, spanContextKey{}, s), s
+}
+
+func (s *span) setAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs[key] = value
+	s.mu.Unlock()
+}
+
+func (s *span) recordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+func (s *span) finish() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tracer.enqueue(s)
+}
+
+func (t *tracer) enqueue(s *span) {
+	t.mu.Lock()
+	t.pending = append(t.pending, s)
+	var batch []*span
+	if len(t.pending) >= spanBatchSize {
+		batch, t.pending = t.pending, nil
+	}
+	t.mu.Unlock()
+
+	if batch != nil {
+		if err := t.exporter.export(batch); err != nil {
+			fmt.Printf("Warning: error exporting spans: %v\n", err)
+		}
+	}
+}
+
+// shutdown exports any spans that have not been sent yet.
+func (t *tracer) shutdown() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	batch := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+
+	if len(batch) > 0 {
+		if err := t.exporter.export(batch); err != nil {
+			fmt.Printf("Warning: error exporting spans: %v\n", err)
+		}
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// OTLP JSON encoding, see opentelemetry-proto's JSON mapping.
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+func otlpValue(v interface{}) map[string]interface{} {
+	switch val := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": val}
+	case int:
+		return map[string]interface{}{"intValue": strconv.Itoa(val)}
+	case bool:
+		return map[string]interface{}{"boolValue": val}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(val)}
+	}
+}
+
+func (s *span) toOTLP() otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := otlpSpan{
+		TraceID:           s.traceID,
+		SpanID:            s.spanID,
+		ParentSpanID:      s.parentID,
+		Name:              s.name,
+		Kind:              1, // SPAN_KIND_INTERNAL
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+	for k, v := range s.attrs {
+		out.Attributes = append(out.Attributes, otlpKeyValue{Key: k, Value: otlpValue(v)})
+	}
+	if s.err != nil {
+		out.Status.Code = 2 // STATUS_CODE_ERROR
+		out.Status.Message = s.err.Error()
+	}
+	return out
+}
+
+func otlpPayload(serviceName string, spans []*span) map[string]interface{} {
+	converted := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		converted = append(converted, s.toOTLP())
+	}
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []otlpKeyValue{{Key: "service.name", Value: otlpValue(serviceName)}},
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]string{"name": tracerScopeName},
+						"spans": converted,
+					},
+				},
+			},
+		},
+	}
+}
+
+type otlpExporter struct {
+	serviceName string
+	endpoint    string
+	headers     map[string]string
+	client      *http.Client
+}
+
+func newOTLPExporterFromEnv(serviceName string) *otlpExporter {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if base == "" {
+			base = defaultOTLPEndpoint
+		}
+		endpoint = strings.TrimRight(base, "/") + "/v1/traces"
+	}
+
+	headers := make(map[string]string)
+	for _, env := range []string{"OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_HEADERS"} {
+		for _, pair := range strings.Split(os.Getenv(env), ",") {
+			if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 {
+				headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+			}
+		}
+	}
+
+	return &otlpExporter{
+		serviceName: serviceName,
+		endpoint:    endpoint,
+		headers:     headers,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *otlpExporter) export(spans []*span) error {
+	data, err := json.Marshal(otlpPayload(e.serviceName, spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// consoleExporter writes each batch as a single OTLP JSON document per line.
+type consoleExporter struct {
+	serviceName string
+	w           io.Writer
+	mu          sync.Mutex
+}
+
+func (e *consoleExporter) export(spans []*span) error {
+	data, err := json.Marshal(otlpPayload(e.serviceName, spans))
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = fmt.Fprintln(e.w, string(data))
+	return err
+}
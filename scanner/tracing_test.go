@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordingExporter keeps exported spans in memory.
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []*span
+}
+
+func (e *recordingExporter) export(spans []*span) error {
+	e.mu.Lock()
+	e.spans = append(e.spans, spans...)
+	e.mu.Unlock()
+	return nil
+}
+
+func TestScanSpans(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "17")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": 0, "items": []interface{}{}})
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"], "file_patterns": ["\\.env$"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	exporter := &recordingExporter{}
+	tr := &tracer{serviceName: "test", exporter: exporter}
+	ctx, scanSpan := startSpan(withTracer(context.Background(), tr), "scan")
+	if _, err := searchPattern(ctx, config, "password", &RequestStats{}, newTopicCache()); err != nil {
+		t.Fatalf("searchPattern: %v", err)
+	}
+	scanSpan.finish()
+	tr.shutdown()
+
+	byName := make(map[string]*span)
+	for _, s := range exporter.spans {
+		byName[s.name] = s
+	}
+	root, pattern, request := byName["scan"], byName["pattern"], byName["http.request"]
+	if root == nil || pattern == nil || request == nil {
+		t.Fatalf("exported %d spans without scan, pattern and http.request", len(exporter.spans))
+	}
+	if pattern.parentID != root.spanID || request.parentID != pattern.spanID || request.traceID != root.traceID {
+		t.Error("spans do not nest scan > pattern > http.request in one trace")
+	}
+	if pattern.attrs["pattern"] != "password" {
+		t.Errorf("pattern span attributes %v", pattern.attrs)
+	}
+	if request.attrs["http.status_code"] != http.StatusOK || request.attrs["github.ratelimit.remaining"] != "17" || request.attrs["http.url"] == nil {
+		t.Errorf("request span attributes %v", request.attrs)
+	}
+}
+
+func TestNoTracerIsNoOp(t *testing.T) {
+	ctx := context.Background()
+	got, s := startSpan(ctx, "scan")
+	if s != nil || got != ctx {
+		t.Fatal("startSpan without a tracer returned a span")
+	}
+	s.setAttribute("k", "v")
+	s.recordError(context.Canceled)
+	s.finish()
+}
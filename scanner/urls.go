@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"net/url"
+	"strings"
+)
+
+// rawContentHost serves raw file content for github.com repositories.
+const rawContentHost = "raw.githubusercontent.com"
+
+// canonicalURL rewrites a file URL to one canonical form: lower-case scheme
+// and host without the default port, no query string, and the blob view
+// rather than raw content, whether the raw URL is the
+// raw.githubusercontent.com form or the /owner/repo/raw/ref/path form.
+// URLs that do not parse are returned unchanged.
+func canonicalURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if u.Scheme == "https" {
+		u.Host = strings.TrimSuffix(u.Host, ":443")
+	}
+	u.RawQuery = ""
+	u.ForceQuery = false
+
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 4)
+	switch {
+	case u.Host == rawContentHost && len(parts) == 4:
+		// /owner/repo/ref/path
+		u.Host = "github.com"
+		u.Path = "/" + strings.Join([]string{parts[0], parts[1], "blob", parts[2], parts[3]}, "/")
+	case len(parts) == 4 && parts[2] == "raw":
+		// /owner/repo/raw/ref/path
+		u.Path = "/" + strings.Join([]string{parts[0], parts[1], "blob", parts[3]}, "/")
+	}
+	u.RawPath = ""
+	return u.String()
+}
+
+// normalizeFindingURLs replaces each finding's URL with its canonical form
+// for normalize_urls, keeping the URL the API returned in OriginalURL when
+// the two differ.
+func normalizeFindingURLs(findings []Finding) {
+	for i := range findings {
+		canonical := canonicalURL(findings[i].URL)
+		if canonical != findings[i].URL {
+			findings[i].OriginalURL = findings[i].URL
+			findings[i].URL = canonical
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package scanner
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	const want = "https://github.com/acme/api/blob/main/config/app.env"
+	variants := []string{
+		want,
+		"https://GitHub.com/acme/api/blob/main/config/app.env",
+		"HTTPS://github.com:443/acme/api/blob/main/config/app.env",
+		"https://github.com/acme/api/blob/main/config/app.env?ref=abc123",
+		"https://github.com/acme/api/raw/main/config/app.env",
+		"https://raw.githubusercontent.com/acme/api/main/config/app.env",
+	}
+	for _, v := range variants {
+		if got := canonicalURL(v); got != want {
+			t.Errorf("canonicalURL(%q) = %q, want %q", v, got, want)
+		}
+	}
+	if got := canonicalURL("not a url"); got != "not a url" {
+		t.Errorf("unparseable URL rewritten to %q", got)
+	}
+}
+
+func TestNormalizeFindingURLsKeepsOriginal(t *testing.T) {
+	findings := []Finding{
+		{URL: "https://raw.githubusercontent.com/acme/api/main/app.env"},
+		{URL: "https://github.com/acme/api/blob/main/app.env"},
+	}
+	normalizeFindingURLs(findings)
+	if findings[0].URL != "https://github.com/acme/api/blob/main/app.env" ||
+		findings[0].OriginalURL != "https://raw.githubusercontent.com/acme/api/main/app.env" {
+		t.Errorf("raw URL normalized to %+v", findings[0])
+	}
+	if findings[1].OriginalURL != "" {
+		t.Errorf("canonical URL got an original %q", findings[1].OriginalURL)
+	}
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// visibilityCache memoizes whether repositories are public, so each
+// repository is looked up at most once per scan. Code search results carry
+// the repository's private flag and need no lookup; direct repository
+// scans look it up only when a repository produced findings.
+type visibilityCache struct {
+	public map[string]bool
+	mu     sync.Mutex
+}
+
+func newVisibilityCache() *visibilityCache {
+	return &visibilityCache{public: make(map[string]bool)}
+}
+
+func (vc *visibilityCache) get(ctx context.Context, client *http.Client, config *Config, repo string, stats *RequestStats) (bool, error) {
+	vc.mu.Lock()
+	public, ok := vc.public[repo]
+	vc.mu.Unlock()
+	if ok {
+		return public, nil
+	}
+
+	var result struct {
+		Private bool `json:"private"`
+	}
+	url := fmt.Sprintf("%s/repos/%s", config.apiURL(), repo)
+	if err := getJSON(ctx, client, config, url, stats, &result); err != nil {
+		return false, fmt.Errorf("error fetching visibility of %s: %v", repo, err)
+	}
+
+	vc.mu.Lock()
+	vc.public[repo] = !result.Private
+	vc.mu.Unlock()
+	return !result.Private, nil
+}
+
+// markPublic sets Public on findings from repo. A failed lookup is logged
+// and leaves the findings marked private, which is never escalated.
+func (vc *visibilityCache) markPublic(ctx context.Context, client *http.Client, config *Config, repo string, findings []Finding, stats *RequestStats) {
+	if len(findings) == 0 {
+		return
+	}
+	public, err := vc.get(ctx, client, config, repo, stats)
+	if err != nil {
+		config.logf("Warning: %v\n", err)
+		return
+	}
+	for i := range findings {
+		findings[i].Public = public
+	}
+}
@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPublicRepoFindingsEscalated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "29")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		var items []map[string]interface{}
+		for _, repo := range []struct {
+			name    string
+			private bool
+		}{{"acme/public", false}, {"acme/private", true}} {
+			items = append(items, map[string]interface{}{
+				"path":       "app.env",
+				"html_url":   "https://github.com/" + repo.name + "/blob/main/app.env",
+				"repository": map[string]interface{}{"full_name": repo.name, "private": repo.private},
+				"text_matches": []interface{}{map[string]interface{}{
+					"property": "content",
+					"fragment": "hostname",
+					"matches":  []interface{}{map[string]interface{}{"text": "hostname", "indices": []int{0, 8}}},
+				}},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": len(items), "items": items})
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["hostname"], "file_patterns": ["\\.env$"], "escalate_public": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+
+	findings, err := runScan(context.Background(), config, &RequestStats{})
+	if err != nil {
+		t.Fatalf("runScan: %v", err)
+	}
+	classifyFindings(config, findings)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2", len(findings))
+	}
+	for _, f := range findings {
+		wantPublic, wantSeverity := f.Repository == "acme/public", "MEDIUM"
+		if wantPublic {
+			wantSeverity = "HIGH"
+		}
+		if f.Public != wantPublic || f.Severity != wantSeverity {
+			t.Errorf("%s: public %v severity %s, want %v %s", f.Repository, f.Public, f.Severity, wantPublic, wantSeverity)
+		}
+	}
+}
+
+func TestVisibilityLookedUpOncePerRepo(t *testing.T) {
+	var lookups int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/api" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		atomic.AddInt32(&lookups, 1)
+		w.Write([]byte(`{"private": false}`))
+	}))
+	defer srv.Close()
+	config, err := parseConfig([]byte(`{"github_token": "t", "search_patterns": ["password"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.apiBase = srv.URL
+	client := newHTTPClient(config)
+	vc := newVisibilityCache()
+
+	for i := 0; i < 3; i++ {
+		findings := []Finding{{Repository: "acme/api"}}
+		vc.markPublic(context.Background(), client, config, "acme/api", findings, &RequestStats{})
+		if !findings[0].Public {
+			t.Fatalf("lookup %d did not mark the finding public", i)
+		}
+	}
+	vc.markPublic(context.Background(), client, config, "acme/other", nil, &RequestStats{})
+	if n := atomic.LoadInt32(&lookups); n != 1 {
+		t.Errorf("%d visibility lookups, want 1", n)
+	}
+}